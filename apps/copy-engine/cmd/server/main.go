@@ -11,11 +11,14 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/hyperdash/copy-engine/internal/config"
+	"github.com/hyperdash/copy-engine/internal/database"
 	"github.com/hyperdash/copy-engine/internal/engine"
 	"github.com/hyperdash/copy-engine/internal/exchange"
 	"github.com/hyperdash/copy-engine/internal/risk"
 	"github.com/hyperdash/copy-engine/internal/server"
+	"github.com/hyperdash/copy-engine/internal/services"
 	"github.com/joho/godotenv"
+	"github.com/sirupsen/logrus"
 )
 
 func main() {
@@ -30,22 +33,98 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	// Initialize dependencies
-	exchangeAdapter := exchange.NewHyperliquidAdapter(cfg.Hyperliquid)
-	riskManager := risk.NewManager(cfg.Risk)
-	copyEngine := engine.NewEngine(cfg.Engine, exchangeAdapter, riskManager)
-
 	// Start the engine
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Initialize dependencies
+	logger := logrus.New()
+	postgres, err := database.NewPostgreSQL(cfg.Database.URL, logger)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer postgres.Close()
+
+	exchangeAdapter := exchange.NewHyperliquidAdapter(cfg.Hyperliquid)
+	if cfg.Hyperliquid.SecretKey != "" {
+		signer, err := exchange.NewPrivateKeySigner(cfg.Hyperliquid.SecretKey, !cfg.Hyperliquid.TestNet)
+		if err != nil {
+			log.Fatalf("Failed to load Hyperliquid signing key: %v", err)
+		}
+		exchangeAdapter.SetSigner(signer)
+	}
+
+	// Sign every account's orders with the agent key its owner approved. The engine
+	// trades on its first tick, so the keys must be loaded before it starts.
+	keyStore, err := services.NewAgentKeyStore(postgres, exchangeAdapter, cfg.KeyStore, !cfg.Hyperliquid.TestNet, logger)
+	if err != nil {
+		log.Fatalf("Failed to create agent key store: %v", err)
+	}
+	if err := keyStore.Start(ctx); err != nil {
+		log.Fatalf("Failed to start agent key store: %v", err)
+	}
+	exchangeAdapter.SetSignerProvider(keyStore)
+
+	riskManager := risk.NewManager(cfg.Risk)
+	copyEngine := engine.NewEngine(cfg, exchangeAdapter, riskManager, engine.NewPostgresStore(postgres.Pool()))
+
 	if err := copyEngine.Start(ctx); err != nil {
 		log.Fatalf("Failed to start copy engine: %v", err)
 	}
 
+	// Keep follower positions in line with the exchange
+	reconciler := services.NewReconciler(postgres, exchangeAdapter, cfg.Reconciler, logger)
+	if err := reconciler.Start(ctx); err != nil {
+		log.Fatalf("Failed to start position reconciler: %v", err)
+	}
+
+	// Copy trader fills published on Redis or Kafka, or streamed from the exchange, to their followers
+	redis, err := database.NewRedis(cfg.Redis.Addr, cfg.Redis.Password, cfg.Redis.DB, logger)
+	if err != nil {
+		log.Fatalf("Failed to connect to redis: %v", err)
+	}
+	defer redis.Close()
+
+	copyService := services.NewCopyEngine(postgres, redis, exchangeAdapter, cfg.Engine, logger)
+	if err := copyService.Start(ctx); err != nil {
+		log.Fatalf("Failed to start copy service: %v", err)
+	}
+
+	var ingestor services.TradeIngestor
+	switch cfg.Ingestion.Transport {
+	case "kafka":
+		ingestor = services.NewKafkaTradeIngestor(services.NewKafkaReader(cfg.Kafka), postgres, copyService, cfg.Ingestion, logger)
+	case "websocket":
+		stream := exchange.NewHyperliquidStream(cfg.Hyperliquid)
+		ingestor = services.NewWebSocketTradeIngestor(stream, postgres, copyService, cfg.Ingestion, logger)
+	default:
+		ingestor = services.NewTradeIngestor(redis, copyService, cfg.Ingestion, logger)
+	}
+	if err := ingestor.Start(ctx); err != nil {
+		log.Fatalf("Failed to start trade ingestor: %v", err)
+	}
+
+	// Publish execution, position and risk events from the outbox
+	var sinks []services.EventSink
+	for _, sink := range cfg.Events.Sinks {
+		switch sink {
+		case "redis":
+			sinks = append(sinks, services.NewRedisEventSink(redis, cfg.Events))
+		case "kafka":
+			sinks = append(sinks, services.NewKafkaEventSink(services.NewKafkaWriter(cfg.Kafka, cfg.Events)))
+		}
+	}
+	publisher := services.NewOutboxPublisher(postgres, sinks, cfg.Events, logger)
+	if err := publisher.Start(ctx); err != nil {
+		log.Fatalf("Failed to start outbox publisher: %v", err)
+	}
+
 	// Setup HTTP server
 	gin.SetMode(gin.ReleaseMode)
-	router := server.SetupRouter(copyEngine)
+	if cfg.Server.APIToken == "" {
+		log.Println("COPY_ENGINE_API_TOKEN is not set; the strategy API accepts unauthenticated requests")
+	}
+	router := server.SetupRouter(copyEngine, exchangeAdapter, cfg.Server.APIToken)
 
 	srv := &http.Server{
 		Addr:    ":" + cfg.Server.Port,
@@ -75,10 +154,30 @@ func main() {
 		log.Printf("Server forced to shutdown: %v", err)
 	}
 
+	if err := reconciler.Stop(); err != nil {
+		log.Printf("Error stopping position reconciler: %v", err)
+	}
+
+	if err := ingestor.Stop(); err != nil {
+		log.Printf("Error stopping trade ingestor: %v", err)
+	}
+
+	if err := copyService.Stop(); err != nil {
+		log.Printf("Error stopping copy service: %v", err)
+	}
+
+	if err := publisher.Stop(); err != nil {
+		log.Printf("Error stopping outbox publisher: %v", err)
+	}
+
 	// Stop the engine
 	if err := copyEngine.Stop(ctx); err != nil {
 		log.Printf("Error stopping copy engine: %v", err)
 	}
 
+	if err := keyStore.Stop(); err != nil {
+		log.Printf("Error stopping agent key store: %v", err)
+	}
+
 	log.Println("Server exited")
 }
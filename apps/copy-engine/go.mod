@@ -3,7 +3,9 @@ module github.com/hyperdash/copy-engine
 go 1.21
 
 require (
+	github.com/gin-gonic/gin v1.9.1
 	github.com/hyperdash/shared-types v1.0.0
+	github.com/joho/godotenv v1.5.1
 	github.com/jackc/pgx/v5 v5.4.0
 	github.com/go-redis/redis/v9 v9.0.5
 	github.com/gorilla/websocket v1.5.0
@@ -12,9 +14,13 @@ require (
 	github.com/sirupsen/logrus v1.9.0
 	github.com/golang-jwt/jwt/v5 v5.0.0
 	github.com/google/uuid v1.3.0
+	github.com/segmentio/kafka-go v0.4.47
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.2.0
+	golang.org/x/crypto v0.36.0
 )
 
 require (
+	github.com/alicebob/miniredis/v2 v2.31.1
 	github.com/stretchr/testify/assert v1.8.4
 	github.com/stretchr/testify/mock v1.6.0
 	github.com/stretchr/testify/suite v1.7.0
@@ -1,20 +1,34 @@
 package config
 
 import (
+	"encoding/hex"
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 )
 
+// exchangeMinOrderValue is the smallest order value in USD Hyperliquid accepts for
+// orders that are not reduce only
+const exchangeMinOrderValue = 10.0
+
 type Config struct {
 	Server    ServerConfig
 	Engine    EngineConfig
 	Hyperliquid HyperliquidConfig
 	Risk      RiskConfig
+	Database  DatabaseConfig
+	Redis     RedisConfig
+	Ingestion IngestionConfig
+	Kafka     KafkaConfig
+	Events    EventsConfig
+	Reconciler ReconcilerConfig
+	KeyStore  KeyStoreConfig
 }
 
 type ServerConfig struct {
-	Port string
+	Port     string
+	APIToken string // bearer token required on /api/v1; empty leaves the API unauthenticated
 }
 
 type EngineConfig struct {
@@ -23,6 +37,8 @@ type EngineConfig struct {
 	AlignmentThreshold  float64
 	RetryAttempts       int
 	RetryBackoffBase    int // seconds
+	MaxChildOrderSize   float64 // USD notional per child order
+	StaleExecutionAfter int     // seconds an unfinished execution may go without an update before it is recovered
 }
 
 type HyperliquidConfig struct {
@@ -30,20 +46,90 @@ type HyperliquidConfig struct {
 	APIKey        string
 	SecretKey     string
 	TestNet       bool
+	WSURL         string // WebSocket endpoint, derived from BaseURL when empty
+	VaultAddress  string // vault or sub-account traded on behalf of, if any
+	RateLimitWeight     int // request weight per minute the adapter may spend
+	CoalesceWindow      int // milliseconds an info query result is shared with identical queries
+	MetaRefreshInterval int // seconds asset metadata (tick and lot sizes, leverage) is cached
+}
+
+type DatabaseConfig struct {
+	URL string
+}
+
+type RedisConfig struct {
+	Addr     string
+	Password string
+	DB       int
+}
+
+type IngestionConfig struct {
+	Transport       string // "streams", "pubsub", "kafka" or "websocket"
+	ConsumerGroup   string
+	ConsumerName    string
+	BatchSize       int
+	ClaimIdle       int // seconds before another consumer's unacknowledged event is reclaimed
+	Retention       int // hours of acknowledged events kept in the stream
+	RefreshInterval int // seconds between reloads of the traders subscribed over websocket
+	MaxAttempts     int // times a trade is handed to the engine before it is dead-lettered
+}
+
+type KafkaConfig struct {
+	Brokers []string
+	Topic   string // trader fills topic, KafkaTopics.TRADER_FILLS in @hyperdash/shared-types
+	GroupID string
+}
+
+type EventsConfig struct {
+	Sinks        []string // "redis" and/or "kafka"
+	RedisStream  string
+	RedisMaxLen  int64
+	KafkaTopic   string
+	PollInterval int // milliseconds
+	BatchSize    int
+}
+
+type KeyStoreConfig struct {
+	EncryptionKey string // hex encoded AES-256 key the api-gateway seals agent wallet keys with
+	CacheTTL      int    // seconds a decrypted agent key is kept in memory
+}
+
+// Key decodes the agent wallet encryption key
+func (c KeyStoreConfig) Key() ([]byte, error) {
+	if c.EncryptionKey == "" {
+		return nil, fmt.Errorf("ENCRYPTION_KEY is required")
+	}
+	key, err := hex.DecodeString(c.EncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("ENCRYPTION_KEY is not valid hex: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("ENCRYPTION_KEY must be a 64-char hex string (32 bytes), got %d bytes", len(key))
+	}
+	return key, nil
+}
+
+type ReconcilerConfig struct {
+	Interval              int     // seconds
+	Tolerance             float64 // relative size difference treated as in sync
+	PlaceCorrectiveOrders bool
+	MinCorrectionSize     float64 // USD notional
+	MaxSlippage           float64 // in basis points
 }
 
 type RiskConfig struct {
 	MaxLeverage      float64
 	MaxPositionSize  float64
 	MaxSlippage      float64 // in basis points
-	MinOrderSize     float64
+	MinOrderSize     float64 // USD notional, at least the exchange minimum
 	MaxDailyLoss     float64
 }
 
 func Load() (*Config, error) {
 	cfg := &Config{
 		Server: ServerConfig{
-			Port: getEnvOrDefault("PORT", "8080"),
+			Port:     getEnvOrDefault("PORT", "8080"),
+			APIToken: getEnvOrDefault("COPY_ENGINE_API_TOKEN", ""),
 		},
 		Engine: EngineConfig{
 			MaxConcurrency:     getEnvIntOrDefault("MAX_CONCURRENCY", 100),
@@ -51,20 +137,69 @@ func Load() (*Config, error) {
 			AlignmentThreshold: getEnvFloatOrDefault("ALIGNMENT_THRESHOLD", 0.02),
 			RetryAttempts:       getEnvIntOrDefault("RETRY_ATTEMPTS", 3),
 			RetryBackoffBase:    getEnvIntOrDefault("RETRY_BACKOFF_BASE", 1),
+			MaxChildOrderSize:   getEnvFloatOrDefault("MAX_CHILD_ORDER_SIZE", 25000.0),
+			StaleExecutionAfter: getEnvIntOrDefault("STALE_EXECUTION_AFTER", 300),
 		},
 		Hyperliquid: HyperliquidConfig{
 			BaseURL:  getEnvOrDefault("HYPERLIQUID_BASE_URL", "https://api.hyperliquid.xyz/info"),
 			APIKey:   getEnvOrDefault("HYPERLIQUID_API_KEY", ""),
 			SecretKey: getEnvOrDefault("HYPERLIQUID_SECRET_KEY", ""),
 			TestNet:  getEnvBoolOrDefault("HYPERLIQUID_TESTNET", false),
+			WSURL:    getEnvOrDefault("HYPERLIQUID_WS_URL", ""),
+			VaultAddress: getEnvOrDefault("HYPERLIQUID_VAULT_ADDRESS", ""),
+			RateLimitWeight:     getEnvIntOrDefault("HYPERLIQUID_RATE_LIMIT_WEIGHT", 1200),
+			CoalesceWindow:      getEnvIntOrDefault("HYPERLIQUID_COALESCE_WINDOW_MS", 1000),
+			MetaRefreshInterval: getEnvIntOrDefault("HYPERLIQUID_META_REFRESH_INTERVAL", 3600),
 		},
 		Risk: RiskConfig{
 			MaxLeverage:     getEnvFloatOrDefault("MAX_LEVERAGE", 5.0),
 			MaxPositionSize: getEnvFloatOrDefault("MAX_POSITION_SIZE", 100000.0),
 			MaxSlippage:     getEnvFloatOrDefault("MAX_SLIPPAGE", 10.0),
-			MinOrderSize:    getEnvFloatOrDefault("MIN_ORDER_SIZE", 5.0),
+			MinOrderSize:    getEnvFloatOrDefault("MIN_ORDER_SIZE", exchangeMinOrderValue),
 			MaxDailyLoss:    getEnvFloatOrDefault("MAX_DAILY_LOSS", 1000.0),
 		},
+		Database: DatabaseConfig{
+			URL: getEnvOrDefault("DATABASE_URL", ""),
+		},
+		Redis: RedisConfig{
+			Addr:     getEnvOrDefault("REDIS_ADDR", "localhost:6379"),
+			Password: getEnvOrDefault("REDIS_PASSWORD", ""),
+			DB:       getEnvIntOrDefault("REDIS_DB", 0),
+		},
+		Ingestion: IngestionConfig{
+			Transport:       getEnvOrDefault("TRADE_EVENTS_TRANSPORT", "streams"),
+			ConsumerGroup:   getEnvOrDefault("TRADE_EVENTS_GROUP", "copy-engine"),
+			ConsumerName:    getEnvOrDefault("TRADE_EVENTS_CONSUMER", hostname()),
+			BatchSize:       getEnvIntOrDefault("TRADE_EVENTS_BATCH_SIZE", 100),
+			ClaimIdle:       getEnvIntOrDefault("TRADE_EVENTS_CLAIM_IDLE", 60),
+			Retention:       getEnvIntOrDefault("TRADE_EVENTS_RETENTION", 24),
+			RefreshInterval: getEnvIntOrDefault("TRADE_EVENTS_REFRESH_INTERVAL", 30),
+			MaxAttempts:     getEnvIntOrDefault("TRADE_EVENTS_MAX_ATTEMPTS", 8),
+		},
+		Kafka: KafkaConfig{
+			Brokers: getEnvListOrDefault("KAFKA_BROKERS", nil),
+			Topic:   getEnvOrDefault("KAFKA_TRADES_TOPIC", "trader-fills"),
+			GroupID: getEnvOrDefault("KAFKA_GROUP_ID", "copy-engine"),
+		},
+		Events: EventsConfig{
+			Sinks:        getEnvListOrDefault("EVENTS_SINKS", []string{"redis"}),
+			RedisStream:  getEnvOrDefault("EVENTS_REDIS_STREAM", "copy_events"),
+			RedisMaxLen:  int64(getEnvIntOrDefault("EVENTS_REDIS_MAX_LEN", 100000)),
+			KafkaTopic:   getEnvOrDefault("EVENTS_KAFKA_TOPIC", "copy-engine-events"),
+			PollInterval: getEnvIntOrDefault("EVENTS_POLL_INTERVAL_MS", 500),
+			BatchSize:    getEnvIntOrDefault("EVENTS_BATCH_SIZE", 100),
+		},
+		Reconciler: ReconcilerConfig{
+			Interval:              getEnvIntOrDefault("RECONCILE_INTERVAL", 60),
+			Tolerance:             getEnvFloatOrDefault("RECONCILE_TOLERANCE", 0.01),
+			PlaceCorrectiveOrders: getEnvBoolOrDefault("RECONCILE_PLACE_ORDERS", false),
+			MinCorrectionSize:     getEnvFloatOrDefault("RECONCILE_MIN_CORRECTION_SIZE", 10.0),
+			MaxSlippage:           getEnvFloatOrDefault("RECONCILE_MAX_SLIPPAGE", 10.0),
+		},
+		KeyStore: KeyStoreConfig{
+			EncryptionKey: getEnvOrDefault("ENCRYPTION_KEY", ""),
+			CacheTTL:      getEnvIntOrDefault("AGENT_KEY_CACHE_TTL", 300),
+		},
 	}
 
 	// Validate configuration
@@ -79,15 +214,52 @@ func (c *Config) validate() error {
 	if c.Hyperliquid.APIKey == "" {
 		return fmt.Errorf("HYPERLIQUID_API_KEY is required")
 	}
-	if c.Hyperliquid.SecretKey == "" {
-		return fmt.Errorf("HYPERLIQUID_SECRET_KEY is required")
+	// HYPERLIQUID_SECRET_KEY is optional: followers trade with their own agent keys
+	if _, err := c.KeyStore.Key(); err != nil {
+		return err
+	}
+	if c.KeyStore.CacheTTL <= 0 {
+		return fmt.Errorf("AGENT_KEY_CACHE_TTL must be positive")
+	}
+	if c.Hyperliquid.RateLimitWeight <= 0 {
+		return fmt.Errorf("HYPERLIQUID_RATE_LIMIT_WEIGHT must be positive")
+	}
+	if c.Hyperliquid.MetaRefreshInterval <= 0 {
+		return fmt.Errorf("HYPERLIQUID_META_REFRESH_INTERVAL must be positive")
+	}
+	if c.Database.URL == "" {
+		return fmt.Errorf("DATABASE_URL is required")
 	}
 	if c.Engine.MaxConcurrency <= 0 {
 		return fmt.Errorf("MAX_CONCURRENCY must be positive")
 	}
+	switch c.Ingestion.Transport {
+	case "streams", "pubsub", "websocket":
+	case "kafka":
+		if len(c.Kafka.Brokers) == 0 {
+			return fmt.Errorf("KAFKA_BROKERS is required for the kafka transport")
+		}
+	default:
+		return fmt.Errorf("TRADE_EVENTS_TRANSPORT must be streams, pubsub, kafka or websocket")
+	}
+	for _, sink := range c.Events.Sinks {
+		switch sink {
+		case "redis":
+		case "kafka":
+			if len(c.Kafka.Brokers) == 0 {
+				return fmt.Errorf("KAFKA_BROKERS is required for the kafka event sink")
+			}
+		default:
+			return fmt.Errorf("unknown event sink %q in EVENTS_SINKS", sink)
+		}
+	}
 	if c.Risk.MaxLeverage <= 0 || c.Risk.MaxLeverage > 100 {
 		return fmt.Errorf("MAX_LEVERAGE must be between 0 and 100")
 	}
+	// Deltas between the floor and the exchange minimum would be rejected on every tick
+	if c.Risk.MinOrderSize < exchangeMinOrderValue {
+		return fmt.Errorf("MIN_ORDER_SIZE must be at least the exchange minimum of %.0f", exchangeMinOrderValue)
+	}
 	return nil
 }
 
@@ -124,3 +296,25 @@ func getEnvBoolOrDefault(key string, defaultValue bool) bool {
 	}
 	return defaultValue
 }
+
+func getEnvListOrDefault(key string, defaultValue []string) []string {
+	if value := os.Getenv(key); value != "" {
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		return list
+	}
+	return defaultValue
+}
+
+// hostname names this instance's stream consumer when none is configured
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil || name == "" {
+		return "copy-engine"
+	}
+	return name
+}
@@ -3,11 +3,14 @@ package database
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/hyperdash/copy-engine/internal/models"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sirupsen/logrus"
 )
@@ -15,24 +18,57 @@ import (
 // PostgreSQL interface
 type PostgreSQL interface {
 	Close()
+	// Pool returns the connection pool, for components that run their own queries on it
+	Pool() *pgxpool.Pool
 	GetActiveCopyRelationships(ctx context.Context) ([]*models.CopyRelationship, error)
 	GetCopyRelationship(ctx context.Context, id string) (*models.CopyRelationship, error)
 	GetCopyRelationshipsByFollower(ctx context.Context, followerID string) ([]*models.CopyRelationship, error)
 	GetCopyRelationshipsByTrader(ctx context.Context, traderID string) ([]*models.CopyRelationship, error)
+	GetActiveTraderAddresses(ctx context.Context) (map[string]string, error)
+	GetAgentWallet(ctx context.Context, userID string) (*models.AgentWallet, error)
+	GetTradingAgentWallets(ctx context.Context) ([]*models.AgentWallet, error)
+	GetActiveCopyStrategy(ctx context.Context, relationshipID string) (*models.CopyStrategy, error)
+	SaveCopyStrategy(ctx context.Context, strategy *models.CopyStrategy) error
 	CreateCopyExecution(ctx context.Context, execution *models.CopyExecution) error
 	UpdateCopyExecution(ctx context.Context, execution *models.CopyExecution) error
+	TransitionCopyExecution(ctx context.Context, execution *models.CopyExecution, transition *models.ExecutionTransition, events ...*models.OutboxEvent) error
+	GetExecutionTransitions(ctx context.Context, executionID string) ([]*models.ExecutionTransition, error)
+	GetCopyExecution(ctx context.Context, id string) (*models.CopyExecution, error)
+	GetCopyExecutionsByStatus(ctx context.Context, status models.ExecutionStatus) ([]*models.CopyExecution, error)
+	GetStaleCopyExecutions(ctx context.Context, updatedBefore time.Time) ([]*models.CopyExecution, error)
+	DeadLetterCopyExecution(ctx context.Context, execution *models.CopyExecution, transition *models.ExecutionTransition, deadLetter *models.DeadLetter) error
+	GetDeadLetter(ctx context.Context, id string) (*models.DeadLetter, error)
+	ListDeadLetters(ctx context.Context, limit int) ([]*models.DeadLetter, error)
+	ReplayDeadLetter(ctx context.Context, id string, execution *models.CopyExecution, transition *models.ExecutionTransition) error
+	CreateTradeDeadLetter(ctx context.Context, deadLetter *models.TradeDeadLetter) error
 	GetTraderPositions(ctx context.Context, traderID string) ([]*models.Position, error)
 	GetFollowerPositions(ctx context.Context, followerID string) ([]*models.Position, error)
 	CreatePosition(ctx context.Context, position *models.Position) error
 	UpdatePosition(ctx context.Context, position *models.Position) error
+	RecordPositionDrift(ctx context.Context, drift *models.PositionDrift) error
 	CreateTrade(ctx context.Context, trade *models.Trade) error
 	GetRecentTradesByTrader(ctx context.Context, traderID string, limit int) ([]*models.Trade, error)
+	GetClosedTradesByRelationship(ctx context.Context, relationshipID string, limit int) ([]*models.Trade, error)
+	GetClosedTradesByTrader(ctx context.Context, traderID string, limit int) ([]*models.Trade, error)
+	GetHourlyClosePrices(ctx context.Context, symbol string, since time.Time) ([]float64, error)
 	UpdatePerformanceMetrics(ctx context.Context, metrics *models.PerformanceMetrics) error
 	GetPerformanceMetrics(ctx context.Context, relationshipID string) (*models.PerformanceMetrics, error)
 	UpdateRiskMetrics(ctx context.Context, metrics *models.RiskMetrics) error
 	GetRiskMetrics(ctx context.Context, relationshipID string) (*models.RiskMetrics, error)
+	EnqueueOutboxEvents(ctx context.Context, events ...*models.OutboxEvent) error
+	PublishOutboxEvents(ctx context.Context, limit int, publish func(ctx context.Context, event *models.OutboxEvent) error) (int, error)
 }
 
+var (
+	// ErrNotFound is returned (wrapped) when a requested row does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrDuplicate is returned (wrapped) when an insert violates a unique constraint
+	ErrDuplicate = errors.New("already exists")
+)
+
+// uniqueViolation is the Postgres SQLSTATE for a unique constraint violation
+const uniqueViolation = "23505"
+
 type postgresql struct {
 	pool *pgxpool.Pool
 	log  *logrus.Logger
@@ -66,6 +102,10 @@ func NewPostgreSQL(dsn string, log *logrus.Logger) (PostgreSQL, error) {
 	}, nil
 }
 
+func (p *postgresql) Pool() *pgxpool.Pool {
+	return p.pool
+}
+
 func (p *postgresql) Close() {
 	if p.pool != nil {
 		p.pool.Close()
@@ -178,6 +218,38 @@ func (p *postgresql) GetCopyRelationshipsByTrader(ctx context.Context, traderID
 	return p.scanCopyRelationships(ctx, query, traderID)
 }
 
+// GetActiveTraderAddresses returns the on-chain address of every trader with at
+// least one active copy relationship, keyed by trader ID
+func (p *postgresql) GetActiveTraderAddresses(ctx context.Context) (map[string]string, error) {
+	query := `
+		SELECT DISTINCT cr.trader_id, t.address
+		FROM copy_relationships cr
+		JOIN traders t ON t.id = cr.trader_id
+		WHERE cr.is_active = true
+	`
+
+	rows, err := p.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query active trader addresses: %w", err)
+	}
+	defer rows.Close()
+
+	addresses := make(map[string]string)
+	for rows.Next() {
+		var traderID, address string
+		if err := rows.Scan(&traderID, &address); err != nil {
+			return nil, fmt.Errorf("failed to scan trader address: %w", err)
+		}
+		addresses[traderID] = address
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating trader addresses: %w", err)
+	}
+
+	return addresses, nil
+}
+
 func (p *postgresql) scanCopyRelationships(ctx context.Context, query string, args ...interface{}) ([]*models.CopyRelationship, error) {
 	rows, err := p.pool.Query(ctx, query, args...)
 	if err != nil {
@@ -214,49 +286,163 @@ func (p *postgresql) scanCopyRelationships(ctx context.Context, query string, ar
 	return relationships, nil
 }
 
-func (p *postgresql) CreateCopyExecution(ctx context.Context, execution *models.CopyExecution) error {
+func (p *postgresql) GetActiveCopyStrategy(ctx context.Context, relationshipID string) (*models.CopyStrategy, error) {
 	query := `
-		INSERT INTO copy_executions (id, signal_id, relationship_id, trade_id, status,
-		                            error_message, parameters, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		SELECT id, relationship_id, name, strategy_type, parameters, is_active,
+		       created_at, updated_at
+		FROM copy_strategies
+		WHERE relationship_id = $1 AND is_active = true
+		ORDER BY updated_at DESC
+		LIMIT 1
 	`
 
+	var strategy models.CopyStrategy
+	err := p.pool.QueryRow(ctx, query, relationshipID).Scan(
+		&strategy.ID,
+		&strategy.RelationshipID,
+		&strategy.Name,
+		&strategy.StrategyType,
+		&strategy.Parameters,
+		&strategy.IsActive,
+		&strategy.CreatedAt,
+		&strategy.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("%w: copy strategy for relationship %s", ErrNotFound, relationshipID)
+		}
+		return nil, fmt.Errorf("failed to get copy strategy: %w", err)
+	}
+
+	return &strategy, nil
+}
+
+// SaveCopyStrategy stores a strategy as the only active one for its relationship.
+// The row is owned by the relationship's follower.
+func (p *postgresql) SaveCopyStrategy(ctx context.Context, strategy *models.CopyStrategy) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE copy_strategies
+		SET is_active = false, updated_at = $2
+		WHERE relationship_id = $1 AND is_active = true
+	`, strategy.RelationshipID, strategy.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate previous copy strategies: %w", err)
+	}
+
+	parametersJSON, err := json.Marshal(strategy.Parameters)
+	if err != nil {
+		return fmt.Errorf("failed to marshal strategy parameters: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO copy_strategies (id, user_id, relationship_id, name, strategy_type,
+		                            parameters, is_active, created_at, updated_at)
+		SELECT $1, follower_id, id, $3, $4, $5, $6, $7, $8
+		FROM copy_relationships
+		WHERE id = $2
+	`,
+		strategy.ID,
+		strategy.RelationshipID,
+		strategy.Name,
+		strategy.StrategyType,
+		parametersJSON,
+		strategy.IsActive,
+		strategy.CreatedAt,
+		strategy.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create copy strategy: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: copy relationship %s", ErrNotFound, strategy.RelationshipID)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// CreateCopyExecution inserts an execution together with the transition into its
+// initial status. An execution for the same original trade and relationship fails
+// with ErrDuplicate.
+func (p *postgresql) CreateCopyExecution(ctx context.Context, execution *models.CopyExecution) error {
 	parametersJSON, err := json.Marshal(execution.Parameters)
 	if err != nil {
 		return fmt.Errorf("failed to marshal execution parameters: %w", err)
 	}
 
-	_, err = p.pool.Exec(ctx, query,
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO copy_executions (id, signal_id, relationship_id, trade_id, status,
+		                            error_message, parameters, created_at, updated_at,
+		                            original_trade_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`,
 		execution.ID,
 		execution.SignalID,
 		execution.Relationship.ID,
-		execution.Trade.ID,
+		executionTradeID(execution),
 		execution.Status,
 		execution.ErrorMessage,
 		parametersJSON,
 		execution.CreatedAt,
 		execution.UpdatedAt,
+		nullIfEmpty(execution.OriginalTradeID),
 	)
-
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return fmt.Errorf("%w: copy execution of trade %s for relationship %s",
+				ErrDuplicate, execution.OriginalTradeID, execution.Relationship.ID)
+		}
 		return fmt.Errorf("failed to create copy execution: %w", err)
 	}
 
-	return nil
+	err = insertExecutionTransition(ctx, tx, &models.ExecutionTransition{
+		ID:          uuid.New().String(),
+		ExecutionID: execution.ID,
+		ToStatus:    execution.Status,
+		Reason:      "created",
+		CreatedAt:   execution.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
+// UpdateCopyExecution stores an execution's trade, error and parameters. The status
+// only changes through TransitionCopyExecution.
 func (p *postgresql) UpdateCopyExecution(ctx context.Context, execution *models.CopyExecution) error {
 	query := `
 		UPDATE copy_executions
-		SET status = $2, error_message = $3, updated_at = $4
+		SET error_message = $2, updated_at = $3,
+		    trade_id = COALESCE($4, trade_id), parameters = $5
 		WHERE id = $1
 	`
 
-	_, err := p.pool.Exec(ctx, query,
+	parametersJSON, err := json.Marshal(execution.Parameters)
+	if err != nil {
+		return fmt.Errorf("failed to marshal execution parameters: %w", err)
+	}
+
+	_, err = p.pool.Exec(ctx, query,
 		execution.ID,
-		execution.Status,
 		execution.ErrorMessage,
 		execution.UpdatedAt,
+		executionTradeID(execution),
+		parametersJSON,
 	)
 
 	if err != nil {
@@ -266,6 +452,577 @@ func (p *postgresql) UpdateCopyExecution(ctx context.Context, execution *models.
 	return nil
 }
 
+// TransitionCopyExecution moves a stored execution from transition.FromStatus to
+// transition.ToStatus and records the transition, together with its status event
+// and any further events in the outbox. It fails with models.ErrInvalidTransition
+// when the stored status is no longer FromStatus.
+func (p *postgresql) TransitionCopyExecution(ctx context.Context, execution *models.CopyExecution, transition *models.ExecutionTransition, events ...*models.OutboxEvent) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := transitionCopyExecution(ctx, tx, execution, transition, events...); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func transitionCopyExecution(ctx context.Context, tx pgx.Tx, execution *models.CopyExecution, transition *models.ExecutionTransition, events ...*models.OutboxEvent) error {
+	parametersJSON, err := json.Marshal(execution.Parameters)
+	if err != nil {
+		return fmt.Errorf("failed to marshal execution parameters: %w", err)
+	}
+
+	statusEvent, err := models.NewExecutionEvent(execution, transition)
+	if err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE copy_executions
+		SET status = $2, error_message = $3, updated_at = $4,
+		    trade_id = COALESCE($5, trade_id), parameters = $6
+		WHERE id = $1 AND status = $7
+	`,
+		execution.ID,
+		transition.ToStatus,
+		execution.ErrorMessage,
+		transition.CreatedAt,
+		executionTradeID(execution),
+		parametersJSON,
+		transition.FromStatus,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to transition copy execution: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: execution %s is no longer %s", models.ErrInvalidTransition,
+			execution.ID, transition.FromStatus)
+	}
+
+	if err := insertExecutionTransition(ctx, tx, transition); err != nil {
+		return err
+	}
+
+	return insertOutboxEvents(ctx, tx, append([]*models.OutboxEvent{statusEvent}, events...)...)
+}
+
+func (p *postgresql) GetExecutionTransitions(ctx context.Context, executionID string) ([]*models.ExecutionTransition, error) {
+	query := `
+		SELECT id, execution_id, COALESCE(from_status, ''), to_status, COALESCE(reason, ''), created_at
+		FROM copy_execution_transitions
+		WHERE execution_id = $1
+		ORDER BY created_at, id
+	`
+
+	rows, err := p.pool.Query(ctx, query, executionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query execution transitions: %w", err)
+	}
+	defer rows.Close()
+
+	var transitions []*models.ExecutionTransition
+	for rows.Next() {
+		var transition models.ExecutionTransition
+		err := rows.Scan(
+			&transition.ID,
+			&transition.ExecutionID,
+			&transition.FromStatus,
+			&transition.ToStatus,
+			&transition.Reason,
+			&transition.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan execution transition: %w", err)
+		}
+		transitions = append(transitions, &transition)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating execution transitions: %w", err)
+	}
+
+	return transitions, nil
+}
+
+// GetCopyExecution loads an execution. Its Relationship only carries the ID and
+// Trade is nil; callers load them as needed.
+func (p *postgresql) GetCopyExecution(ctx context.Context, id string) (*models.CopyExecution, error) {
+	executions, err := p.scanCopyExecutions(ctx, `
+		SELECT id, signal_id, relationship_id, status, error_message, parameters,
+		       created_at, updated_at, COALESCE(original_trade_id, '')
+		FROM copy_executions
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(executions) == 0 {
+		return nil, fmt.Errorf("%w: copy execution %s", ErrNotFound, id)
+	}
+
+	return executions[0], nil
+}
+
+func (p *postgresql) GetCopyExecutionsByStatus(ctx context.Context, status models.ExecutionStatus) ([]*models.CopyExecution, error) {
+	return p.scanCopyExecutions(ctx, `
+		SELECT id, signal_id, relationship_id, status, error_message, parameters,
+		       created_at, updated_at, COALESCE(original_trade_id, '')
+		FROM copy_executions
+		WHERE status = $1
+		ORDER BY created_at
+	`, status)
+}
+
+// GetStaleCopyExecutions returns pending and executing executions not updated since
+// updatedBefore: attempts interrupted by a crash, which no worker will finish
+func (p *postgresql) GetStaleCopyExecutions(ctx context.Context, updatedBefore time.Time) ([]*models.CopyExecution, error) {
+	return p.scanCopyExecutions(ctx, `
+		SELECT id, signal_id, relationship_id, status, error_message, parameters,
+		       created_at, updated_at, COALESCE(original_trade_id, '')
+		FROM copy_executions
+		WHERE status IN ('pending', 'executing') AND updated_at < $1
+		ORDER BY created_at
+	`, updatedBefore)
+}
+
+func (p *postgresql) scanCopyExecutions(ctx context.Context, query string, args ...interface{}) ([]*models.CopyExecution, error) {
+	rows, err := p.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query copy executions: %w", err)
+	}
+	defer rows.Close()
+
+	var executions []*models.CopyExecution
+	for rows.Next() {
+		var execution models.CopyExecution
+		var relationshipID string
+		var parametersJSON []byte
+		err := rows.Scan(
+			&execution.ID,
+			&execution.SignalID,
+			&relationshipID,
+			&execution.Status,
+			&execution.ErrorMessage,
+			&parametersJSON,
+			&execution.CreatedAt,
+			&execution.UpdatedAt,
+			&execution.OriginalTradeID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan copy execution: %w", err)
+		}
+
+		execution.Relationship = &models.CopyRelationship{ID: relationshipID}
+		execution.Parameters = make(map[string]interface{})
+		if len(parametersJSON) > 0 {
+			if err := json.Unmarshal(parametersJSON, &execution.Parameters); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal execution parameters: %w", err)
+			}
+		}
+		executions = append(executions, &execution)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating copy executions: %w", err)
+	}
+
+	return executions, nil
+}
+
+// DeadLetterCopyExecution fails an execution and stores it for replay in one
+// transaction, so a failed execution always has its dead letter
+func (p *postgresql) DeadLetterCopyExecution(ctx context.Context, execution *models.CopyExecution, transition *models.ExecutionTransition, deadLetter *models.DeadLetter) error {
+	tradeJSON, err := json.Marshal(deadLetter.OriginalTrade)
+	if err != nil {
+		return fmt.Errorf("failed to marshal original trade: %w", err)
+	}
+
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := transitionCopyExecution(ctx, tx, execution, transition); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO copy_execution_dead_letters (id, execution_id, relationship_id, original_trade,
+		                                        copy_size, attempts, last_error, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`,
+		deadLetter.ID,
+		deadLetter.ExecutionID,
+		deadLetter.RelationshipID,
+		tradeJSON,
+		deadLetter.CopySize,
+		deadLetter.Attempts,
+		deadLetter.LastError,
+		deadLetter.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create dead letter: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (p *postgresql) GetDeadLetter(ctx context.Context, id string) (*models.DeadLetter, error) {
+	deadLetters, err := p.scanDeadLetters(ctx, `
+		SELECT id, execution_id, relationship_id, original_trade, copy_size::float8,
+		       attempts, COALESCE(last_error, ''), created_at, replayed_at
+		FROM copy_execution_dead_letters
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(deadLetters) == 0 {
+		return nil, fmt.Errorf("%w: dead letter %s", ErrNotFound, id)
+	}
+
+	return deadLetters[0], nil
+}
+
+// ListDeadLetters returns dead letters that have not been replayed, oldest first
+func (p *postgresql) ListDeadLetters(ctx context.Context, limit int) ([]*models.DeadLetter, error) {
+	return p.scanDeadLetters(ctx, `
+		SELECT id, execution_id, relationship_id, original_trade, copy_size::float8,
+		       attempts, COALESCE(last_error, ''), created_at, replayed_at
+		FROM copy_execution_dead_letters
+		WHERE replayed_at IS NULL
+		ORDER BY created_at
+		LIMIT $1
+	`, limit)
+}
+
+func (p *postgresql) scanDeadLetters(ctx context.Context, query string, args ...interface{}) ([]*models.DeadLetter, error) {
+	rows, err := p.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	var deadLetters []*models.DeadLetter
+	for rows.Next() {
+		var deadLetter models.DeadLetter
+		var tradeJSON []byte
+		err := rows.Scan(
+			&deadLetter.ID,
+			&deadLetter.ExecutionID,
+			&deadLetter.RelationshipID,
+			&tradeJSON,
+			&deadLetter.CopySize,
+			&deadLetter.Attempts,
+			&deadLetter.LastError,
+			&deadLetter.CreatedAt,
+			&deadLetter.ReplayedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan dead letter: %w", err)
+		}
+
+		if err := json.Unmarshal(tradeJSON, &deadLetter.OriginalTrade); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal original trade: %w", err)
+		}
+		deadLetters = append(deadLetters, &deadLetter)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating dead letters: %w", err)
+	}
+
+	return deadLetters, nil
+}
+
+// GetAgentWallet returns the user's active Hyperliquid agent wallet that has a key
+func (p *postgresql) GetAgentWallet(ctx context.Context, userID string) (*models.AgentWallet, error) {
+	wallets, err := p.scanAgentWallets(ctx, `
+		SELECT aw.id, aw.user_id, aw.exchange, aw.address, u.wallet_address, aw.status,
+		       aw.encrypted_private_key, COALESCE(aw.created_at, now()), COALESCE(aw.updated_at, now())
+		FROM agent_wallets aw
+		JOIN users u ON u.id = aw.user_id
+		WHERE aw.user_id = $1 AND aw.exchange = 'hyperliquid' AND aw.status = 'active'
+		  AND aw.encrypted_private_key IS NOT NULL
+		ORDER BY aw.updated_at DESC
+		LIMIT 1
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(wallets) == 0 {
+		return nil, fmt.Errorf("%w: agent wallet for user %s", ErrNotFound, userID)
+	}
+
+	return wallets[0], nil
+}
+
+// GetTradingAgentWallets returns the agent wallet of every user the engine trades
+// for: followers in an active copy relationship and owners of engine strategies
+func (p *postgresql) GetTradingAgentWallets(ctx context.Context) ([]*models.AgentWallet, error) {
+	return p.scanAgentWallets(ctx, `
+		SELECT DISTINCT ON (aw.user_id)
+		       aw.id, aw.user_id, aw.exchange, aw.address, u.wallet_address, aw.status,
+		       aw.encrypted_private_key, COALESCE(aw.created_at, now()), COALESCE(aw.updated_at, now())
+		FROM agent_wallets aw
+		JOIN users u ON u.id = aw.user_id
+		WHERE aw.exchange = 'hyperliquid' AND aw.status = 'active'
+		  AND aw.encrypted_private_key IS NOT NULL
+		  AND (aw.user_id IN (SELECT follower_id FROM copy_relationships WHERE is_active = true)
+		       OR aw.user_id IN (SELECT user_id FROM copy_strategies
+		                         WHERE relationship_id IS NULL AND status <> 'terminated'))
+		ORDER BY aw.user_id, aw.updated_at DESC
+	`)
+}
+
+func (p *postgresql) scanAgentWallets(ctx context.Context, query string, args ...interface{}) ([]*models.AgentWallet, error) {
+	rows, err := p.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query agent wallets: %w", err)
+	}
+	defer rows.Close()
+
+	var wallets []*models.AgentWallet
+	for rows.Next() {
+		var wallet models.AgentWallet
+		err := rows.Scan(
+			&wallet.ID,
+			&wallet.UserID,
+			&wallet.Exchange,
+			&wallet.Address,
+			&wallet.MasterAddress,
+			&wallet.Status,
+			&wallet.EncryptedPrivateKey,
+			&wallet.CreatedAt,
+			&wallet.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan agent wallet: %w", err)
+		}
+		wallets = append(wallets, &wallet)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating agent wallets: %w", err)
+	}
+
+	return wallets, nil
+}
+
+// ReplayDeadLetter flags a dead letter as replayed and reopens its execution in one
+// transaction. Replaying it twice fails with ErrNotFound.
+func (p *postgresql) ReplayDeadLetter(ctx context.Context, id string, execution *models.CopyExecution, transition *models.ExecutionTransition) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE copy_execution_dead_letters SET replayed_at = $2
+		WHERE id = $1 AND replayed_at IS NULL
+	`, id, transition.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to mark dead letter replayed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: unreplayed dead letter %s", ErrNotFound, id)
+	}
+
+	if err := transitionCopyExecution(ctx, tx, execution, transition); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (p *postgresql) CreateTradeDeadLetter(ctx context.Context, deadLetter *models.TradeDeadLetter) error {
+	tradeJSON, err := json.Marshal(deadLetter.Trade)
+	if err != nil {
+		return fmt.Errorf("failed to marshal trade: %w", err)
+	}
+
+	_, err = p.pool.Exec(ctx, `
+		INSERT INTO trade_dead_letters (id, trade_id, trader_id, source, trade, attempts, last_error, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`,
+		deadLetter.ID,
+		deadLetter.TradeID,
+		deadLetter.TraderID,
+		deadLetter.Source,
+		tradeJSON,
+		deadLetter.Attempts,
+		deadLetter.LastError,
+		deadLetter.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create trade dead letter: %w", err)
+	}
+
+	return nil
+}
+
+func insertExecutionTransition(ctx context.Context, tx pgx.Tx, transition *models.ExecutionTransition) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO copy_execution_transitions (id, execution_id, from_status, to_status, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`,
+		transition.ID,
+		transition.ExecutionID,
+		nullIfEmpty(string(transition.FromStatus)),
+		transition.ToStatus,
+		transition.Reason,
+		transition.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record execution transition: %w", err)
+	}
+
+	return nil
+}
+
+// insertOutboxEvents stores events within the transaction of the change they describe
+func insertOutboxEvents(ctx context.Context, tx pgx.Tx, events ...*models.OutboxEvent) error {
+	for _, event := range events {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO event_outbox (id, event_type, schema_version, aggregate_id, payload, occurred_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`,
+			event.ID,
+			event.Type,
+			event.SchemaVersion,
+			event.AggregateID,
+			event.Data,
+			event.OccurredAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to store %s event: %w", event.Type, err)
+		}
+	}
+
+	return nil
+}
+
+// EnqueueOutboxEvents stores events that do not accompany any other write
+func (p *postgresql) EnqueueOutboxEvents(ctx context.Context, events ...*models.OutboxEvent) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := insertOutboxEvents(ctx, tx, events...); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// outboxPublisherLock is the advisory lock held while publishing, so events leave
+// the outbox in the order they were written even with several engine instances
+const outboxPublisherLock = 0x6f7574626f78
+
+// PublishOutboxEvents hands up to limit unpublished events, oldest first, to publish
+// and marks the ones it accepted as published. Publishing stops at the first event
+// publish rejects so that later events never overtake it. Returns how many events
+// were published; 0 while another instance holds the publisher lock.
+func (p *postgresql) PublishOutboxEvents(ctx context.Context, limit int, publish func(ctx context.Context, event *models.OutboxEvent) error) (int, error) {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var locked bool
+	if err := tx.QueryRow(ctx, `SELECT pg_try_advisory_xact_lock($1)`, outboxPublisherLock).Scan(&locked); err != nil {
+		return 0, fmt.Errorf("failed to acquire outbox lock: %w", err)
+	}
+	if !locked {
+		return 0, nil
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT id, event_type, schema_version, aggregate_id, payload, occurred_at, attempts
+		FROM event_outbox
+		WHERE published_at IS NULL
+		ORDER BY seq
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query outbox events: %w", err)
+	}
+
+	var events []*models.OutboxEvent
+	for rows.Next() {
+		var event models.OutboxEvent
+		if err := rows.Scan(
+			&event.ID,
+			&event.Type,
+			&event.SchemaVersion,
+			&event.AggregateID,
+			&event.Data,
+			&event.OccurredAt,
+			&event.Attempts,
+		); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		events = append(events, &event)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating outbox events: %w", err)
+	}
+
+	published := 0
+	var publishErr error
+	for _, event := range events {
+		if publishErr = publish(ctx, event); publishErr != nil {
+			_, err := tx.Exec(ctx, `
+				UPDATE event_outbox SET attempts = attempts + 1, last_error = $2 WHERE id = $1
+			`, event.ID, publishErr.Error())
+			if err != nil {
+				return 0, fmt.Errorf("failed to record outbox failure: %w", err)
+			}
+			break
+		}
+
+		if _, err := tx.Exec(ctx, `UPDATE event_outbox SET published_at = now() WHERE id = $1`, event.ID); err != nil {
+			return 0, fmt.Errorf("failed to mark outbox event published: %w", err)
+		}
+		published++
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit outbox progress: %w", err)
+	}
+
+	if publishErr != nil {
+		return published, fmt.Errorf("failed to publish event: %w", publishErr)
+	}
+	return published, nil
+}
+
+// executionTradeID returns the copy trade ID, or nil before the copy trade exists
+func executionTradeID(execution *models.CopyExecution) *string {
+	if execution.Trade == nil {
+		return nil
+	}
+	return &execution.Trade.ID
+}
+
+func nullIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
 func (p *postgresql) GetTraderPositions(ctx context.Context, traderID string) ([]*models.Position, error) {
 	query := `
 		SELECT id, user_id, trader_id, token_symbol, token_address, side, size,
@@ -335,6 +1092,17 @@ func (p *postgresql) scanPositions(ctx context.Context, query string, args ...in
 }
 
 func (p *postgresql) CreatePosition(ctx context.Context, position *models.Position) error {
+	event, err := models.NewPositionEvent(models.EventPositionOpened, position)
+	if err != nil {
+		return err
+	}
+
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
 		INSERT INTO positions (id, user_id, trader_id, token_symbol, token_address, side,
 		                      size, entry_price, current_price, unrealized_pnl, leverage,
@@ -343,7 +1111,7 @@ func (p *postgresql) CreatePosition(ctx context.Context, position *models.Positi
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
 	`
 
-	_, err := p.pool.Exec(ctx, query,
+	_, err = tx.Exec(ctx, query,
 		position.ID,
 		position.UserID,
 		position.TraderID,
@@ -367,18 +1135,37 @@ func (p *postgresql) CreatePosition(ctx context.Context, position *models.Positi
 		return fmt.Errorf("failed to create position: %w", err)
 	}
 
-	return nil
+	if err := insertOutboxEvents(ctx, tx, event); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
 func (p *postgresql) UpdatePosition(ctx context.Context, position *models.Position) error {
+	eventType := models.EventPositionChanged
+	if position.Size == 0 {
+		eventType = models.EventPositionClosed
+	}
+	event, err := models.NewPositionEvent(eventType, position)
+	if err != nil {
+		return err
+	}
+
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
 		UPDATE positions
 		SET size = $2, current_price = $3, unrealized_pnl = $4, funding_rate = $5,
-		    liquidation_price = $6, updated_at = $7
+		    liquidation_price = $6, updated_at = $7, side = $8, entry_price = $9, leverage = $10
 		WHERE id = $1
 	`
 
-	_, err := p.pool.Exec(ctx, query,
+	_, err = tx.Exec(ctx, query,
 		position.ID,
 		position.Size,
 		position.CurrentPrice,
@@ -386,12 +1173,45 @@ func (p *postgresql) UpdatePosition(ctx context.Context, position *models.Positi
 		position.FundingRate,
 		position.LiquidationPrice,
 		position.UpdatedAt,
+		position.Side,
+		position.EntryPrice,
+		position.Leverage,
 	)
 
 	if err != nil {
 		return fmt.Errorf("failed to update position: %w", err)
 	}
 
+	if err := insertOutboxEvents(ctx, tx, event); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (p *postgresql) RecordPositionDrift(ctx context.Context, drift *models.PositionDrift) error {
+	query := `
+		INSERT INTO position_drifts (id, follower_id, relationship_id, token_symbol, recorded_size,
+		                            exchange_size, expected_size, action, detected_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	_, err := p.pool.Exec(ctx, query,
+		drift.ID,
+		drift.FollowerID,
+		drift.RelationshipID,
+		drift.TokenSymbol,
+		drift.RecordedSize,
+		drift.ExchangeSize,
+		drift.ExpectedSize,
+		drift.Action,
+		drift.DetectedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to record position drift: %w", err)
+	}
+
 	return nil
 }
 
@@ -442,6 +1262,71 @@ func (p *postgresql) GetRecentTradesByTrader(ctx context.Context, traderID strin
 	return p.scanTrades(ctx, query, traderID, limit)
 }
 
+// GetClosedTradesByRelationship returns the most recent copy trades of a relationship
+// that realized PnL, newest first
+func (p *postgresql) GetClosedTradesByRelationship(ctx context.Context, relationshipID string, limit int) ([]*models.Trade, error) {
+	query := `
+		SELECT id, user_id, trader_id, position_id, token_symbol, side, size, price,
+		       fee, realized_pnl, transaction_hash, block_number, created_at,
+		       is_copy_trade, copy_relationship_id
+		FROM trades
+		WHERE copy_relationship_id = $1 AND realized_pnl <> 0
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+
+	return p.scanTrades(ctx, query, relationshipID, limit)
+}
+
+// GetClosedTradesByTrader returns the most recent trades a trader made on their own
+// account (excluding copies of them) that realized PnL, newest first
+func (p *postgresql) GetClosedTradesByTrader(ctx context.Context, traderID string, limit int) ([]*models.Trade, error) {
+	query := `
+		SELECT id, user_id, trader_id, position_id, token_symbol, side, size, price,
+		       fee, realized_pnl, transaction_hash, block_number, created_at,
+		       is_copy_trade, copy_relationship_id
+		FROM trades
+		WHERE trader_id = $1 AND is_copy_trade = false AND realized_pnl <> 0
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+
+	return p.scanTrades(ctx, query, traderID, limit)
+}
+
+// GetHourlyClosePrices returns the last traded price of each hour since the given time,
+// oldest first, built from the traders' own (non-copy) trades
+func (p *postgresql) GetHourlyClosePrices(ctx context.Context, symbol string, since time.Time) ([]float64, error) {
+	query := `
+		SELECT (array_agg(price ORDER BY created_at DESC))[1]
+		FROM trades
+		WHERE token_symbol = $1 AND created_at >= $2 AND is_copy_trade = false AND price > 0
+		GROUP BY date_trunc('hour', created_at)
+		ORDER BY date_trunc('hour', created_at)
+	`
+
+	rows, err := p.pool.Query(ctx, query, symbol, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query price history: %w", err)
+	}
+	defer rows.Close()
+
+	var prices []float64
+	for rows.Next() {
+		var price float64
+		if err := rows.Scan(&price); err != nil {
+			return nil, fmt.Errorf("failed to scan price: %w", err)
+		}
+		prices = append(prices, price)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating prices: %w", err)
+	}
+
+	return prices, nil
+}
+
 func (p *postgresql) scanTrades(ctx context.Context, query string, args ...interface{}) ([]*models.Trade, error) {
 	rows, err := p.pool.Query(ctx, query, args...)
 	if err != nil {
@@ -547,7 +1432,7 @@ func (p *postgresql) GetPerformanceMetrics(ctx context.Context, relationshipID s
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("performance metrics not found for relationship: %s", relationshipID)
+			return nil, fmt.Errorf("%w: performance metrics for relationship %s", ErrNotFound, relationshipID)
 		}
 		return nil, fmt.Errorf("failed to get performance metrics: %w", err)
 	}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/hyperdash/copy-engine/internal/models"
@@ -18,6 +21,7 @@ type Redis interface {
 	GetCopySignals(ctx context.Context, relationshipID string) ([]*models.CopySignal, error)
 	SetExecutionStatus(ctx context.Context, executionID string, status models.ExecutionStatus) error
 	GetExecutionStatus(ctx context.Context, executionID string) (models.ExecutionStatus, error)
+	DeleteExecutionStatus(ctx context.Context, executionID string) error
 	SetPerformanceMetrics(ctx context.Context, relationshipID string, metrics *models.PerformanceMetrics) error
 	GetPerformanceMetrics(ctx context.Context, relationshipID string) (*models.PerformanceMetrics, error)
 	SetRiskMetrics(ctx context.Context, relationshipID string, metrics *models.RiskMetrics) error
@@ -29,14 +33,34 @@ type Redis interface {
 	IsLocked(ctx context.Context, key string) (bool, error)
 	PublishTradeEvent(ctx context.Context, event *TradeEvent) error
 	SubscribeToTradeEvents(ctx context.Context) (<-chan *TradeEvent, error)
+	DroppedTradeEvents() uint64
+	EnsureTradeStreamGroup(ctx context.Context, group string) error
+	ReadTradeStream(ctx context.Context, group, consumer string, count int64, block time.Duration) ([]*TradeStreamMessage, error)
+	ClaimStaleTradeEvents(ctx context.Context, group, consumer string, minIdle time.Duration, count int64) ([]*TradeStreamMessage, error)
+	AckTradeEvents(ctx context.Context, group string, ids ...string) error
+	DeadLetterTradeEvent(ctx context.Context, group string, message *TradeStreamMessage, reason string) error
+	TrimTradeStream(ctx context.Context, group string, olderThan time.Time) (int64, error)
+	AppendCopyEvent(ctx context.Context, stream string, event *models.OutboxEvent, maxLen int64) error
 }
 
+// tradeEventsStream is the stream trade events are appended to; the pub/sub channel
+// of the same name carries the same events for subscribers that tolerate loss
+const tradeEventsStream = "trade_events"
+
+// tradeEventsDeadLetterStream keeps trade events that were delivered too often
+// without being acknowledged, for inspection
+const tradeEventsDeadLetterStream = "trade_events_dead_letter"
+
 type redisClient struct {
 	client *redis.Client
 	log    *logrus.Logger
+
+	// droppedTradeEvents counts events discarded because a subscriber fell behind
+	droppedTradeEvents atomic.Uint64
 }
 
-// TradeEvent represents a trade event for pub/sub
+// TradeEvent represents a trade event for pub/sub. It is also the message of the
+// Kafka trader-fills topic, TraderFillEventSchema in @hyperdash/shared-types.
 type TradeEvent struct {
 	Type      string                 `json:"type"`
 	TraderID  string                 `json:"trader_id"`
@@ -45,6 +69,15 @@ type TradeEvent struct {
 	Data      map[string]interface{} `json:"data"`
 }
 
+// TradeStreamMessage is a trade event delivered from the trade_events stream. It stays
+// pending for its consumer group until acknowledged.
+type TradeStreamMessage struct {
+	ID         string
+	Event      *TradeEvent // nil when the entry could not be decoded
+	Payload    string      // the entry's raw event
+	Deliveries int64       // times the group delivered the entry, set on reclaimed entries
+}
+
 // NewRedis creates a new Redis instance
 func NewRedis(addr string, password string, db int, log *logrus.Logger) (Redis, error) {
 	rdb := redis.NewClient(&redis.Options{
@@ -127,6 +160,12 @@ func (r *redisClient) GetExecutionStatus(ctx context.Context, executionID string
 	return models.ExecutionStatus(status), nil
 }
 
+func (r *redisClient) DeleteExecutionStatus(ctx context.Context, executionID string) error {
+	key := fmt.Sprintf("execution_status:%s", executionID)
+
+	return r.client.Del(ctx, key).Err()
+}
+
 func (r *redisClient) SetPerformanceMetrics(ctx context.Context, relationshipID string, metrics *models.PerformanceMetrics) error {
 	key := fmt.Sprintf("performance_metrics:%s", relationshipID)
 
@@ -249,7 +288,20 @@ func (r *redisClient) PublishTradeEvent(ctx context.Context, event *TradeEvent)
 		return fmt.Errorf("failed to marshal trade event: %w", err)
 	}
 
-	return r.client.Publish(ctx, "trade_events", data).Err()
+	// Append to the stream for consumer groups and publish for pub/sub subscribers
+	_, err = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: tradeEventsStream,
+			Values: map[string]interface{}{"event": data},
+		})
+		pipe.Publish(ctx, "trade_events", data)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish trade event: %w", err)
+	}
+
+	return nil
 }
 
 func (r *redisClient) SubscribeToTradeEvents(ctx context.Context) (<-chan *TradeEvent, error) {
@@ -281,6 +333,7 @@ func (r *redisClient) SubscribeToTradeEvents(ctx context.Context) (<-chan *Trade
 				case <-ctx.Done():
 					return
 				default:
+					r.droppedTradeEvents.Add(1)
 					r.log.Warn("Event channel full, dropping trade event")
 				}
 			}
@@ -289,3 +342,264 @@ func (r *redisClient) SubscribeToTradeEvents(ctx context.Context) (<-chan *Trade
 
 	return eventChan, nil
 }
+
+// DroppedTradeEvents returns how many trade events subscribers have missed because
+// their channel was full
+func (r *redisClient) DroppedTradeEvents() uint64 {
+	return r.droppedTradeEvents.Load()
+}
+
+// EnsureTradeStreamGroup creates the consumer group on the trade_events stream if it
+// does not exist. A new group starts at the end of the stream; from then on Redis
+// tracks its position, so events appended while no consumer runs are not lost.
+func (r *redisClient) EnsureTradeStreamGroup(ctx context.Context, group string) error {
+	err := r.client.XGroupCreateMkStream(ctx, tradeEventsStream, group, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group %s: %w", group, err)
+	}
+
+	return nil
+}
+
+// ReadTradeStream returns up to count new events for the consumer, waiting up to block
+// for one to arrive. It returns no messages and no error when the wait times out.
+func (r *redisClient) ReadTradeStream(ctx context.Context, group, consumer string, count int64, block time.Duration) ([]*TradeStreamMessage, error) {
+	streams, err := r.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  []string{tradeEventsStream, ">"},
+		Count:    count,
+		Block:    block,
+	}).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read trade stream: %w", err)
+	}
+
+	var messages []*TradeStreamMessage
+	for _, stream := range streams {
+		messages = append(messages, r.decodeTradeStreamMessages(stream.Messages)...)
+	}
+
+	return messages, nil
+}
+
+// ClaimStaleTradeEvents takes over events another consumer received but did not
+// acknowledge within minIdle, typically because it crashed while processing them or
+// failed to. Each message carries its delivery count, including this claim.
+func (r *redisClient) ClaimStaleTradeEvents(ctx context.Context, group, consumer string, minIdle time.Duration, count int64) ([]*TradeStreamMessage, error) {
+	var messages []*TradeStreamMessage
+
+	start := "0-0"
+	for {
+		claimed, next, err := r.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   tradeEventsStream,
+			Group:    group,
+			Consumer: consumer,
+			MinIdle:  minIdle,
+			Start:    start,
+			Count:    count,
+		}).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim stale trade events: %w", err)
+		}
+
+		batch := r.decodeTradeStreamMessages(claimed)
+		if err := r.countDeliveries(ctx, group, consumer, batch); err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, batch...)
+		if next == "0-0" || int64(len(messages)) >= count {
+			return messages, nil
+		}
+		start = next
+	}
+}
+
+// countDeliveries sets the delivery count of claimed messages from the group's
+// pending entries
+func (r *redisClient) countDeliveries(ctx context.Context, group, consumer string, messages []*TradeStreamMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	// Claimed entries are returned in ID order. The range may also hold entries this
+	// consumer is still processing; one pushed out of the page counts as delivered
+	// zero times until a later claim.
+	pending, err := r.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream:   tradeEventsStream,
+		Group:    group,
+		Consumer: consumer,
+		Start:    messages[0].ID,
+		End:      messages[len(messages)-1].ID,
+		Count:    int64(len(messages)) * 2,
+	}).Result()
+	if err != nil {
+		return fmt.Errorf("failed to inspect pending trade events: %w", err)
+	}
+
+	deliveries := make(map[string]int64, len(pending))
+	for _, entry := range pending {
+		deliveries[entry.ID] = entry.RetryCount
+	}
+	for _, message := range messages {
+		message.Deliveries = deliveries[message.ID]
+	}
+
+	return nil
+}
+
+// DeadLetterTradeEvent appends a pending event to the trade_events_dead_letter stream
+// and acknowledges it, in one transaction
+func (r *redisClient) DeadLetterTradeEvent(ctx context.Context, group string, message *TradeStreamMessage, reason string) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: tradeEventsDeadLetterStream,
+			Values: map[string]interface{}{
+				"id":         message.ID,
+				"group":      group,
+				"deliveries": message.Deliveries,
+				"reason":     reason,
+				"event":      message.Payload,
+			},
+		})
+		pipe.XAck(ctx, tradeEventsStream, group, message.ID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to dead-letter trade event %s: %w", message.ID, err)
+	}
+
+	return nil
+}
+
+// AckTradeEvents removes processed events from the group's pending entries
+func (r *redisClient) AckTradeEvents(ctx context.Context, group string, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := r.client.XAck(ctx, tradeEventsStream, group, ids...).Err(); err != nil {
+		return fmt.Errorf("failed to acknowledge trade events: %w", err)
+	}
+
+	return nil
+}
+
+// TrimTradeStream removes events older than olderThan. Events the group has not
+// yet received or acknowledged are always kept, whatever their age.
+func (r *redisClient) TrimTradeStream(ctx context.Context, group string, olderThan time.Time) (int64, error) {
+	minID := fmt.Sprintf("%d-0", olderThan.UnixMilli())
+
+	groups, err := r.client.XInfoGroups(ctx, tradeEventsStream).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to inspect trade stream groups: %w", err)
+	}
+	found := false
+	for _, info := range groups {
+		if info.Name == group {
+			found = true
+			minID = minStreamID(minID, info.LastDeliveredID)
+		}
+	}
+	if !found {
+		return 0, fmt.Errorf("consumer group %s does not exist", group)
+	}
+
+	pending, err := r.client.XPending(ctx, tradeEventsStream, group).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to inspect pending trade events: %w", err)
+	}
+	if pending.Count > 0 {
+		minID = minStreamID(minID, pending.Lower)
+	}
+
+	trimmed, err := r.client.XTrimMinID(ctx, tradeEventsStream, minID).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to trim trade stream: %w", err)
+	}
+
+	return trimmed, nil
+}
+
+// AppendCopyEvent adds an outbox event to a stream for downstream consumers, keeping
+// roughly the newest maxLen entries
+func (r *redisClient) AppendCopyEvent(ctx context.Context, stream string, event *models.OutboxEvent, maxLen int64) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", event.Type, err)
+	}
+
+	err = r.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: stream,
+		MaxLen: maxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"id":             event.ID,
+			"type":           string(event.Type),
+			"schema_version": event.SchemaVersion,
+			"event":          data,
+		},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to append %s event: %w", event.Type, err)
+	}
+
+	return nil
+}
+
+func (r *redisClient) decodeTradeStreamMessages(entries []redis.XMessage) []*TradeStreamMessage {
+	messages := make([]*TradeStreamMessage, 0, len(entries))
+	for _, entry := range entries {
+		payload, _ := entry.Values["event"].(string)
+		message := &TradeStreamMessage{ID: entry.ID, Payload: payload}
+
+		var event TradeEvent
+		if err := json.Unmarshal([]byte(payload), &event); err != nil {
+			r.log.Errorf("Failed to unmarshal trade stream entry %s: %v", entry.ID, err)
+		} else {
+			message.Event = &event
+		}
+
+		messages = append(messages, message)
+	}
+
+	return messages
+}
+
+// minStreamID returns the lower of two stream entry IDs ("<ms>-<seq>")
+func minStreamID(a, b string) string {
+	if compareStreamIDs(b, a) < 0 {
+		return b
+	}
+	return a
+}
+
+func compareStreamIDs(a, b string) int {
+	aMs, aSeq := parseStreamID(a)
+	bMs, bSeq := parseStreamID(b)
+
+	switch {
+	case aMs != bMs:
+		if aMs < bMs {
+			return -1
+		}
+		return 1
+	case aSeq != bSeq:
+		if aSeq < bSeq {
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
+
+func parseStreamID(id string) (ms, seq uint64) {
+	msPart, seqPart, _ := strings.Cut(id, "-")
+	ms, _ = strconv.ParseUint(msPart, 10, 64)
+	seq, _ = strconv.ParseUint(seqPart, 10, 64)
+	return ms, seq
+}
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hyperdash/copy-engine/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+func newTestRedis(t *testing.T) *redisClient {
+	t.Helper()
+	server := miniredis.RunT(t)
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	r, err := NewRedis(server.Addr(), "", 0, log)
+	if err != nil {
+		t.Fatalf("NewRedis: %v", err)
+	}
+	t.Cleanup(r.Close)
+	return r.(*redisClient)
+}
+
+func TestClaimStaleTradeEventsCountsDeliveries(t *testing.T) {
+	r := newTestRedis(t)
+	ctx := context.Background()
+
+	if err := r.EnsureTradeStreamGroup(ctx, "copy-engine"); err != nil {
+		t.Fatalf("EnsureTradeStreamGroup: %v", err)
+	}
+	traderID := "trader-1"
+	event := &TradeEvent{Type: "trade", TraderID: traderID, Trade: &models.Trade{ID: "trade-1", TraderID: &traderID}}
+	if err := r.PublishTradeEvent(ctx, event); err != nil {
+		t.Fatalf("PublishTradeEvent: %v", err)
+	}
+
+	read, err := r.ReadTradeStream(ctx, "copy-engine", "consumer-a", 10, 10*time.Millisecond)
+	if err != nil || len(read) != 1 {
+		t.Fatalf("ReadTradeStream: %d messages, %v", len(read), err)
+	}
+
+	for want := int64(2); want <= 3; want++ {
+		time.Sleep(5 * time.Millisecond)
+		claimed, err := r.ClaimStaleTradeEvents(ctx, "copy-engine", "consumer-b", time.Millisecond, 10)
+		if err != nil {
+			t.Fatalf("ClaimStaleTradeEvents: %v", err)
+		}
+		if len(claimed) != 1 || claimed[0].ID != read[0].ID {
+			t.Fatalf("claimed %v, want %s", claimed, read[0].ID)
+		}
+		if claimed[0].Deliveries != want {
+			t.Fatalf("deliveries %d, want %d", claimed[0].Deliveries, want)
+		}
+		if claimed[0].Event == nil || claimed[0].Event.Trade.ID != "trade-1" {
+			t.Fatalf("claimed event %+v, want trade-1", claimed[0].Event)
+		}
+	}
+}
+
+func TestDeadLetterTradeEventMovesAndAcknowledges(t *testing.T) {
+	r := newTestRedis(t)
+	ctx := context.Background()
+
+	if err := r.EnsureTradeStreamGroup(ctx, "copy-engine"); err != nil {
+		t.Fatalf("EnsureTradeStreamGroup: %v", err)
+	}
+	if err := r.PublishTradeEvent(ctx, &TradeEvent{Type: "trade", TraderID: "trader-1"}); err != nil {
+		t.Fatalf("PublishTradeEvent: %v", err)
+	}
+	read, err := r.ReadTradeStream(ctx, "copy-engine", "consumer-a", 10, 10*time.Millisecond)
+	if err != nil || len(read) != 1 {
+		t.Fatalf("ReadTradeStream: %d messages, %v", len(read), err)
+	}
+	message := read[0]
+	message.Deliveries = 9
+
+	if err := r.DeadLetterTradeEvent(ctx, "copy-engine", message, "delivered 9 times"); err != nil {
+		t.Fatalf("DeadLetterTradeEvent: %v", err)
+	}
+
+	pending, err := r.client.XPending(ctx, tradeEventsStream, "copy-engine").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending.Count != 0 {
+		t.Errorf("%d events still pending", pending.Count)
+	}
+
+	entries, err := r.client.XRange(ctx, tradeEventsDeadLetterStream, "-", "+").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("%d dead-lettered entries, want 1", len(entries))
+	}
+	want := map[string]interface{}{
+		"id":         message.ID,
+		"group":      "copy-engine",
+		"deliveries": "9",
+		"reason":     "delivered 9 times",
+		"event":      message.Payload,
+	}
+	for field, value := range want {
+		if entries[0].Values[field] != value {
+			t.Errorf("%s = %v, want %v", field, entries[0].Values[field], value)
+		}
+	}
+}
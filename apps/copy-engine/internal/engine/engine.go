@@ -2,6 +2,8 @@ package engine
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"sync"
 	"time"
@@ -11,10 +13,16 @@ import (
 	"github.com/hyperdash/copy-engine/internal/risk"
 )
 
+var (
+	ErrStrategyNotFound = errors.New("strategy not found")
+	ErrStrategyExists   = errors.New("strategy already exists")
+)
+
 type Engine struct {
 	config          *config.Config
 	exchangeAdapter exchange.Adapter
 	riskManager     *risk.Manager
+	store           Store
 
 	// State management
 	strategies       map[string]*Strategy
@@ -43,6 +51,96 @@ type Strategy struct {
 	mutex           sync.RWMutex
 }
 
+// Account is the exchange account the strategy trades: its owner's, which the agent
+// key store binds to the owner's master address
+func (s *Strategy) Account() string {
+	return s.UserID
+}
+
+// StrategyID implements risk.Strategy
+func (s *Strategy) StrategyID() string {
+	return s.ID
+}
+
+// RiskLimits implements risk.Strategy
+func (s *Strategy) RiskLimits() risk.Limits {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return risk.Limits{
+		MaxLeverage:  s.RiskParams.MaxLeverage,
+		MaxSlippage:  s.RiskParams.MaxSlippage,
+		MinOrderSize: s.RiskParams.MinOrderSize,
+	}
+}
+
+// TraderAllocations implements risk.Strategy
+func (s *Strategy) TraderAllocations() []risk.Allocation {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	allocations := make([]risk.Allocation, 0, len(s.Traders))
+	for _, trader := range s.Traders {
+		allocations = append(allocations, risk.Allocation{TraderID: trader.TraderID, Weight: trader.Weight})
+	}
+	return allocations
+}
+
+// StrategySnapshot is a copy of a strategy's state taken under its lock
+type StrategySnapshot struct {
+	ID            string
+	UserID        string
+	Name          string
+	Traders       []TraderAllocation
+	RiskParams    RiskParameters
+	Status        StrategyStatus
+	LastExecution time.Time
+	AlignmentRate float64
+}
+
+// Snapshot returns a copy of the strategy's state, safe to read while the engine
+// keeps executing it
+func (s *Strategy) Snapshot() StrategySnapshot {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return StrategySnapshot{
+		ID:            s.ID,
+		UserID:        s.UserID,
+		Name:          s.Name,
+		Traders:       append([]TraderAllocation(nil), s.Traders...),
+		RiskParams:    s.RiskParams,
+		Status:        s.Status,
+		LastExecution: s.LastExecution,
+		AlignmentRate: s.AlignmentRate,
+	}
+}
+
+// CurrentStatus returns the strategy's status
+func (s *Strategy) CurrentStatus() StrategyStatus {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.Status
+}
+
+func (s *Strategy) setStatus(status StrategyStatus) {
+	s.mutex.Lock()
+	s.Status = status
+	s.mutex.Unlock()
+}
+
+// GetPositions returns a copy of the positions tracked for the strategy
+func (s *Strategy) GetPositions() []Position {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	positions := make([]Position, 0, len(s.Positions))
+	for _, position := range s.Positions {
+		positions = append(positions, *position)
+	}
+	return positions
+}
+
 type TraderAllocation struct {
 	TraderID string
 	Weight   float64
@@ -96,14 +194,18 @@ type Metrics struct {
 	FailedExecutions     int
 	AverageLatency       time.Duration
 	AlignmentRate        float64
+	OrdersPlaced         int
+	PartialFills         int
+	RejectedOrders       int
 	mutex                 sync.RWMutex
 }
 
-func NewEngine(cfg *config.Config, exchangeAdapter exchange.Adapter, riskManager *risk.Manager) *Engine {
+func NewEngine(cfg *config.Config, exchangeAdapter exchange.Adapter, riskManager *risk.Manager, store Store) *Engine {
 	return &Engine{
 		config:          cfg,
 		exchangeAdapter: exchangeAdapter,
 		riskManager:     riskManager,
+		store:           store,
 		strategies:      make(map[string]*Strategy),
 		activeStrategies: make(map[string]bool),
 		commandChan:     make(chan Command, 100),
@@ -118,6 +220,11 @@ func (e *Engine) Start(ctx context.Context) error {
 	e.wg.Add(1)
 	go e.commandProcessor()
 
+	// Recover persisted strategies before the first tick can trade on them
+	if err := e.recoverStrategies(ctx); err != nil {
+		return fmt.Errorf("failed to recover strategies: %w", err)
+	}
+
 	e.wg.Add(1)
 	go e.positionMonitor(ctx)
 
@@ -160,7 +267,7 @@ func (e *Engine) StartStrategy(strategy *Strategy) error {
 	defer e.strategiesMutex.Unlock()
 
 	if _, exists := e.strategies[strategy.ID]; exists {
-		return fmt.Errorf("strategy %s already exists", strategy.ID)
+		return fmt.Errorf("%w: %s", ErrStrategyExists, strategy.ID)
 	}
 
 	e.strategies[strategy.ID] = strategy
@@ -183,11 +290,12 @@ func (e *Engine) StopStrategy(strategyID string) error {
 
 	strategy, exists := e.strategies[strategyID]
 	if !exists {
-		return fmt.Errorf("strategy %s not found", strategyID)
+		return fmt.Errorf("%w: %s", ErrStrategyNotFound, strategyID)
 	}
 
 	e.activeStrategies[strategyID] = false
-	strategy.Status = StatusPaused
+	strategy.setStatus(StatusPaused)
+	e.persistStatus(strategyID, StatusPaused)
 
 	e.commandChan <- Command{
 		Type:      CommandStopStrategy,
@@ -198,10 +306,133 @@ func (e *Engine) StopStrategy(strategyID string) error {
 	return nil
 }
 
+// CreateStrategy registers a strategy without starting it
+func (e *Engine) CreateStrategy(strategy *Strategy) error {
+	e.strategiesMutex.Lock()
+	defer e.strategiesMutex.Unlock()
+
+	if _, exists := e.strategies[strategy.ID]; exists {
+		return fmt.Errorf("%w: %s", ErrStrategyExists, strategy.ID)
+	}
+
+	e.applyRiskDefaults(&strategy.RiskParams)
+	if err := e.riskManager.ValidateStrategy(strategy); err != nil {
+		return err
+	}
+
+	if strategy.Positions == nil {
+		strategy.Positions = make(map[string]*Position)
+	}
+	strategy.Status = StatusPaused
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	if err := e.store.SaveStrategy(ctx, strategy); err != nil {
+		return fmt.Errorf("failed to persist strategy: %w", err)
+	}
+
+	e.strategies[strategy.ID] = strategy
+	e.activeStrategies[strategy.ID] = false
+
+	log.Printf("Strategy %s created", strategy.ID)
+	return nil
+}
+
+// ResumeStrategy starts a previously created or stopped strategy
+func (e *Engine) ResumeStrategy(strategyID string) error {
+	e.strategiesMutex.Lock()
+	defer e.strategiesMutex.Unlock()
+
+	strategy, exists := e.strategies[strategyID]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrStrategyNotFound, strategyID)
+	}
+	if strategy.CurrentStatus() == StatusTerminated {
+		return fmt.Errorf("strategy %s is terminated", strategyID)
+	}
+
+	e.activeStrategies[strategyID] = true
+
+	e.commandChan <- Command{
+		Type:       CommandStartStrategy,
+		StrategyID: strategyID,
+		Data:       strategy,
+	}
+
+	log.Printf("Strategy %s resumed", strategyID)
+	return nil
+}
+
+// StrategyUpdate holds the mutable fields of a strategy; nil fields are left unchanged
+type StrategyUpdate struct {
+	Name       *string
+	Traders    []TraderAllocation
+	RiskParams *RiskParameters
+}
+
+// UpdateStrategy validates and applies changes to a strategy
+func (e *Engine) UpdateStrategy(strategyID string, update StrategyUpdate) error {
+	strategy, err := e.GetStrategy(strategyID)
+	if err != nil {
+		return err
+	}
+
+	// Validate the strategy as it would look after the update
+	strategy.mutex.RLock()
+	candidate := &Strategy{
+		ID:         strategy.ID,
+		Traders:    strategy.Traders,
+		RiskParams: strategy.RiskParams,
+	}
+	strategy.mutex.RUnlock()
+
+	if update.Traders != nil {
+		candidate.Traders = update.Traders
+	}
+	if update.RiskParams != nil {
+		e.applyRiskDefaults(update.RiskParams)
+		candidate.RiskParams = *update.RiskParams
+	}
+	if err := e.riskManager.ValidateStrategy(candidate); err != nil {
+		return err
+	}
+
+	e.commandChan <- Command{
+		Type:       CommandUpdateStrategy,
+		StrategyID: strategyID,
+		Data:       update,
+	}
+
+	return nil
+}
+
+// applyRiskDefaults fills in the minimum order size when a request leaves it unset,
+// so strategies inherit the configured floor instead of failing validation at 0
+func (e *Engine) applyRiskDefaults(params *RiskParameters) {
+	if params.MinOrderSize == 0 {
+		params.MinOrderSize = e.config.Risk.MinOrderSize
+	}
+}
+
+// ListStrategies returns all strategies known to the engine
+func (e *Engine) ListStrategies() []*Strategy {
+	e.strategiesMutex.RLock()
+	defer e.strategiesMutex.RUnlock()
+
+	strategies := make([]*Strategy, 0, len(e.strategies))
+	for _, strategy := range e.strategies {
+		strategies = append(strategies, strategy)
+	}
+
+	return strategies
+}
+
+// stopStrategy halts a strategy on shutdown. The terminated status is kept in memory
+// only, so the persisted status still resumes the strategy on restart.
 func (e *Engine) stopStrategy(strategyID string) {
 	e.activeStrategies[strategyID] = false
 	if strategy, exists := e.strategies[strategyID]; exists {
-		strategy.Status = StatusTerminated
+		strategy.setStatus(StatusTerminated)
 	}
 }
 
@@ -237,11 +468,13 @@ func (e *Engine) handleStartStrategy(command Command) {
 	// Validate strategy with risk manager
 	if err := e.riskManager.ValidateStrategy(strategy); err != nil {
 		log.Printf("Risk validation failed for strategy %s: %v", strategy.ID, err)
-		strategy.Status = StatusError
+		strategy.setStatus(StatusError)
+		e.persistStatus(strategy.ID, StatusError)
 		return
 	}
 
-	strategy.Status = StatusActive
+	strategy.setStatus(StatusActive)
+	e.persistStatus(strategy.ID, StatusActive)
 	log.Printf("Strategy %s processing started", strategy.ID)
 }
 
@@ -251,7 +484,36 @@ func (e *Engine) handleStopStrategy(command Command) {
 }
 
 func (e *Engine) handleUpdateStrategy(command Command) {
-	// Handle strategy update logic
+	update, ok := command.Data.(StrategyUpdate)
+	if !ok {
+		log.Printf("Invalid update for strategy %s", command.StrategyID)
+		return
+	}
+
+	strategy, err := e.GetStrategy(command.StrategyID)
+	if err != nil {
+		log.Printf("Failed to update strategy: %v", err)
+		return
+	}
+
+	strategy.mutex.Lock()
+	if update.Name != nil {
+		strategy.Name = *update.Name
+	}
+	if update.Traders != nil {
+		strategy.Traders = update.Traders
+	}
+	if update.RiskParams != nil {
+		strategy.RiskParams = *update.RiskParams
+	}
+	strategy.mutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	if err := e.store.SaveStrategy(ctx, strategy); err != nil {
+		log.Printf("Failed to persist update for strategy %s: %v", command.StrategyID, err)
+	}
+
 	log.Printf("Strategy %s updated", command.StrategyID)
 }
 
@@ -295,46 +557,152 @@ func (e *Engine) checkAndUpdatePositions() {
 func (e *Engine) processStrategy(strategy *Strategy) {
 	start := time.Now()
 
+	ctx, cancel := context.WithTimeout(context.Background(), e.executionTimeout())
+	defer cancel()
+
 	// Get current positions from exchange
-	positions, err := e.exchangeAdapter.GetCurrentPositions(strategy.ID)
+	positions, err := e.exchangeAdapter.GetCurrentPositions(strategy.Account())
 	if err != nil {
 		log.Printf("Failed to get positions for strategy %s: %v", strategy.ID, err)
-		e.metrics.FailedExecutions++
+		e.incrementMetric(func(m *Metrics) { m.FailedExecutions++ })
+		return
+	}
+
+	prices, err := e.exchangeAdapter.GetMidPrices(ctx)
+	if err != nil {
+		log.Printf("Failed to get prices for strategy %s: %v", strategy.ID, err)
+		e.incrementMetric(func(m *Metrics) { m.FailedExecutions++ })
 		return
 	}
 
 	// Calculate target positions based on trader allocations
-	targetPositions := e.calculateTargetPositions(strategy)
+	targetPositions, err := e.calculateTargetPositions(ctx, strategy, prices)
+	if err != nil {
+		// Never act on a partial view of the traders, it would unwind positions
+		log.Printf("Failed to calculate target positions for strategy %s: %v", strategy.ID, err)
+		e.incrementMetric(func(m *Metrics) { m.FailedExecutions++ })
+		return
+	}
 
 	// Calculate position deltas
-	deltas := e.calculatePositionDeltas(positions, targetPositions)
+	deltas := e.calculatePositionDeltas(positions, targetPositions, prices)
 
 	// Execute trades if needed
 	if len(deltas) > 0 {
-		if err := e.executePositionDeltas(strategy, deltas); err != nil {
+		if err := e.executePositionDeltas(ctx, strategy, positions, deltas, prices); err != nil {
 			log.Printf("Failed to execute position deltas for strategy %s: %v", strategy.ID, err)
-			e.metrics.FailedExecutions++
+			e.incrementMetric(func(m *Metrics) { m.FailedExecutions++ })
 		} else {
-			e.metrics.SuccessfulExecutions++
+			e.incrementMetric(func(m *Metrics) { m.SuccessfulExecutions++ })
 		}
 	}
 
 	// Update alignment rate
-	strategy.AlignmentRate = e.calculateAlignmentRate(positions, targetPositions)
+	alignmentRate := e.calculateAlignmentRate(positions, targetPositions)
+	strategy.mutex.Lock()
+	strategy.AlignmentRate = alignmentRate
+	strategy.LastExecution = time.Now()
+	strategy.mutex.Unlock()
 
 	// Update metrics
 	latency := time.Since(start)
 	e.updateMetrics(latency)
+}
 
-	strategy.LastExecution = time.Now()
+// executionTimeout bounds the exchange calls made for one strategy in one tick
+func (e *Engine) executionTimeout() time.Duration {
+	timeout := time.Duration(e.config.Engine.ExecutionInterval) * time.Second
+	if timeout < 5*time.Second {
+		timeout = 5 * time.Second
+	}
+	return timeout
 }
 
-func (e *Engine) calculateTargetPositions(strategy *Strategy) map[string]float64 {
-	// TODO: Implement target position calculation based on trader allocations
-	return make(map[string]float64)
+// calculateTargetPositions mirrors each followed trader's positions, scaled by
+// the allocation weight and the follower's equity relative to the trader's,
+// nets them per symbol and scales the result down to the strategy's max leverage.
+func (e *Engine) calculateTargetPositions(ctx context.Context, strategy *Strategy, prices map[string]float64) (map[string]float64, error) {
+	strategy.mutex.RLock()
+	traders := append([]TraderAllocation(nil), strategy.Traders...)
+	maxLeverage := strategy.RiskParams.MaxLeverage
+	strategy.mutex.RUnlock()
+
+	if maxLeverage <= 0 || maxLeverage > e.config.Risk.MaxLeverage {
+		maxLeverage = e.config.Risk.MaxLeverage
+	}
+
+	followerBalance, err := e.exchangeAdapter.GetBalances(ctx, strategy.Account())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get follower balance: %w", err)
+	}
+
+	targets := make(map[string]float64)
+	if followerBalance.AccountValue <= 0 {
+		return targets, nil
+	}
+
+	// Allocations name traders by ID; their books are read from their on-chain addresses
+	traderIDs := make([]string, 0, len(traders))
+	for _, trader := range traders {
+		traderIDs = append(traderIDs, trader.TraderID)
+	}
+	addresses, err := e.store.GetTraderAddresses(ctx, traderIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trader addresses: %w", err)
+	}
+
+	for _, trader := range traders {
+		if trader.Weight <= 0 {
+			continue
+		}
+
+		address, ok := addresses[trader.TraderID]
+		if !ok {
+			return nil, fmt.Errorf("trader %s has no known address", trader.TraderID)
+		}
+
+		traderBalance, err := e.exchangeAdapter.GetBalances(ctx, address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get balance of trader %s: %w", trader.TraderID, err)
+		}
+		if traderBalance.AccountValue <= 0 {
+			// Usually a bad read; dropping the allocation would unwind the follower
+			return nil, fmt.Errorf("trader %s reports no equity", trader.TraderID)
+		}
+
+		traderPositions, err := e.exchangeAdapter.GetPositions(ctx, address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get positions of trader %s: %w", trader.TraderID, err)
+		}
+
+		scale := trader.Weight * followerBalance.AccountValue / traderBalance.AccountValue
+		for _, position := range traderPositions {
+			targets[position.Symbol] += position.Size * scale
+
+			// Fall back to the trader's mark when the symbol has no mid price
+			if _, ok := prices[position.Symbol]; !ok && position.Size != 0 {
+				prices[position.Symbol] = position.PositionValue / abs(position.Size)
+			}
+		}
+	}
+
+	var notional float64
+	for symbol, quantity := range targets {
+		notional += abs(quantity) * prices[symbol]
+	}
+
+	if leverage := notional / followerBalance.AccountValue; leverage > maxLeverage {
+		factor := maxLeverage / leverage
+		for symbol := range targets {
+			targets[symbol] *= factor
+		}
+		log.Printf("Scaled strategy %s targets by %.4f to respect %.2fx max leverage", strategy.ID, factor, maxLeverage)
+	}
+
+	return targets, nil
 }
 
-func (e *Engine) calculatePositionDeltas(current, target map[string]float64) map[string]float64 {
+func (e *Engine) calculatePositionDeltas(current, target, prices map[string]float64) map[string]float64 {
 	deltas := make(map[string]float64)
 
 	for symbol, targetQty := range target {
@@ -342,17 +710,19 @@ func (e *Engine) calculatePositionDeltas(current, target map[string]float64) map
 		delta := targetQty - currentQty
 
 		// Apply minimum order size filter
-		if abs(delta) >= e.config.Risk.MinOrderSize {
+		if abs(delta)*prices[symbol] >= e.config.Risk.MinOrderSize {
 			deltas[symbol] = delta
 		}
 	}
 
-	return deltas
-}
+	// Close positions the followed traders no longer hold
+	for symbol, currentQty := range current {
+		if _, ok := target[symbol]; !ok && currentQty != 0 {
+			deltas[symbol] = -currentQty
+		}
+	}
 
-func (e *Engine) executePositionDeltas(strategy *Strategy, deltas map[string]float64) error {
-	// TODO: Implement position delta execution with risk management
-	return nil
+	return deltas
 }
 
 func (e *Engine) calculateAlignmentRate(current, target map[string]float64) float64 {
@@ -390,15 +760,22 @@ func (e *Engine) updateMetrics(latency time.Duration) {
 
 	// Calculate overall alignment rate
 	var totalAlignment float64
-	var activeCount int
+	var activeCount, positionCount int
 
 	for _, strategy := range e.strategies {
+		strategy.mutex.RLock()
+		positionCount += len(strategy.Positions)
+		alignmentRate := strategy.AlignmentRate
+		strategy.mutex.RUnlock()
+
 		if e.activeStrategies[strategy.ID] {
-			totalAlignment += strategy.AlignmentRate
+			totalAlignment += alignmentRate
 			activeCount++
 		}
 	}
 
+	e.metrics.TotalPositions = positionCount
+
 	if activeCount > 0 {
 		e.metrics.AlignmentRate = totalAlignment / float64(activeCount)
 	}
@@ -407,7 +784,19 @@ func (e *Engine) updateMetrics(latency time.Duration) {
 func (e *Engine) GetMetrics() Metrics {
 	e.metrics.mutex.RLock()
 	defer e.metrics.mutex.RUnlock()
-	return *e.metrics
+
+	return Metrics{
+		TotalStrategies:      e.metrics.TotalStrategies,
+		ActiveStrategies:     e.metrics.ActiveStrategies,
+		TotalPositions:       e.metrics.TotalPositions,
+		SuccessfulExecutions: e.metrics.SuccessfulExecutions,
+		FailedExecutions:     e.metrics.FailedExecutions,
+		AverageLatency:       e.metrics.AverageLatency,
+		AlignmentRate:        e.metrics.AlignmentRate,
+		OrdersPlaced:         e.metrics.OrdersPlaced,
+		PartialFills:         e.metrics.PartialFills,
+		RejectedOrders:       e.metrics.RejectedOrders,
+	}
 }
 
 func (e *Engine) GetStrategy(strategyID string) (*Strategy, error) {
@@ -416,7 +805,7 @@ func (e *Engine) GetStrategy(strategyID string) (*Strategy, error) {
 
 	strategy, exists := e.strategies[strategyID]
 	if !exists {
-		return nil, fmt.Errorf("strategy %s not found", strategyID)
+		return nil, fmt.Errorf("%w: %s", ErrStrategyNotFound, strategyID)
 	}
 
 	return strategy, nil
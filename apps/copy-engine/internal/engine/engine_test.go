@@ -0,0 +1,232 @@
+package engine
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/hyperdash/copy-engine/internal/exchange"
+)
+
+const testTraderAddress = "0x00000000000000000000000000000000000000aa"
+
+// addressStore knows the addresses of followed traders and holds nothing else
+type addressStore struct {
+	Store
+
+	addresses map[string]string
+}
+
+func (s *addressStore) GetTraderAddresses(ctx context.Context, traderIDs []string) (map[string]string, error) {
+	addresses := make(map[string]string)
+	for _, id := range traderIDs {
+		if address, ok := s.addresses[id]; ok {
+			addresses[id] = address
+		}
+	}
+	return addresses, nil
+}
+
+func testStrategy(traders ...TraderAllocation) *Strategy {
+	return &Strategy{
+		ID:         "strategy-1",
+		UserID:     "follower-1",
+		Traders:    traders,
+		RiskParams: RiskParameters{MaxLeverage: 3, MaxSlippage: 10, MinOrderSize: 10},
+		Status:     StatusActive,
+	}
+}
+
+func TestProcessStrategyMirrorsTraderOnSimulatedExchange(t *testing.T) {
+	sim := exchange.NewSimulatedAdapter(exchange.DefaultSimulatedConfig())
+	sim.SetPrice("ETH", 3000)
+	// The trader's 100000 of equity against the follower's 10000 scales copies to a tenth
+	sim.Deposit(testTraderAddress, 90000)
+	sim.SetPosition(testTraderAddress, "ETH", 10, 3000)
+
+	e := newTestEngine(testConfig(), sim, &addressStore{addresses: map[string]string{"trader-1": testTraderAddress}})
+	strategy := testStrategy(TraderAllocation{TraderID: "trader-1", Weight: 1})
+
+	steps := []struct {
+		name         string
+		traderSize   float64
+		wantFollower float64
+	}{
+		{"trader opens", 10, 1},
+		{"trader reduces", 5, 0.5},
+		{"trader closes", 0, 0},
+	}
+
+	for _, step := range steps {
+		sim.SetPosition(testTraderAddress, "ETH", step.traderSize, 3000)
+		e.processStrategy(strategy)
+
+		positions, err := sim.GetCurrentPositions(strategy.Account())
+		if err != nil {
+			t.Fatalf("GetCurrentPositions: %v", err)
+		}
+		// Fees and slippage shave a little off the follower's equity
+		if got := positions["ETH"]; math.Abs(got-step.wantFollower) > 0.001 {
+			t.Errorf("%s: follower holds %v ETH, want about %v", step.name, got, step.wantFollower)
+		}
+	}
+
+	fills, _ := sim.GetFills(context.Background(), strategy.Account(), time.Time{})
+	if len(fills) != 3 {
+		t.Errorf("%d fills, want one per step", len(fills))
+	}
+	if metrics := e.GetMetrics(); metrics.SuccessfulExecutions != 3 || metrics.FailedExecutions != 0 {
+		t.Errorf("%d successful and %d failed executions, want 3 and 0", metrics.SuccessfulExecutions, metrics.FailedExecutions)
+	}
+}
+
+// bookAdapter serves fixed balances and positions per account
+type bookAdapter struct {
+	exchange.Adapter
+
+	equity    map[string]float64
+	positions map[string][]*exchange.Position
+}
+
+func (a *bookAdapter) GetBalances(ctx context.Context, account string) (*exchange.Balance, error) {
+	return &exchange.Balance{AccountValue: a.equity[account]}, nil
+}
+
+func (a *bookAdapter) GetPositions(ctx context.Context, account string) ([]*exchange.Position, error) {
+	return a.positions[account], nil
+}
+
+func TestCalculateTargetPositions(t *testing.T) {
+	const traderA, traderB = "0x00000000000000000000000000000000000000aa", "0x00000000000000000000000000000000000000bb"
+	store := &addressStore{addresses: map[string]string{"trader-a": traderA, "trader-b": traderB}}
+	prices := map[string]float64{"BTC": 60000, "ETH": 3000}
+
+	tests := []struct {
+		name        string
+		equity      map[string]float64
+		positions   map[string][]*exchange.Position
+		traders     []TraderAllocation
+		maxLeverage float64
+		want        map[string]float64
+		wantErr     bool
+	}{
+		{
+			name:      "scaled by weight and equity",
+			equity:    map[string]float64{"follower-1": 10000, traderA: 100000},
+			positions: map[string][]*exchange.Position{traderA: {{Symbol: "ETH", Size: 20}, {Symbol: "BTC", Size: -0.5}}},
+			traders:   []TraderAllocation{{TraderID: "trader-a", Weight: 0.5}},
+			want:      map[string]float64{"ETH": 1, "BTC": -0.025},
+		},
+		{
+			name:   "netted across traders",
+			equity: map[string]float64{"follower-1": 10000, traderA: 100000, traderB: 20000},
+			positions: map[string][]*exchange.Position{
+				traderA: {{Symbol: "ETH", Size: 20}},
+				traderB: {{Symbol: "ETH", Size: -2}, {Symbol: "BTC", Size: 0.1}},
+			},
+			traders: []TraderAllocation{{TraderID: "trader-a", Weight: 0.5}, {TraderID: "trader-b", Weight: 0.5}},
+			want:    map[string]float64{"ETH": 0.5, "BTC": 0.025},
+		},
+		{
+			name:      "zero weight skipped",
+			equity:    map[string]float64{"follower-1": 10000, traderA: 100000},
+			positions: map[string][]*exchange.Position{traderA: {{Symbol: "ETH", Size: 20}}, traderB: {{Symbol: "BTC", Size: 1}}},
+			traders:   []TraderAllocation{{TraderID: "trader-a", Weight: 0.5}, {TraderID: "trader-b", Weight: 0}},
+			want:      map[string]float64{"ETH": 1},
+		},
+		{
+			name:        "scaled to the strategy leverage",
+			equity:      map[string]float64{"follower-1": 10000, traderA: 10000},
+			positions:   map[string][]*exchange.Position{traderA: {{Symbol: "ETH", Size: 10}, {Symbol: "BTC", Size: -0.5}}},
+			traders:     []TraderAllocation{{TraderID: "trader-a", Weight: 1}},
+			maxLeverage: 2,
+			// 60000 of notional against 10000 of equity is scaled down to 20000
+			want: map[string]float64{"ETH": 10.0 / 3, "BTC": -0.5 / 3},
+		},
+		{
+			name:        "strategy leverage capped by the service",
+			equity:      map[string]float64{"follower-1": 10000, traderA: 10000},
+			positions:   map[string][]*exchange.Position{traderA: {{Symbol: "ETH", Size: 30}}},
+			traders:     []TraderAllocation{{TraderID: "trader-a", Weight: 1}},
+			maxLeverage: 20,
+			want:        map[string]float64{"ETH": 50000.0 / 3000},
+		},
+		{
+			name:      "priced from the trader's mark without a mid",
+			equity:    map[string]float64{"follower-1": 10000, traderA: 10000},
+			positions: map[string][]*exchange.Position{traderA: {{Symbol: "HYPE", Size: 10000, PositionValue: 300000}}},
+			traders:   []TraderAllocation{{TraderID: "trader-a", Weight: 1}},
+			want:      map[string]float64{"HYPE": 10000.0 / 6},
+		},
+		{
+			name:    "follower without equity",
+			equity:  map[string]float64{traderA: 100000},
+			traders: []TraderAllocation{{TraderID: "trader-a", Weight: 1}},
+			want:    map[string]float64{},
+		},
+		{
+			name:      "trader without equity",
+			equity:    map[string]float64{"follower-1": 10000},
+			positions: map[string][]*exchange.Position{traderA: {{Symbol: "ETH", Size: 20}}},
+			traders:   []TraderAllocation{{TraderID: "trader-a", Weight: 1}},
+			wantErr:   true,
+		},
+		{
+			name:    "trader without address",
+			equity:  map[string]float64{"follower-1": 10000},
+			traders: []TraderAllocation{{TraderID: "trader-c", Weight: 1}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEngine(testConfig(), &bookAdapter{equity: tt.equity, positions: tt.positions}, store)
+			strategy := testStrategy(tt.traders...)
+			strategy.RiskParams.MaxLeverage = tt.maxLeverage
+
+			quotes := make(map[string]float64)
+			for symbol, price := range prices {
+				quotes[symbol] = price
+			}
+			targets, err := e.calculateTargetPositions(context.Background(), strategy, quotes)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("targets %v, want an error", targets)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("calculateTargetPositions: %v", err)
+			}
+
+			if len(targets) != len(tt.want) {
+				t.Fatalf("targets %v, want %v", targets, tt.want)
+			}
+			for symbol, want := range tt.want {
+				if got, ok := targets[symbol]; !ok || math.Abs(got-want) > 1e-9 {
+					t.Errorf("%s target %v, want %v", symbol, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessStrategyKeepsPositionsWhenATraderReportsNoEquity(t *testing.T) {
+	sim := exchange.NewSimulatedAdapter(exchange.DefaultSimulatedConfig())
+	sim.SetPrice("ETH", 3000)
+	sim.SetPosition("follower-1", "ETH", 1, 3000)
+	sim.Deposit(testTraderAddress, -10000)
+
+	e := newTestEngine(testConfig(), sim, &addressStore{addresses: map[string]string{"trader-1": testTraderAddress}})
+	e.processStrategy(testStrategy(TraderAllocation{TraderID: "trader-1", Weight: 1}))
+
+	positions, _ := sim.GetCurrentPositions("follower-1")
+	if positions["ETH"] != 1 {
+		t.Errorf("follower holds %v ETH, want the 1 ETH left in place", positions["ETH"])
+	}
+	if metrics := e.GetMetrics(); metrics.FailedExecutions != 1 {
+		t.Errorf("%d failed executions, want the tick counted as failed", metrics.FailedExecutions)
+	}
+}
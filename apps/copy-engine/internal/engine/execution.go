@@ -0,0 +1,272 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/hyperdash/copy-engine/internal/exchange"
+	"github.com/hyperdash/copy-engine/internal/risk"
+)
+
+// maxChildOrders bounds how many child orders a single delta is sliced into per tick;
+// whatever remains is picked up again on the next tick.
+const maxChildOrders = 20
+
+// symbolOutcome records how a single symbol delta was executed
+type symbolOutcome struct {
+	Symbol    string
+	Requested float64 // signed quantity
+	Filled    float64 // signed quantity
+	AvgPrice  float64
+	Orders    int
+	Partial   bool
+	Err       error
+}
+
+// executePositionDeltas turns per-symbol deltas into sliced IOC orders bounded by
+// the strategy's slippage limit. Reductions are executed first to free margin.
+func (e *Engine) executePositionDeltas(ctx context.Context, strategy *Strategy, current, deltas, prices map[string]float64) error {
+	balance, err := e.exchangeAdapter.GetBalances(ctx, strategy.Account())
+	if err != nil {
+		return fmt.Errorf("failed to get balance: %w", err)
+	}
+
+	limits := strategy.RiskLimits()
+
+	symbols := make([]string, 0, len(deltas))
+	for symbol := range deltas {
+		symbols = append(symbols, symbol)
+	}
+	sort.Slice(symbols, func(i, j int) bool {
+		ri, rj := reducesExposure(current[symbols[i]], deltas[symbols[i]]), reducesExposure(current[symbols[j]], deltas[symbols[j]])
+		if ri != rj {
+			return ri
+		}
+		return symbols[i] < symbols[j]
+	})
+
+	notional := balance.TotalNotional
+	var failures []string
+
+	for _, symbol := range symbols {
+		outcome := e.executeSymbolDelta(ctx, strategy, limits, symbol, current[symbol], deltas[symbol], prices[symbol], balance.AccountValue, &notional)
+		e.recordOutcome(strategy, outcome, current[symbol], prices[symbol])
+
+		if outcome.Err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", symbol, outcome.Err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d symbols failed: %s", len(failures), len(symbols), strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+func (e *Engine) executeSymbolDelta(ctx context.Context, strategy *Strategy, limits risk.Limits, symbol string,
+	position, delta, mid, accountValue float64, notional *float64) *symbolOutcome {
+
+	outcome := &symbolOutcome{Symbol: symbol, Requested: delta}
+	if mid <= 0 {
+		outcome.Err = fmt.Errorf("no price available")
+		return outcome
+	}
+
+	side := exchange.SideBuy
+	if delta < 0 {
+		side = exchange.SideSell
+	}
+
+	slippageBps := e.config.Risk.MaxSlippage
+	if limits.MaxSlippage > 0 && limits.MaxSlippage < slippageBps {
+		slippageBps = limits.MaxSlippage
+	}
+
+	childSize := abs(delta)
+	if e.config.Engine.MaxChildOrderSize > 0 {
+		childSize = math.Min(childSize, e.config.Engine.MaxChildOrderSize/mid)
+	}
+
+	remaining := abs(delta)
+	var filledNotional float64
+
+	for remaining > 0 && outcome.Orders < maxChildOrders {
+		size := math.Min(childSize, remaining)
+		signed := size
+		if side == exchange.SideSell {
+			signed = -size
+		}
+
+		// A residual below the minimum order size is left for the next tick
+		if size*mid < e.config.Risk.MinOrderSize && outcome.Orders > 0 {
+			break
+		}
+
+		worstPrice := mid * (1 + slippageBps/10000)
+		if side == exchange.SideSell {
+			worstPrice = mid * (1 - slippageBps/10000)
+		}
+
+		resulting := position + signed
+		check := risk.OrderCheck{
+			StrategyID:      strategy.ID,
+			Symbol:          symbol,
+			Size:            size,
+			Price:           worstPrice,
+			SlippageBps:     slippageBps,
+			PositionSize:    position,
+			ResultingSize:   resulting,
+			AccountValue:    accountValue,
+			AccountNotional: *notional - abs(position)*mid + abs(resulting)*mid,
+			Limits:          limits,
+		}
+		if err := e.riskManager.CheckOrder(check); err != nil {
+			e.incrementMetric(func(m *Metrics) { m.RejectedOrders++ })
+			outcome.Err = err
+			break
+		}
+
+		result, err := e.exchangeAdapter.PlaceOrder(ctx, &exchange.OrderRequest{
+			Account:        strategy.Account(),
+			Symbol:         symbol,
+			Side:           side,
+			Type:           exchange.OrderTypeMarket,
+			Size:           size,
+			Price:          mid,
+			ReduceOnly:     reducesExposure(position, signed),
+			MaxSlippageBps: slippageBps,
+		})
+		outcome.Orders++
+		e.incrementMetric(func(m *Metrics) { m.OrdersPlaced++ })
+
+		if err != nil {
+			if errors.Is(err, exchange.ErrOrderTooSmall) && outcome.Filled != 0 {
+				// The residual rounds below the exchange minimum; leave it for the next tick
+				outcome.Partial = true
+				break
+			}
+			if errors.Is(err, exchange.ErrInvalidOrder) {
+				e.incrementMetric(func(m *Metrics) { m.RejectedOrders++ })
+			}
+			outcome.Err = err
+			break
+		}
+
+		filled := result.FilledSize
+		if filled <= 0 {
+			// Nothing could fill inside the slippage bound; don't chase the price
+			outcome.Partial = true
+			break
+		}
+
+		filledSigned := filled
+		if side == exchange.SideSell {
+			filledSigned = -filled
+		}
+
+		*notional += abs(position+filledSigned)*mid - abs(position)*mid
+		position += filledSigned
+		remaining -= filled
+		outcome.Filled += filledSigned
+		filledNotional += filled * result.AvgPrice
+
+		if result.Status == exchange.OrderStatusPartial {
+			// The book is thin inside our slippage bound; stop and retry next tick
+			outcome.Partial = true
+			e.incrementMetric(func(m *Metrics) { m.PartialFills++ })
+			break
+		}
+	}
+
+	if outcome.Filled != 0 {
+		outcome.AvgPrice = filledNotional / abs(outcome.Filled)
+	}
+	if remaining > 1e-12 && outcome.Err == nil && !outcome.Partial {
+		outcome.Partial = true
+	}
+
+	return outcome
+}
+
+// recordOutcome applies an executed delta to the strategy's tracked positions.
+// before is the exchange-reported quantity the delta was computed from.
+func (e *Engine) recordOutcome(strategy *Strategy, outcome *symbolOutcome, before, mid float64) {
+	switch {
+	case outcome.Err != nil:
+		log.Printf("Strategy %s %s: filled %.6f of %.6f in %d orders, error: %v",
+			strategy.ID, outcome.Symbol, outcome.Filled, outcome.Requested, outcome.Orders, outcome.Err)
+	case outcome.Partial:
+		log.Printf("Strategy %s %s: partially filled %.6f of %.6f in %d orders",
+			strategy.ID, outcome.Symbol, outcome.Filled, outcome.Requested, outcome.Orders)
+	}
+
+	if outcome.Filled == 0 {
+		return
+	}
+
+	strategy.mutex.Lock()
+	position, exists := strategy.Positions[outcome.Symbol]
+	if !exists {
+		position = &Position{Symbol: outcome.Symbol, EntryPrice: mid}
+		if strategy.Positions == nil {
+			strategy.Positions = make(map[string]*Position)
+		}
+		strategy.Positions[outcome.Symbol] = position
+	}
+
+	// The exchange is the source of truth for the quantity held before the fill
+	position.Quantity = before
+
+	var realizedPnL float64
+	if position.Quantity != 0 && (position.Quantity > 0) != (outcome.Filled > 0) {
+		closed := math.Min(abs(outcome.Filled), abs(position.Quantity))
+		if position.Quantity > 0 {
+			realizedPnL = closed * (outcome.AvgPrice - position.EntryPrice)
+		} else {
+			realizedPnL = closed * (position.EntryPrice - outcome.AvgPrice)
+		}
+
+		newQuantity := position.Quantity + outcome.Filled
+		if newQuantity != 0 && (newQuantity > 0) != (position.Quantity > 0) {
+			// Flipped through zero: the remainder opens at the fill price
+			position.EntryPrice = outcome.AvgPrice
+		}
+		position.Quantity = newQuantity
+	} else {
+		newQuantity := position.Quantity + outcome.Filled
+		position.EntryPrice = (position.EntryPrice*abs(position.Quantity) + outcome.AvgPrice*abs(outcome.Filled)) / abs(newQuantity)
+		position.Quantity = newQuantity
+	}
+
+	if abs(position.Quantity) < 1e-12 {
+		delete(strategy.Positions, outcome.Symbol)
+	} else {
+		position.CurrentPrice = mid
+		position.UnrealizedPnL = position.Quantity * (mid - position.EntryPrice)
+	}
+	strategy.mutex.Unlock()
+
+	if realizedPnL != 0 {
+		e.riskManager.RecordPnL(strategy.ID, realizedPnL)
+	}
+}
+
+func (e *Engine) incrementMetric(update func(m *Metrics)) {
+	e.metrics.mutex.Lock()
+	defer e.metrics.mutex.Unlock()
+	update(e.metrics)
+}
+
+// reducesExposure reports whether applying delta to position shrinks it without flipping sides
+func reducesExposure(position, delta float64) bool {
+	if position == 0 || delta == 0 {
+		return false
+	}
+	return (position > 0) != (delta > 0) && abs(delta) <= abs(position)
+}
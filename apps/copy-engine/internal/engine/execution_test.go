@@ -0,0 +1,149 @@
+package engine
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/hyperdash/copy-engine/internal/config"
+	"github.com/hyperdash/copy-engine/internal/exchange"
+	"github.com/hyperdash/copy-engine/internal/risk"
+)
+
+// fillingAdapter fills every order in full at the worst price it accepts
+type fillingAdapter struct {
+	exchange.Adapter
+
+	mu     sync.Mutex
+	orders []exchange.OrderRequest
+}
+
+func (a *fillingAdapter) PlaceOrder(ctx context.Context, req *exchange.OrderRequest) (*exchange.OrderResult, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.orders = append(a.orders, *req)
+
+	price := req.Price * (1 + req.MaxSlippageBps/10000)
+	if req.Side == exchange.SideSell {
+		price = req.Price * (1 - req.MaxSlippageBps/10000)
+	}
+	return &exchange.OrderResult{
+		OrderID:    int64(len(a.orders)),
+		Status:     exchange.OrderStatusFilled,
+		FilledSize: req.Size,
+		AvgPrice:   price,
+	}, nil
+}
+
+func testConfig() *config.Config {
+	return &config.Config{
+		Engine: config.EngineConfig{ExecutionInterval: 1},
+		Risk: config.RiskConfig{
+			MaxLeverage:     5,
+			MaxPositionSize: 100000,
+			MaxSlippage:     10,
+			MinOrderSize:    10,
+			MaxDailyLoss:    1000,
+		},
+	}
+}
+
+func newTestEngine(cfg *config.Config, adapter exchange.Adapter, store Store) *Engine {
+	return NewEngine(cfg, adapter, risk.NewManager(cfg.Risk), store)
+}
+
+func TestExecuteSymbolDeltaAcceptsSlippageAtTheLimit(t *testing.T) {
+	mids := []float64{0.1234, 1.7, 27.33, 142.1, 3150, 3200.25, 64000.5, 98765.4}
+	limits := risk.Limits{MaxLeverage: 5, MaxSlippage: 10, MinOrderSize: 10}
+
+	for _, mid := range mids {
+		for _, side := range []exchange.Side{exchange.SideBuy, exchange.SideSell} {
+			adapter := &fillingAdapter{}
+			e := newTestEngine(testConfig(), adapter, nil)
+			strategy := &Strategy{ID: "strategy-1", UserID: "user-1"}
+
+			// Orders worth $1000 against $100k of equity, well inside every other limit
+			delta := 1000 / mid
+			if side == exchange.SideSell {
+				delta = -delta
+			}
+			notional := 0.0
+			outcome := e.executeSymbolDelta(context.Background(), strategy, limits, "ETH", 0, delta, mid, 100000, &notional)
+
+			if outcome.Err != nil {
+				t.Errorf("%s at mid %g: %v", side, mid, outcome.Err)
+				continue
+			}
+			if math.Abs(outcome.Filled-delta) > 1e-9 {
+				t.Errorf("%s at mid %g filled %g of %g", side, mid, outcome.Filled, delta)
+			}
+			if len(adapter.orders) != 1 || adapter.orders[0].MaxSlippageBps != 10 {
+				t.Errorf("%s at mid %g placed %+v, want one order bounded by 10 bps", side, mid, adapter.orders)
+			}
+		}
+	}
+}
+
+func TestCalculatePositionDeltas(t *testing.T) {
+	prices := map[string]float64{"BTC": 60000, "ETH": 3000, "SOL": 150}
+
+	tests := []struct {
+		name    string
+		current map[string]float64
+		target  map[string]float64
+		want    map[string]float64
+	}{
+		{
+			name:   "opens targets",
+			target: map[string]float64{"ETH": 1, "BTC": -0.01},
+			want:   map[string]float64{"ETH": 1, "BTC": -0.01},
+		},
+		{
+			name:    "adjusts towards targets",
+			current: map[string]float64{"ETH": 1.5, "SOL": -10},
+			target:  map[string]float64{"ETH": 1, "SOL": -4},
+			want:    map[string]float64{"ETH": -0.5, "SOL": 6},
+		},
+		{
+			name:    "flips through zero",
+			current: map[string]float64{"ETH": 1},
+			target:  map[string]float64{"ETH": -1},
+			want:    map[string]float64{"ETH": -2},
+		},
+		{
+			name:    "skips deltas below the minimum order size",
+			current: map[string]float64{"ETH": 1, "SOL": 2},
+			target:  map[string]float64{"ETH": 1.003, "SOL": 2.05, "BTC": 0.0001},
+			want:    map[string]float64{},
+		},
+		{
+			name:    "keeps deltas at the minimum order size",
+			current: map[string]float64{"SOL": 2},
+			target:  map[string]float64{"SOL": 2 + 10.0/150},
+			want:    map[string]float64{"SOL": 10.0 / 150},
+		},
+		{
+			name:    "closes untargeted positions of any size",
+			current: map[string]float64{"ETH": 1, "SOL": 0.01, "BTC": 0},
+			target:  map[string]float64{"ETH": 1},
+			want:    map[string]float64{"SOL": -0.01},
+		},
+	}
+
+	e := newTestEngine(testConfig(), nil, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deltas := e.calculatePositionDeltas(tt.current, tt.target, prices)
+
+			if len(deltas) != len(tt.want) {
+				t.Fatalf("deltas %v, want %v", deltas, tt.want)
+			}
+			for symbol, want := range tt.want {
+				if got, ok := deltas[symbol]; !ok || math.Abs(got-want) > 1e-9 {
+					t.Errorf("%s delta %v, want %v", symbol, got, want)
+				}
+			}
+		})
+	}
+}
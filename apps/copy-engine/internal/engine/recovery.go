@@ -0,0 +1,90 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// storeTimeout bounds a single persistence call made outside of a request context
+const storeTimeout = 5 * time.Second
+
+// recoverStrategies reloads persisted strategies, rebuilds their positions from the
+// exchange and resumes the ones that were active when the engine last stopped.
+// A strategy whose positions cannot be reconciled is put into the error state
+// instead of trading on a stale view.
+func (e *Engine) recoverStrategies(ctx context.Context) error {
+	strategies, err := e.store.LoadStrategies(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load strategies: %w", err)
+	}
+
+	var resume []string
+	for _, strategy := range strategies {
+		wasActive := strategy.Status == StatusActive
+
+		if err := e.reconcilePositions(ctx, strategy); err != nil {
+			log.Printf("Failed to reconcile positions for strategy %s: %v", strategy.ID, err)
+			strategy.Status = StatusError
+			e.persistStatus(strategy.ID, StatusError)
+		} else if wasActive {
+			strategy.Status = StatusPaused
+			resume = append(resume, strategy.ID)
+		}
+
+		e.strategiesMutex.Lock()
+		e.strategies[strategy.ID] = strategy
+		e.activeStrategies[strategy.ID] = false
+		e.strategiesMutex.Unlock()
+	}
+
+	for _, strategyID := range resume {
+		if err := e.ResumeStrategy(strategyID); err != nil {
+			log.Printf("Failed to resume strategy %s: %v", strategyID, err)
+		}
+	}
+
+	log.Printf("Recovered %d strategies, resumed %d", len(strategies), len(resume))
+	return nil
+}
+
+// reconcilePositions replaces a strategy's tracked positions with the exchange's
+func (e *Engine) reconcilePositions(ctx context.Context, strategy *Strategy) error {
+	positions, err := e.exchangeAdapter.GetPositions(ctx, strategy.Account())
+	if err != nil {
+		return fmt.Errorf("failed to get positions: %w", err)
+	}
+
+	reconciled := make(map[string]*Position, len(positions))
+	for _, position := range positions {
+		if position.Size == 0 {
+			continue
+		}
+
+		reconciled[position.Symbol] = &Position{
+			Symbol:        position.Symbol,
+			Quantity:      position.Size,
+			EntryPrice:    position.EntryPrice,
+			CurrentPrice:  position.PositionValue / abs(position.Size),
+			UnrealizedPnL: position.UnrealizedPnL,
+			Leverage:      position.Leverage,
+		}
+	}
+
+	strategy.mutex.Lock()
+	strategy.Positions = reconciled
+	strategy.mutex.Unlock()
+
+	return nil
+}
+
+// persistStatus records a status change, logging rather than failing the caller
+func (e *Engine) persistStatus(strategyID string, status StrategyStatus) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	if err := e.store.UpdateStrategyStatus(ctx, strategyID, status); err != nil {
+		log.Printf("Failed to persist status %s for strategy %s: %v", status, strategyID, err)
+	}
+}
@@ -0,0 +1,236 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Store persists strategy configuration and status so the engine can recover
+// running strategies after a restart. Positions are not stored; they are
+// reconciled from the exchange on recovery.
+type Store interface {
+	SaveStrategy(ctx context.Context, strategy *Strategy) error
+	UpdateStrategyStatus(ctx context.Context, strategyID string, status StrategyStatus) error
+	LoadStrategies(ctx context.Context) ([]*Strategy, error)
+	// GetTraderAddresses maps trader IDs to the on-chain addresses they trade from.
+	// Unknown traders are missing from the result.
+	GetTraderAddresses(ctx context.Context, traderIDs []string) (map[string]string, error)
+}
+
+type postgresStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresStore creates a Store backed by the copy_strategies and copy_allocations tables
+func NewPostgresStore(pool *pgxpool.Pool) Store {
+	return &postgresStore{pool: pool}
+}
+
+// SaveStrategy upserts a strategy and replaces its set of allocations
+func (s *postgresStore) SaveStrategy(ctx context.Context, strategy *Strategy) error {
+	strategy.mutex.RLock()
+	name := strategy.Name
+	status := strategy.Status
+	riskParams := strategy.RiskParams
+	alignmentRate := strategy.AlignmentRate
+	traders := append([]TraderAllocation(nil), strategy.Traders...)
+	strategy.mutex.RUnlock()
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO copy_strategies (id, user_id, name, status, max_leverage, slippage_bps,
+		                             min_order_usd, follow_new_entries_only, alignment_rate,
+		                             created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, now(), now())
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name,
+			status = EXCLUDED.status,
+			max_leverage = EXCLUDED.max_leverage,
+			slippage_bps = EXCLUDED.slippage_bps,
+			min_order_usd = EXCLUDED.min_order_usd,
+			follow_new_entries_only = EXCLUDED.follow_new_entries_only,
+			alignment_rate = EXCLUDED.alignment_rate,
+			updated_at = now()
+	`,
+		strategy.ID,
+		strategy.UserID,
+		name,
+		status,
+		riskParams.MaxLeverage,
+		int(math.Round(riskParams.MaxSlippage)),
+		riskParams.MinOrderSize,
+		riskParams.FollowNewEntries,
+		alignmentRate,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save strategy %s: %w", strategy.ID, err)
+	}
+
+	traderIDs := make([]string, 0, len(traders))
+	for _, trader := range traders {
+		traderIDs = append(traderIDs, trader.TraderID)
+
+		tag, err := tx.Exec(ctx, `
+			UPDATE copy_allocations
+			SET weight = $3, status = 'active', updated_at = now()
+			WHERE strategy_id = $1 AND trader_id = $2
+		`, strategy.ID, trader.TraderID, trader.Weight)
+		if err != nil {
+			return fmt.Errorf("failed to update allocation for trader %s: %w", trader.TraderID, err)
+		}
+		if tag.RowsAffected() > 0 {
+			continue
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO copy_allocations (strategy_id, trader_id, weight, status, created_at, updated_at)
+			VALUES ($1, $2, $3, 'active', now(), now())
+		`, strategy.ID, trader.TraderID, trader.Weight)
+		if err != nil {
+			return fmt.Errorf("failed to create allocation for trader %s: %w", trader.TraderID, err)
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM copy_allocations
+		WHERE strategy_id = $1 AND NOT (trader_id::text = ANY($2::text[]))
+	`, strategy.ID, traderIDs)
+	if err != nil {
+		return fmt.Errorf("failed to remove stale allocations: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit strategy %s: %w", strategy.ID, err)
+	}
+
+	return nil
+}
+
+func (s *postgresStore) UpdateStrategyStatus(ctx context.Context, strategyID string, status StrategyStatus) error {
+	tag, err := s.pool.Exec(ctx, `
+		UPDATE copy_strategies SET status = $2, updated_at = now() WHERE id = $1
+	`, strategyID, status)
+	if err != nil {
+		return fmt.Errorf("failed to update status of strategy %s: %w", strategyID, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: %s", ErrStrategyNotFound, strategyID)
+	}
+
+	return nil
+}
+
+// LoadStrategies returns every engine strategy that has not been terminated.
+// Rows that configure sizing for a copy relationship are not engine strategies.
+func (s *postgresStore) LoadStrategies(ctx context.Context) ([]*Strategy, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id::text, user_id::text, name, status,
+		       COALESCE(max_leverage, 0)::float8, COALESCE(slippage_bps, 0),
+		       COALESCE(min_order_usd, 0)::float8, COALESCE(follow_new_entries_only, false),
+		       COALESCE(alignment_rate, 100)::float8
+		FROM copy_strategies
+		WHERE relationship_id IS NULL AND status <> $1
+	`, StatusTerminated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query strategies: %w", err)
+	}
+	defer rows.Close()
+
+	var strategies []*Strategy
+	byID := make(map[string]*Strategy)
+	for rows.Next() {
+		strategy := &Strategy{Positions: make(map[string]*Position)}
+		var slippageBps int
+		err := rows.Scan(
+			&strategy.ID,
+			&strategy.UserID,
+			&strategy.Name,
+			&strategy.Status,
+			&strategy.RiskParams.MaxLeverage,
+			&slippageBps,
+			&strategy.RiskParams.MinOrderSize,
+			&strategy.RiskParams.FollowNewEntries,
+			&strategy.AlignmentRate,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan strategy: %w", err)
+		}
+		strategy.RiskParams.MaxSlippage = float64(slippageBps)
+
+		strategies = append(strategies, strategy)
+		byID[strategy.ID] = strategy
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating strategies: %w", err)
+	}
+
+	if len(strategies) == 0 {
+		return strategies, nil
+	}
+
+	ids := make([]string, 0, len(byID))
+	for id := range byID {
+		ids = append(ids, id)
+	}
+
+	allocationRows, err := s.pool.Query(ctx, `
+		SELECT strategy_id::text, trader_id::text, weight::float8
+		FROM copy_allocations
+		WHERE strategy_id::text = ANY($1::text[]) AND status = 'active'
+		ORDER BY created_at
+	`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query allocations: %w", err)
+	}
+	defer allocationRows.Close()
+
+	for allocationRows.Next() {
+		var strategyID string
+		var allocation TraderAllocation
+		if err := allocationRows.Scan(&strategyID, &allocation.TraderID, &allocation.Weight); err != nil {
+			return nil, fmt.Errorf("failed to scan allocation: %w", err)
+		}
+		if strategy, ok := byID[strategyID]; ok {
+			strategy.Traders = append(strategy.Traders, allocation)
+		}
+	}
+	if err := allocationRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating allocations: %w", err)
+	}
+
+	return strategies, nil
+}
+
+func (s *postgresStore) GetTraderAddresses(ctx context.Context, traderIDs []string) (map[string]string, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT trader_id::text, address
+		FROM trader_stats
+		WHERE trader_id::text = ANY($1::text[])
+	`, traderIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trader addresses: %w", err)
+	}
+	defer rows.Close()
+
+	addresses := make(map[string]string, len(traderIDs))
+	for rows.Next() {
+		var traderID, address string
+		if err := rows.Scan(&traderID, &address); err != nil {
+			return nil, fmt.Errorf("failed to scan trader address: %w", err)
+		}
+		addresses[traderID] = address
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating trader addresses: %w", err)
+	}
+
+	return addresses, nil
+}
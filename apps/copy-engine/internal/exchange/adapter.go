@@ -0,0 +1,187 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Adapter is the exchange abstraction used by the copy engine.
+//
+// Account arguments accept either an account ID bound to an on-chain address
+// (see BindAccount on the concrete adapters) or a raw 0x address. The Hyperliquid
+// adapter rejects account IDs that are not bound with ErrAccountNotBound.
+type Adapter interface {
+	// GetCurrentPositions returns the signed position size per symbol
+	// (positive for long, negative for short).
+	GetCurrentPositions(account string) (map[string]float64, error)
+	GetPositions(ctx context.Context, account string) ([]*Position, error)
+	GetOpenOrders(ctx context.Context, account string) ([]*Order, error)
+	GetBalances(ctx context.Context, account string) (*Balance, error)
+	GetMidPrices(ctx context.Context) (map[string]float64, error)
+	GetFills(ctx context.Context, account string, since time.Time) ([]*Fill, error)
+	PlaceOrder(ctx context.Context, req *OrderRequest) (*OrderResult, error)
+	// GetOrderStatus looks up an order by the client order ID it was placed with and
+	// returns ErrOrderNotFound if the exchange never received it.
+	GetOrderStatus(ctx context.Context, account string, clientOrderID string) (*OrderResult, error)
+	CancelOrder(ctx context.Context, account string, symbol string, orderID int64) error
+	ModifyOrder(ctx context.Context, orderID int64, req *OrderRequest) (*OrderResult, error)
+}
+
+// Side represents the side of an order or fill
+type Side string
+
+const (
+	SideBuy  Side = "buy"
+	SideSell Side = "sell"
+)
+
+// OrderType represents the type of an order
+type OrderType string
+
+const (
+	OrderTypeMarket OrderType = "market"
+	OrderTypeLimit  OrderType = "limit"
+)
+
+// TimeInForce represents how long a limit order rests on the book
+type TimeInForce string
+
+const (
+	TimeInForceGTC TimeInForce = "Gtc"
+	TimeInForceIOC TimeInForce = "Ioc"
+	TimeInForceALO TimeInForce = "Alo"
+)
+
+// OrderStatus represents the state of an order after submission
+type OrderStatus string
+
+const (
+	OrderStatusResting  OrderStatus = "resting"
+	OrderStatusFilled   OrderStatus = "filled"
+	OrderStatusPartial  OrderStatus = "partial"
+	OrderStatusRejected OrderStatus = "rejected"
+)
+
+// Position represents an open perpetual position
+type Position struct {
+	Symbol           string   `json:"symbol"`
+	Size             float64  `json:"size"` // signed, negative for short
+	EntryPrice       float64  `json:"entry_price"`
+	PositionValue    float64  `json:"position_value"`
+	UnrealizedPnL    float64  `json:"unrealized_pnl"`
+	Leverage         float64  `json:"leverage"`
+	MarginUsed       float64  `json:"margin_used"`
+	LiquidationPrice *float64 `json:"liquidation_price"`
+}
+
+// Order represents a resting order
+type Order struct {
+	OrderID   int64     `json:"order_id"`
+	Symbol    string    `json:"symbol"`
+	Side      Side      `json:"side"`
+	Price     float64   `json:"price"`
+	Size      float64   `json:"size"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Balance represents the margin summary of an account
+type Balance struct {
+	AccountValue    float64 `json:"account_value"`
+	TotalNotional   float64 `json:"total_notional"`
+	TotalMarginUsed float64 `json:"total_margin_used"`
+	Withdrawable    float64 `json:"withdrawable"`
+}
+
+// Leverage returns the account's notional exposure relative to its equity
+func (b *Balance) Leverage() float64 {
+	if b.AccountValue <= 0 {
+		return 0
+	}
+	return b.TotalNotional / b.AccountValue
+}
+
+// Fill represents an executed trade on the account
+type Fill struct {
+	Symbol        string    `json:"symbol"`
+	Side          Side      `json:"side"`
+	Price         float64   `json:"price"`
+	Size          float64   `json:"size"`
+	Fee           float64   `json:"fee"`
+	ClosedPnL     float64   `json:"closed_pnl"`
+	StartPosition float64   `json:"start_position"`
+	Direction     string    `json:"direction"`
+	OrderID       int64     `json:"order_id"`
+	TradeID       int64     `json:"trade_id"`
+	Hash          string    `json:"hash"`
+	Time          time.Time `json:"time"`
+}
+
+// OrderRequest describes an order to place or modify
+type OrderRequest struct {
+	Account     string      `json:"account"`
+	Symbol      string      `json:"symbol"`
+	Side        Side        `json:"side"`
+	Type        OrderType   `json:"type"`
+	Size        float64     `json:"size"`
+	Price       float64     `json:"price"` // limit price, or reference price for market orders
+	TimeInForce TimeInForce `json:"time_in_force"`
+	ReduceOnly  bool        `json:"reduce_only"`
+	// MaxSlippageBps bounds the worst acceptable price of a market order
+	MaxSlippageBps float64 `json:"max_slippage_bps"`
+	ClientOrderID  string  `json:"client_order_id"`
+}
+
+// OrderResult describes the outcome of an order submission
+type OrderResult struct {
+	OrderID    int64       `json:"order_id"`
+	Status     OrderStatus `json:"status"`
+	FilledSize float64     `json:"filled_size"`
+	AvgPrice   float64     `json:"avg_price"`
+	Error      string      `json:"error,omitempty"`
+}
+
+var (
+	ErrUnknownSymbol       = errors.New("unknown symbol")
+	ErrOrderNotFound       = errors.New("order not found")
+	ErrInvalidOrder        = errors.New("invalid order")
+	ErrSignerNotConfigured = errors.New("exchange signer not configured")
+	ErrInsufficientMargin  = errors.New("insufficient margin")
+	ErrAccountNotBound     = errors.New("account not bound to an address")
+)
+
+// ErrOrderTooSmall is returned (wrapped) when an order's size or value falls below
+// the exchange's minimum once rounded to its lot size. It wraps ErrInvalidOrder.
+var ErrOrderTooSmall = fmt.Errorf("%w: below the exchange minimum", ErrInvalidOrder)
+
+// APIError is returned when the exchange responds with a non-success status
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("exchange API error (status %d): %s", e.StatusCode, e.Message)
+}
+
+// Temporary reports whether the request may succeed if retried
+func (e *APIError) Temporary() bool {
+	return e.StatusCode == 429 || e.StatusCode >= 500
+}
+
+func (r *OrderRequest) validate() error {
+	if r.Symbol == "" {
+		return fmt.Errorf("%w: symbol is required", ErrInvalidOrder)
+	}
+	if r.Side != SideBuy && r.Side != SideSell {
+		return fmt.Errorf("%w: side must be buy or sell", ErrInvalidOrder)
+	}
+	if r.Size <= 0 {
+		return fmt.Errorf("%w: size must be positive", ErrInvalidOrder)
+	}
+	if r.Type == OrderTypeLimit && r.Price <= 0 {
+		return fmt.Errorf("%w: limit orders require a price", ErrInvalidOrder)
+	}
+	return nil
+}
@@ -0,0 +1,899 @@
+package exchange
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperdash/copy-engine/internal/config"
+)
+
+const (
+	hyperliquidMainnetURL = "https://api.hyperliquid.xyz"
+	hyperliquidTestnetURL = "https://api.hyperliquid-testnet.xyz"
+
+	// Hyperliquid has no native market orders; they are sent as aggressive
+	// IOC limit orders bounded by this slippage when the request sets none.
+	defaultMarketSlippageBps = 50.0
+)
+
+// Signer signs exchange actions on behalf of an account. Actions are passed in
+// their wire form and vaultAddress is set when trading for a vault or
+// sub-account; see PrivateKeySigner.
+type Signer interface {
+	SignAction(action interface{}, nonce int64, vaultAddress *string) (*Signature, error)
+}
+
+// SignerProvider chooses the signer for an account's actions. A nil signer without
+// an error leaves the account to the adapter's own signer.
+type SignerProvider interface {
+	SignerFor(ctx context.Context, account string) (Signer, error)
+}
+
+// Signature is the ECDSA signature attached to /exchange requests
+type Signature struct {
+	R string `json:"r"`
+	S string `json:"s"`
+	V int    `json:"v"`
+}
+
+// HyperliquidAdapter implements Adapter against the Hyperliquid HTTP API
+type HyperliquidAdapter struct {
+	baseURL    string
+	account    string
+	httpClient *http.Client
+	signer     Signer
+	signers    SignerProvider
+	nonces     NonceManager
+
+	accounts   map[string]string
+	vaults     map[string]string
+	vault      string // vault or sub-account traded by accounts without their own
+	accountsMu sync.RWMutex
+
+	assets         map[string]*AssetInfo
+	assetsLoadedAt time.Time
+	metaRefresh    time.Duration
+	assetsMu       sync.RWMutex
+
+	scheduler *weightScheduler
+	queries   *queryCoalescer
+}
+
+// NewHyperliquidAdapter creates a Hyperliquid adapter from configuration.
+// APIKey is the address of the trading account.
+func NewHyperliquidAdapter(cfg config.HyperliquidConfig) *HyperliquidAdapter {
+	return &HyperliquidAdapter{
+		baseURL:     resolveBaseURL(cfg),
+		account:     cfg.APIKey,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		accounts:    make(map[string]string),
+		vaults:      make(map[string]string),
+		vault:       cfg.VaultAddress,
+		metaRefresh: time.Duration(cfg.MetaRefreshInterval) * time.Second,
+		scheduler:   newWeightScheduler(cfg.RateLimitWeight),
+		queries:     newQueryCoalescer(time.Duration(cfg.CoalesceWindow) * time.Millisecond),
+	}
+}
+
+// RateLimitMetrics reports how the adapter spends the exchange's request weight
+func (h *HyperliquidAdapter) RateLimitMetrics() RateLimitMetrics {
+	return h.scheduler.Metrics()
+}
+
+// resolveBaseURL strips any endpoint suffix from the configured URL so that
+// both /info and /exchange can be addressed, and swaps in the testnet host
+// when TestNet is set and no custom host was configured.
+func resolveBaseURL(cfg config.HyperliquidConfig) string {
+	baseURL := strings.TrimRight(cfg.BaseURL, "/")
+	baseURL = strings.TrimSuffix(baseURL, "/info")
+	baseURL = strings.TrimSuffix(baseURL, "/exchange")
+
+	if baseURL == "" {
+		baseURL = hyperliquidMainnetURL
+	}
+	if cfg.TestNet && baseURL == hyperliquidMainnetURL {
+		baseURL = hyperliquidTestnetURL
+	}
+
+	return baseURL
+}
+
+// SetSigner configures the signer used for /exchange actions
+func (h *HyperliquidAdapter) SetSigner(signer Signer) {
+	h.signer = signer
+}
+
+// SetSignerProvider routes each account's actions through the signer the provider
+// returns for it, e.g. the account owner's agent wallet. It may be called while
+// the adapter is in use.
+func (h *HyperliquidAdapter) SetSignerProvider(signers SignerProvider) {
+	h.accountsMu.Lock()
+	defer h.accountsMu.Unlock()
+	h.signers = signers
+}
+
+// BindAccount maps an account ID (e.g. a strategy ID) to an on-chain address
+func (h *HyperliquidAdapter) BindAccount(accountID, address string) {
+	h.accountsMu.Lock()
+	defer h.accountsMu.Unlock()
+	h.accounts[accountID] = address
+}
+
+// BindVault makes /exchange actions for an account trade the given vault or
+// sub-account instead of the signer's own account
+func (h *HyperliquidAdapter) BindVault(accountID, vaultAddress string) {
+	h.accountsMu.Lock()
+	defer h.accountsMu.Unlock()
+	h.vaults[accountID] = vaultAddress
+}
+
+func (h *HyperliquidAdapter) resolveVault(account string) *string {
+	h.accountsMu.RLock()
+	defer h.accountsMu.RUnlock()
+
+	vault, ok := h.vaults[account]
+	if !ok {
+		vault = h.vault
+	}
+	if vault == "" {
+		return nil
+	}
+	return &vault
+}
+
+// resolveAddress maps an account to the address it trades. An empty account is the
+// adapter's own; any other account must be an address or bound to one, so a typo or
+// a missing binding never reads or trades the operator's account.
+func (h *HyperliquidAdapter) resolveAddress(account string) (string, error) {
+	if strings.HasPrefix(account, "0x") {
+		return account, nil
+	}
+	if account == "" {
+		if h.account == "" {
+			return "", fmt.Errorf("%w: no trading account configured", ErrAccountNotBound)
+		}
+		return h.account, nil
+	}
+
+	h.accountsMu.RLock()
+	defer h.accountsMu.RUnlock()
+	if address, ok := h.accounts[account]; ok {
+		return address, nil
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrAccountNotBound, account)
+}
+
+func (h *HyperliquidAdapter) GetCurrentPositions(account string) (map[string]float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	positions, err := h.GetPositions(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]float64, len(positions))
+	for _, position := range positions {
+		result[position.Symbol] = position.Size
+	}
+
+	return result, nil
+}
+
+type hlClearinghouseState struct {
+	AssetPositions []struct {
+		Position struct {
+			Coin          string  `json:"coin"`
+			Szi           string  `json:"szi"`
+			EntryPx       *string `json:"entryPx"`
+			PositionValue string  `json:"positionValue"`
+			UnrealizedPnl string  `json:"unrealizedPnl"`
+			MarginUsed    string  `json:"marginUsed"`
+			LiquidationPx *string `json:"liquidationPx"`
+			Leverage      struct {
+				Type  string  `json:"type"`
+				Value float64 `json:"value"`
+			} `json:"leverage"`
+		} `json:"position"`
+	} `json:"assetPositions"`
+	MarginSummary struct {
+		AccountValue    string `json:"accountValue"`
+		TotalNtlPos     string `json:"totalNtlPos"`
+		TotalMarginUsed string `json:"totalMarginUsed"`
+	} `json:"marginSummary"`
+	Withdrawable string `json:"withdrawable"`
+}
+
+// clearinghouseState backs both position and balance queries. Identical queries
+// within the coalescing window share one request; callers must not modify the
+// returned state.
+func (h *HyperliquidAdapter) clearinghouseState(ctx context.Context, account string) (*hlClearinghouseState, error) {
+	address, err := h.resolveAddress(account)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := h.coalesce(ctx, clearinghouseKey(address), func() (interface{}, error) {
+		var state hlClearinghouseState
+		err := h.info(ctx, map[string]interface{}{
+			"type": "clearinghouseState",
+			"user": address,
+		}, &state)
+		return &state, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get clearinghouse state: %w", err)
+	}
+	return result.(*hlClearinghouseState), nil
+}
+
+func clearinghouseKey(address string) string {
+	return "clearinghouseState:" + strings.ToLower(address)
+}
+
+func (h *HyperliquidAdapter) GetPositions(ctx context.Context, account string) ([]*Position, error) {
+	state, err := h.clearinghouseState(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make([]*Position, 0, len(state.AssetPositions))
+	for _, ap := range state.AssetPositions {
+		p := ap.Position
+		size := parseFloat(p.Szi)
+		if size == 0 {
+			continue
+		}
+
+		position := &Position{
+			Symbol:        p.Coin,
+			Size:          size,
+			PositionValue: parseFloat(p.PositionValue),
+			UnrealizedPnL: parseFloat(p.UnrealizedPnl),
+			MarginUsed:    parseFloat(p.MarginUsed),
+			Leverage:      p.Leverage.Value,
+		}
+		if p.EntryPx != nil {
+			position.EntryPrice = parseFloat(*p.EntryPx)
+		}
+		if p.LiquidationPx != nil {
+			liquidationPrice := parseFloat(*p.LiquidationPx)
+			position.LiquidationPrice = &liquidationPrice
+		}
+
+		positions = append(positions, position)
+	}
+
+	return positions, nil
+}
+
+func (h *HyperliquidAdapter) GetOpenOrders(ctx context.Context, account string) ([]*Order, error) {
+	var raw []struct {
+		Coin      string `json:"coin"`
+		LimitPx   string `json:"limitPx"`
+		Oid       int64  `json:"oid"`
+		Side      string `json:"side"`
+		Sz        string `json:"sz"`
+		Timestamp int64  `json:"timestamp"`
+	}
+
+	address, err := h.resolveAddress(account)
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.info(ctx, map[string]interface{}{
+		"type": "openOrders",
+		"user": address,
+	}, &raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open orders: %w", err)
+	}
+
+	orders := make([]*Order, 0, len(raw))
+	for _, o := range raw {
+		orders = append(orders, &Order{
+			OrderID:   o.Oid,
+			Symbol:    o.Coin,
+			Side:      parseSide(o.Side),
+			Price:     parseFloat(o.LimitPx),
+			Size:      parseFloat(o.Sz),
+			Timestamp: time.UnixMilli(o.Timestamp),
+		})
+	}
+
+	return orders, nil
+}
+
+func (h *HyperliquidAdapter) GetBalances(ctx context.Context, account string) (*Balance, error) {
+	state, err := h.clearinghouseState(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Balance{
+		AccountValue:    parseFloat(state.MarginSummary.AccountValue),
+		TotalNotional:   parseFloat(state.MarginSummary.TotalNtlPos),
+		TotalMarginUsed: parseFloat(state.MarginSummary.TotalMarginUsed),
+		Withdrawable:    parseFloat(state.Withdrawable),
+	}, nil
+}
+
+func (h *HyperliquidAdapter) GetMidPrices(ctx context.Context) (map[string]float64, error) {
+	return h.midPrices(ctx, priorityInfo)
+}
+
+// midPrices reads the mids at the given priority, so that pricing an order does not
+// wait behind info queries
+func (h *HyperliquidAdapter) midPrices(ctx context.Context, priority requestPriority) (map[string]float64, error) {
+	result, err := h.coalesce(ctx, "allMids", func() (interface{}, error) {
+		var raw map[string]string
+		err := h.infoAt(ctx, priority, map[string]interface{}{"type": "allMids"}, &raw)
+		return raw, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mid prices: %w", err)
+	}
+	raw := result.(map[string]string)
+
+	mids := make(map[string]float64, len(raw))
+	for symbol, px := range raw {
+		mids[symbol] = parseFloat(px)
+	}
+
+	return mids, nil
+}
+
+// hlFill is a fill as reported by userFillsByTime and the userFills subscription
+type hlFill struct {
+	Coin          string `json:"coin"`
+	Px            string `json:"px"`
+	Sz            string `json:"sz"`
+	Side          string `json:"side"`
+	Time          int64  `json:"time"`
+	StartPosition string `json:"startPosition"`
+	Dir           string `json:"dir"`
+	ClosedPnl     string `json:"closedPnl"`
+	Hash          string `json:"hash"`
+	Oid           int64  `json:"oid"`
+	Fee           string `json:"fee"`
+	Tid           int64  `json:"tid"`
+}
+
+func (f *hlFill) fill() *Fill {
+	return &Fill{
+		Symbol:        f.Coin,
+		Side:          parseSide(f.Side),
+		Price:         parseFloat(f.Px),
+		Size:          parseFloat(f.Sz),
+		Fee:           parseFloat(f.Fee),
+		ClosedPnL:     parseFloat(f.ClosedPnl),
+		StartPosition: parseFloat(f.StartPosition),
+		Direction:     f.Dir,
+		OrderID:       f.Oid,
+		TradeID:       f.Tid,
+		Hash:          f.Hash,
+		Time:          time.UnixMilli(f.Time),
+	}
+}
+
+func (h *HyperliquidAdapter) GetFills(ctx context.Context, account string, since time.Time) ([]*Fill, error) {
+	address, err := h.resolveAddress(account)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []hlFill
+	err = h.info(ctx, map[string]interface{}{
+		"type":      "userFillsByTime",
+		"user":      address,
+		"startTime": since.UnixMilli(),
+	}, &raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fills: %w", err)
+	}
+
+	fills := make([]*Fill, 0, len(raw))
+	for i := range raw {
+		fills = append(fills, raw[i].fill())
+	}
+
+	return fills, nil
+}
+
+type hlOrderWire struct {
+	Asset       int
+	IsBuy       bool
+	Price       string
+	Size        string
+	ReduceOnly  bool
+	TimeInForce TimeInForce
+	Cloid       *string
+
+	size float64 // Size as a number, to tell partial fills apart
+}
+
+// wire renders the order with the keys in the order the exchange hashes them
+func (o *hlOrderWire) wire() wireMap {
+	order := wireMap{
+		{"a", o.Asset},
+		{"b", o.IsBuy},
+		{"p", o.Price},
+		{"s", o.Size},
+		{"r", o.ReduceOnly},
+		{"t", wireMap{{"limit", wireMap{{"tif", string(o.TimeInForce)}}}}},
+	}
+	if o.Cloid != nil {
+		order = append(order, wireField{"c", *o.Cloid})
+	}
+	return order
+}
+
+type hlExchangeResponse struct {
+	Status   string          `json:"status"`
+	Response json.RawMessage `json:"response"`
+}
+
+type hlOrderStatuses struct {
+	Type string `json:"type"`
+	Data struct {
+		Statuses []struct {
+			Resting *struct {
+				Oid int64 `json:"oid"`
+			} `json:"resting"`
+			Filled *struct {
+				TotalSz string `json:"totalSz"`
+				AvgPx   string `json:"avgPx"`
+				Oid     int64  `json:"oid"`
+			} `json:"filled"`
+			Error *string `json:"error"`
+		} `json:"statuses"`
+	} `json:"data"`
+}
+
+func (h *HyperliquidAdapter) PlaceOrder(ctx context.Context, req *OrderRequest) (*OrderResult, error) {
+	order, err := h.buildOrderWire(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	action := wireMap{
+		{"type", "order"},
+		{"orders", []wireMap{order.wire()}},
+		{"grouping", "na"},
+	}
+
+	resp, err := h.exchange(ctx, req.Account, action)
+	if err != nil {
+		return nil, fmt.Errorf("failed to place order: %w", err)
+	}
+
+	result, err := parseOrderStatus(resp, order.size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to place order: %w", err)
+	}
+
+	return result, nil
+}
+
+type hlOrderStatusInfo struct {
+	Status string `json:"status"` // "order", or "unknownOid" for an order the exchange never received
+	Order  *struct {
+		Order struct {
+			Oid       int64  `json:"oid"`
+			Sz        string `json:"sz"` // unfilled remainder
+			OrigSz    string `json:"origSz"`
+			Timestamp int64  `json:"timestamp"`
+		} `json:"order"`
+		Status string `json:"status"`
+	} `json:"order"`
+}
+
+func (h *HyperliquidAdapter) GetOrderStatus(ctx context.Context, account string, clientOrderID string) (*OrderResult, error) {
+	address, err := h.resolveAddress(account)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw hlOrderStatusInfo
+	err = h.info(ctx, map[string]interface{}{
+		"type": "orderStatus",
+		"user": address,
+		"oid":  clientOrderID,
+	}, &raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order status: %w", err)
+	}
+	if raw.Status != "order" || raw.Order == nil {
+		return nil, fmt.Errorf("%w: %s", ErrOrderNotFound, clientOrderID)
+	}
+
+	order := raw.Order.Order
+	result := &OrderResult{
+		OrderID:    order.Oid,
+		FilledSize: parseFloat(order.OrigSz) - parseFloat(order.Sz),
+	}
+	switch {
+	case raw.Order.Status == "filled":
+		result.Status = OrderStatusFilled
+	case result.FilledSize > 0:
+		result.Status = OrderStatusPartial
+	case raw.Order.Status == "open":
+		result.Status = OrderStatusResting
+	default:
+		result.Status = OrderStatusRejected
+		result.Error = raw.Order.Status
+	}
+	if result.FilledSize <= 0 {
+		return result, nil
+	}
+
+	// The status only carries sizes; the average price comes from the order's fills
+	fills, err := h.GetFills(ctx, address, time.UnixMilli(order.Timestamp))
+	if err != nil {
+		return nil, err
+	}
+	var size, notional float64
+	for _, fill := range fills {
+		if fill.OrderID == order.Oid {
+			size += fill.Size
+			notional += fill.Size * fill.Price
+		}
+	}
+	if size > 0 {
+		result.FilledSize = size
+		result.AvgPrice = notional / size
+	}
+
+	return result, nil
+}
+
+func (h *HyperliquidAdapter) CancelOrder(ctx context.Context, account string, symbol string, orderID int64) error {
+	asset, err := h.asset(ctx, symbol, priorityOrder)
+	if err != nil {
+		return err
+	}
+
+	action := wireMap{
+		{"type", "cancel"},
+		{"cancels", []wireMap{{{"a", asset.Index}, {"o", orderID}}}},
+	}
+
+	resp, err := h.exchange(ctx, account, action)
+	if err != nil {
+		return fmt.Errorf("failed to cancel order %d: %w", orderID, err)
+	}
+
+	var statuses struct {
+		Data struct {
+			Statuses []json.RawMessage `json:"statuses"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &statuses); err != nil {
+		return fmt.Errorf("failed to decode cancel response: %w", err)
+	}
+	for _, status := range statuses.Data.Statuses {
+		var errStatus struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(status, &errStatus) == nil && errStatus.Error != "" {
+			return fmt.Errorf("%w: %s", ErrOrderNotFound, errStatus.Error)
+		}
+	}
+
+	return nil
+}
+
+func (h *HyperliquidAdapter) ModifyOrder(ctx context.Context, orderID int64, req *OrderRequest) (*OrderResult, error) {
+	order, err := h.buildOrderWire(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	action := wireMap{
+		{"type", "modify"},
+		{"oid", orderID},
+		{"order", order.wire()},
+	}
+
+	resp, err := h.exchange(ctx, req.Account, action)
+	if err != nil {
+		return nil, fmt.Errorf("failed to modify order %d: %w", orderID, err)
+	}
+
+	// A successful modify returns a bare "default" response without statuses
+	var statuses hlOrderStatuses
+	if err := json.Unmarshal(resp, &statuses); err != nil || len(statuses.Data.Statuses) == 0 {
+		return &OrderResult{OrderID: orderID, Status: OrderStatusResting}, nil
+	}
+
+	return parseOrderStatus(resp, order.size)
+}
+
+func (h *HyperliquidAdapter) buildOrderWire(ctx context.Context, req *OrderRequest) (*hlOrderWire, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	asset, err := h.asset(ctx, req.Symbol, priorityOrder)
+	if err != nil {
+		return nil, err
+	}
+	if asset.Delisted && !req.ReduceOnly {
+		return nil, fmt.Errorf("%w: %s is delisted", ErrInvalidOrder, req.Symbol)
+	}
+
+	price := req.Price
+	tif := req.TimeInForce
+	if req.Type == OrderTypeMarket {
+		price, err = h.marketPrice(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		tif = TimeInForceIOC
+	}
+	if tif == "" {
+		tif = TimeInForceGTC
+	}
+
+	// Orders off the asset's tick or lot size are rejected by the exchange
+	size := asset.RoundSize(req.Size)
+	if size <= 0 {
+		return nil, fmt.Errorf("%w: %s size %g is below the lot size of %g",
+			ErrOrderTooSmall, req.Symbol, req.Size, math.Pow10(-asset.SizeDecimals))
+	}
+	price = asset.RoundPrice(price, req.Side)
+	if price <= 0 {
+		return nil, fmt.Errorf("%w: %s price must be positive", ErrInvalidOrder, req.Symbol)
+	}
+	// Reduce only orders are exempt so positions worth less than the minimum can
+	// still be closed
+	if value := size * price; value < minOrderValue && !req.ReduceOnly {
+		return nil, fmt.Errorf("%w: %s order value %.2f is below %.2f",
+			ErrOrderTooSmall, req.Symbol, value, minOrderValue)
+	}
+
+	order := &hlOrderWire{
+		Asset:       asset.Index,
+		IsBuy:       req.Side == SideBuy,
+		Price:       formatDecimal(price, asset.priceDecimals(price)),
+		Size:        formatDecimal(size, asset.SizeDecimals),
+		ReduceOnly:  req.ReduceOnly,
+		TimeInForce: tif,
+		size:        size,
+	}
+	if req.ClientOrderID != "" {
+		order.Cloid = &req.ClientOrderID
+	}
+
+	return order, nil
+}
+
+// marketPrice derives the worst acceptable price for a market order from the
+// reference price (or current mid) and the allowed slippage.
+func (h *HyperliquidAdapter) marketPrice(ctx context.Context, req *OrderRequest) (float64, error) {
+	reference := req.Price
+	if reference <= 0 {
+		mids, err := h.midPrices(ctx, priorityOrder)
+		if err != nil {
+			return 0, err
+		}
+		mid, ok := mids[req.Symbol]
+		if !ok {
+			return 0, fmt.Errorf("%w: %s", ErrUnknownSymbol, req.Symbol)
+		}
+		reference = mid
+	}
+
+	slippageBps := req.MaxSlippageBps
+	if slippageBps <= 0 {
+		slippageBps = defaultMarketSlippageBps
+	}
+
+	if req.Side == SideBuy {
+		return reference * (1 + slippageBps/10000), nil
+	}
+	return reference * (1 - slippageBps/10000), nil
+}
+
+func (h *HyperliquidAdapter) info(ctx context.Context, request map[string]interface{}, out interface{}) error {
+	return h.infoAt(ctx, priorityInfo, request, out)
+}
+
+// infoAt runs an info query at the given priority. Queries an order placement
+// depends on run at priorityOrder.
+func (h *HyperliquidAdapter) infoAt(ctx context.Context, priority requestPriority, request map[string]interface{}, out interface{}) error {
+	weight := weightInfo
+	switch request["type"] {
+	case "allMids", "clearinghouseState", "l2Book", "orderStatus":
+		weight = weightLightInfo
+	}
+
+	if err := h.scheduler.acquire(ctx, priority, weight); err != nil {
+		return err
+	}
+	return h.post(ctx, "/info", request, out)
+}
+
+func (h *HyperliquidAdapter) coalesce(ctx context.Context, key string, query func() (interface{}, error)) (interface{}, error) {
+	result, shared, err := h.queries.do(ctx, key, query)
+	if shared {
+		h.scheduler.recordCoalesced()
+	}
+	return result, err
+}
+
+// exchange signs an L1 action for the account and submits it
+func (h *HyperliquidAdapter) exchange(ctx context.Context, account string, action wireMap) (json.RawMessage, error) {
+	signer, err := h.signerFor(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+	// Resolved after the signer, which may bind the account to its owner's address
+	address, err := h.resolveAddress(account)
+	if err != nil {
+		return nil, err
+	}
+
+	// Weight is taken before the nonce so waiting for it cannot leave nonces out of order
+	if err := h.scheduler.acquire(ctx, priorityOrder, weightExchangeAction); err != nil {
+		return nil, err
+	}
+
+	nonce := h.nonces.Next()
+	vault := h.resolveVault(account)
+	signature, err := signer.SignAction(action, nonce, vault)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign action: %w", err)
+	}
+
+	body := map[string]interface{}{
+		"action":    action,
+		"nonce":     nonce,
+		"signature": signature,
+	}
+	if vault != nil {
+		body["vaultAddress"] = *vault
+	}
+
+	var resp hlExchangeResponse
+	err = h.post(ctx, "/exchange", body, &resp)
+	// Whatever the outcome, the account may have changed since its last query
+	h.queries.forget(clearinghouseKey(address))
+	if vault != nil {
+		h.queries.forget(clearinghouseKey(*vault))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Status != "ok" {
+		var message string
+		if err := json.Unmarshal(resp.Response, &message); err != nil {
+			message = string(resp.Response)
+		}
+		return nil, &APIError{StatusCode: http.StatusOK, Message: message}
+	}
+
+	return resp.Response, nil
+}
+
+func (h *HyperliquidAdapter) signerFor(ctx context.Context, account string) (Signer, error) {
+	h.accountsMu.RLock()
+	signers := h.signers
+	h.accountsMu.RUnlock()
+
+	if signers != nil {
+		signer, err := signers.SignerFor(ctx, account)
+		if err != nil {
+			return nil, err
+		}
+		if signer != nil {
+			return signer, nil
+		}
+	}
+
+	if h.signer == nil {
+		return nil, ErrSignerNotConfigured
+	}
+	return h.signer, nil
+}
+
+func (h *HyperliquidAdapter) post(ctx context.Context, path string, request interface{}, out interface{}) error {
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, h.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("request to %s failed: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func parseOrderStatus(resp json.RawMessage, requestedSize float64) (*OrderResult, error) {
+	var statuses hlOrderStatuses
+	if err := json.Unmarshal(resp, &statuses); err != nil {
+		return nil, fmt.Errorf("failed to decode order response: %w", err)
+	}
+
+	if len(statuses.Data.Statuses) == 0 {
+		return nil, fmt.Errorf("empty order response")
+	}
+
+	status := statuses.Data.Statuses[0]
+	switch {
+	case status.Error != nil:
+		return &OrderResult{Status: OrderStatusRejected, Error: *status.Error},
+			fmt.Errorf("%w: %s", ErrInvalidOrder, *status.Error)
+	case status.Filled != nil:
+		filled := parseFloat(status.Filled.TotalSz)
+		result := &OrderResult{
+			OrderID:    status.Filled.Oid,
+			Status:     OrderStatusFilled,
+			FilledSize: filled,
+			AvgPrice:   parseFloat(status.Filled.AvgPx),
+		}
+		if filled < requestedSize {
+			result.Status = OrderStatusPartial
+		}
+		return result, nil
+	case status.Resting != nil:
+		return &OrderResult{OrderID: status.Resting.Oid, Status: OrderStatusResting}, nil
+	default:
+		return nil, fmt.Errorf("unrecognised order status")
+	}
+}
+
+func parseSide(side string) Side {
+	// Hyperliquid uses "B" for bids and "A" for asks
+	if side == "B" {
+		return SideBuy
+	}
+	return SideSell
+}
+
+func parseFloat(value string) float64 {
+	if value == "" {
+		return 0
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Warning: failed to parse exchange number %q: %v", value, err)
+		return 0
+	}
+	return f
+}
@@ -0,0 +1,428 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperdash/copy-engine/internal/config"
+)
+
+const testAccount = "0x00000000000000000000000000000000000000a1"
+
+// Responses in the shape the Hyperliquid /info endpoint returns them
+var infoResponses = map[string]string{
+	"meta": `{"universe": [
+		{"name": "BTC", "szDecimals": 5, "maxLeverage": 40},
+		{"name": "ETH", "szDecimals": 4, "maxLeverage": 25},
+		{"name": "MATIC", "szDecimals": 1, "maxLeverage": 20, "onlyIsolated": true, "isDelisted": true}
+	]}`,
+	"allMids": `{"BTC": "64000.5", "ETH": "3200.25"}`,
+	"clearinghouseState": `{
+		"assetPositions": [
+			{"type": "oneWay", "position": {"coin": "ETH", "szi": "-1.5", "entryPx": "3180.4", "positionValue": "4800.38",
+				"unrealizedPnl": "-29.78", "marginUsed": "480.04", "liquidationPx": "5120.7",
+				"leverage": {"type": "cross", "value": 10}, "returnOnEquity": "-0.06"}},
+			{"type": "oneWay", "position": {"coin": "BTC", "szi": "0.0", "entryPx": null, "positionValue": "0.0",
+				"unrealizedPnl": "0.0", "marginUsed": "0.0", "liquidationPx": null,
+				"leverage": {"type": "isolated", "value": 5}}},
+			{"type": "oneWay", "position": {"coin": "SOL", "szi": "12.0", "entryPx": "142.1", "positionValue": "1710.0",
+				"unrealizedPnl": "4.8", "marginUsed": "342.0", "liquidationPx": null,
+				"leverage": {"type": "isolated", "value": 5}}}
+		],
+		"crossMarginSummary": {"accountValue": "10250.12", "totalNtlPos": "6510.38", "totalRawUsd": "14729.9", "totalMarginUsed": "822.04"},
+		"marginSummary": {"accountValue": "10250.12", "totalNtlPos": "6510.38", "totalRawUsd": "14729.9", "totalMarginUsed": "822.04"},
+		"withdrawable": "9428.08",
+		"time": 1760693400000
+	}`,
+	"openOrders": `[
+		{"coin": "ETH", "limitPx": "3100.5", "oid": 91490942, "side": "B", "sz": "0.25", "timestamp": 1760693400123},
+		{"coin": "BTC", "limitPx": "70000.0", "oid": 91490943, "side": "A", "sz": "0.01", "timestamp": 1760693401456}
+	]`,
+	"userFillsByTime": `[
+		{"coin": "ETH", "px": "3201.1", "sz": "0.5", "side": "A", "time": 1760693400500, "startPosition": "-1.0",
+			"dir": "Open Short", "closedPnl": "0.0", "hash": "0xa166e3fa63c25663024b03f2e0da011a00307e4017e9f0db2a5c6b2c30a1e6d0",
+			"oid": 90542681, "crossed": true, "fee": "0.720247", "tid": 118906512037719, "feeToken": "USDC"}
+	]`,
+}
+
+// fakeHyperliquid serves canned /info responses, which info overrides per request
+// type, and answers /exchange with the configured response, recording the actions
+// it receives
+type fakeHyperliquid struct {
+	t        *testing.T
+	exchange string
+	info     map[string]string
+
+	mu       sync.Mutex
+	requests []map[string]interface{}
+	actions  []map[string]interface{}
+}
+
+func (f *fakeHyperliquid) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	var request map[string]interface{}
+	if err := json.Unmarshal(body, &request); err != nil {
+		f.t.Errorf("undecodable request %s: %v", body, err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/info":
+		f.requests = append(f.requests, request)
+		response, ok := f.info[request["type"].(string)]
+		if !ok {
+			response, ok = infoResponses[request["type"].(string)]
+		}
+		if !ok {
+			http.Error(w, "unknown info type", http.StatusUnprocessableEntity)
+			return
+		}
+		io.WriteString(w, response)
+	case "/exchange":
+		f.actions = append(f.actions, request["action"].(map[string]interface{}))
+		io.WriteString(w, f.exchange)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeHyperliquid) lastRequest() map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[len(f.requests)-1]
+}
+
+func (f *fakeHyperliquid) lastAction() map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.actions[len(f.actions)-1]
+}
+
+type staticSigner struct{}
+
+func (staticSigner) SignAction(action interface{}, nonce int64, vaultAddress *string) (*Signature, error) {
+	return &Signature{R: "0x01", S: "0x02", V: 27}, nil
+}
+
+func newTestAdapter(t *testing.T, exchangeResponse string) (*HyperliquidAdapter, *fakeHyperliquid) {
+	t.Helper()
+	fake := &fakeHyperliquid{t: t, exchange: exchangeResponse}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	adapter := NewHyperliquidAdapter(config.HyperliquidConfig{
+		BaseURL:             server.URL + "/info",
+		APIKey:              testAccount,
+		RateLimitWeight:     1200,
+		MetaRefreshInterval: 3600,
+	})
+	adapter.SetSigner(staticSigner{})
+	return adapter, fake
+}
+
+func TestGetPositionsParsesClearinghouseState(t *testing.T) {
+	adapter, fake := newTestAdapter(t, "")
+
+	positions, err := adapter.GetPositions(context.Background(), "")
+	if err != nil {
+		t.Fatalf("GetPositions: %v", err)
+	}
+	if user := fake.lastRequest()["user"]; user != testAccount {
+		t.Errorf("queried user %v, want %s", user, testAccount)
+	}
+
+	// The flat BTC position is skipped
+	if len(positions) != 2 {
+		t.Fatalf("%d positions, want 2", len(positions))
+	}
+	eth := positions[0]
+	if eth.Symbol != "ETH" || eth.Size != -1.5 || eth.EntryPrice != 3180.4 || eth.PositionValue != 4800.38 ||
+		eth.UnrealizedPnL != -29.78 || eth.MarginUsed != 480.04 || eth.Leverage != 10 {
+		t.Errorf("ETH position %+v", eth)
+	}
+	if eth.LiquidationPrice == nil || *eth.LiquidationPrice != 5120.7 {
+		t.Errorf("ETH liquidation price %v, want 5120.7", eth.LiquidationPrice)
+	}
+	if sol := positions[1]; sol.Symbol != "SOL" || sol.Size != 12 || sol.LiquidationPrice != nil {
+		t.Errorf("SOL position %+v, want 12 without a liquidation price", sol)
+	}
+}
+
+func TestGetBalancesParsesMarginSummary(t *testing.T) {
+	adapter, _ := newTestAdapter(t, "")
+
+	balance, err := adapter.GetBalances(context.Background(), "")
+	if err != nil {
+		t.Fatalf("GetBalances: %v", err)
+	}
+	want := Balance{AccountValue: 10250.12, TotalNotional: 6510.38, TotalMarginUsed: 822.04, Withdrawable: 9428.08}
+	if *balance != want {
+		t.Errorf("balance %+v, want %+v", *balance, want)
+	}
+}
+
+func TestGetOpenOrdersParsesOrders(t *testing.T) {
+	adapter, _ := newTestAdapter(t, "")
+
+	orders, err := adapter.GetOpenOrders(context.Background(), "")
+	if err != nil {
+		t.Fatalf("GetOpenOrders: %v", err)
+	}
+	if len(orders) != 2 {
+		t.Fatalf("%d orders, want 2", len(orders))
+	}
+	want := Order{OrderID: 91490942, Symbol: "ETH", Side: SideBuy, Price: 3100.5, Size: 0.25, Timestamp: time.UnixMilli(1760693400123)}
+	if *orders[0] != want {
+		t.Errorf("first order %+v, want %+v", *orders[0], want)
+	}
+	if orders[1].Side != SideSell || orders[1].Price != 70000 {
+		t.Errorf("second order %+v, want a sell at 70000", *orders[1])
+	}
+}
+
+func TestGetFillsParsesUserFillsByTime(t *testing.T) {
+	adapter, fake := newTestAdapter(t, "")
+	since := time.UnixMilli(1760690000000)
+
+	fills, err := adapter.GetFills(context.Background(), "", since)
+	if err != nil {
+		t.Fatalf("GetFills: %v", err)
+	}
+	if startTime := fake.lastRequest()["startTime"]; startTime != float64(since.UnixMilli()) {
+		t.Errorf("startTime %v, want %d", startTime, since.UnixMilli())
+	}
+	if len(fills) != 1 {
+		t.Fatalf("%d fills, want 1", len(fills))
+	}
+	want := Fill{
+		Symbol:        "ETH",
+		Side:          SideSell,
+		Price:         3201.1,
+		Size:          0.5,
+		Fee:           0.720247,
+		StartPosition: -1,
+		Direction:     "Open Short",
+		OrderID:       90542681,
+		TradeID:       118906512037719,
+		Hash:          "0xa166e3fa63c25663024b03f2e0da011a00307e4017e9f0db2a5c6b2c30a1e6d0",
+		Time:          time.UnixMilli(1760693400500),
+	}
+	if *fills[0] != want {
+		t.Errorf("fill %+v, want %+v", *fills[0], want)
+	}
+}
+
+func TestGetOrderStatusLooksUpClientOrderID(t *testing.T) {
+	const cloid = "0x5f0c8a521d3e4c479a7e2b6f3c1d9e80"
+
+	tests := []struct {
+		name    string
+		status  string
+		want    *OrderResult
+		wantErr error
+	}{
+		{
+			name: "partially filled IOC",
+			status: `{"status": "order", "order": {"order": {"coin": "ETH", "side": "A", "limitPx": "3180.0", "sz": "0.3",
+				"oid": 90542681, "timestamp": 1760693400400, "origSz": "0.8", "cloid": "` + cloid + `"},
+				"status": "canceled", "statusTimestamp": 1760693400500}}`,
+			want: &OrderResult{OrderID: 90542681, Status: OrderStatusPartial, FilledSize: 0.5, AvgPrice: 3201.1},
+		},
+		{
+			name: "resting",
+			status: `{"status": "order", "order": {"order": {"coin": "ETH", "side": "B", "limitPx": "3100.5", "sz": "0.25",
+				"oid": 91490942, "timestamp": 1760693400123, "origSz": "0.25", "cloid": "` + cloid + `"},
+				"status": "open", "statusTimestamp": 1760693400123}}`,
+			want: &OrderResult{OrderID: 91490942, Status: OrderStatusResting},
+		},
+		{
+			name: "rejected",
+			status: `{"status": "order", "order": {"order": {"coin": "ETH", "side": "B", "limitPx": "3300.0", "sz": "0.25",
+				"oid": 91490944, "timestamp": 1760693400123, "origSz": "0.25", "cloid": "` + cloid + `"},
+				"status": "marginCanceled", "statusTimestamp": 1760693400123}}`,
+			want: &OrderResult{OrderID: 91490944, Status: OrderStatusRejected, Error: "marginCanceled"},
+		},
+		{
+			name:    "never received",
+			status:  `{"status": "unknownOid"}`,
+			wantErr: ErrOrderNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter, fake := newTestAdapter(t, "")
+			fake.info = map[string]string{"orderStatus": tt.status}
+
+			result, err := adapter.GetOrderStatus(context.Background(), "", cloid)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetOrderStatus: %v", err)
+			}
+			if *result != *tt.want {
+				t.Errorf("result %+v, want %+v", *result, *tt.want)
+			}
+		})
+	}
+}
+
+func TestGetMidPricesParsesAllMids(t *testing.T) {
+	adapter, _ := newTestAdapter(t, "")
+
+	mids, err := adapter.GetMidPrices(context.Background())
+	if err != nil {
+		t.Fatalf("GetMidPrices: %v", err)
+	}
+	if len(mids) != 2 || mids["BTC"] != 64000.5 || mids["ETH"] != 3200.25 {
+		t.Errorf("mids %v", mids)
+	}
+}
+
+func TestAssetInfoParsesMeta(t *testing.T) {
+	adapter, _ := newTestAdapter(t, "")
+
+	eth, err := adapter.AssetInfo(context.Background(), "ETH")
+	if err != nil {
+		t.Fatalf("AssetInfo: %v", err)
+	}
+	want := AssetInfo{Symbol: "ETH", Index: 1, SizeDecimals: 4, PriceDecimals: 2, MaxLeverage: 25}
+	if *eth != want {
+		t.Errorf("ETH %+v, want %+v", *eth, want)
+	}
+
+	matic, err := adapter.AssetInfo(context.Background(), "MATIC")
+	if err != nil {
+		t.Fatalf("AssetInfo: %v", err)
+	}
+	if matic.Index != 2 || !matic.OnlyIsolated || !matic.Delisted {
+		t.Errorf("MATIC %+v, want index 2, isolated only and delisted", *matic)
+	}
+
+	if _, err := adapter.AssetInfo(context.Background(), "DOGE"); !errors.Is(err, ErrUnknownSymbol) {
+		t.Errorf("unknown symbol returned %v, want ErrUnknownSymbol", err)
+	}
+}
+
+func TestPlaceOrderParsesStatuses(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     OrderResult
+		wantErr  error
+	}{
+		{
+			name:     "filled",
+			response: `{"status":"ok","response":{"type":"order","data":{"statuses":[{"filled":{"totalSz":"0.5","avgPx":"3200.3","oid":77738308}}]}}}`,
+			want:     OrderResult{OrderID: 77738308, Status: OrderStatusFilled, FilledSize: 0.5, AvgPrice: 3200.3},
+		},
+		{
+			name:     "partially filled",
+			response: `{"status":"ok","response":{"type":"order","data":{"statuses":[{"filled":{"totalSz":"0.2","avgPx":"3200.1","oid":77738309}}]}}}`,
+			want:     OrderResult{OrderID: 77738309, Status: OrderStatusPartial, FilledSize: 0.2, AvgPrice: 3200.1},
+		},
+		{
+			name:     "resting",
+			response: `{"status":"ok","response":{"type":"order","data":{"statuses":[{"resting":{"oid":77738310}}]}}}`,
+			want:     OrderResult{OrderID: 77738310, Status: OrderStatusResting},
+		},
+		{
+			name:     "rejected",
+			response: `{"status":"ok","response":{"type":"order","data":{"statuses":[{"error":"Insufficient margin to place order. asset=1"}]}}}`,
+			wantErr:  ErrInvalidOrder,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter, fake := newTestAdapter(t, tt.response)
+
+			result, err := adapter.PlaceOrder(context.Background(), &OrderRequest{
+				Symbol: "ETH",
+				Side:   SideBuy,
+				Type:   OrderTypeLimit,
+				Size:   0.5,
+				Price:  3200.37,
+			})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("PlaceOrder returned %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PlaceOrder: %v", err)
+			}
+			if *result != tt.want {
+				t.Errorf("result %+v, want %+v", *result, tt.want)
+			}
+
+			action := fake.lastAction()
+			order := action["orders"].([]interface{})[0].(map[string]interface{})
+			if action["type"] != "order" || order["a"] != float64(1) || order["b"] != true || order["p"] != "3200.3" || order["s"] != "0.5" {
+				t.Errorf("sent action %v, want a buy of 0.5 ETH (asset 1) at 3200.3", action)
+			}
+		})
+	}
+}
+
+func TestExchangeErrorResponse(t *testing.T) {
+	adapter, _ := newTestAdapter(t, `{"status":"err","response":"User or API Wallet 0x0000 does not exist."}`)
+
+	_, err := adapter.PlaceOrder(context.Background(), &OrderRequest{
+		Symbol: "ETH", Side: SideSell, Type: OrderTypeLimit, Size: 0.5, Price: 3300,
+	})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "User or API Wallet 0x0000 does not exist." {
+		t.Fatalf("PlaceOrder returned %v, want the exchange's error message", err)
+	}
+	if apiErr.Temporary() {
+		t.Errorf("a rejected action is reported as temporary")
+	}
+}
+
+func TestCancelOrderParsesStatuses(t *testing.T) {
+	adapter, fake := newTestAdapter(t, `{"status":"ok","response":{"type":"cancel","data":{"statuses":["success"]}}}`)
+	if err := adapter.CancelOrder(context.Background(), "", "BTC", 77738308); err != nil {
+		t.Fatalf("CancelOrder: %v", err)
+	}
+	cancel := fake.lastAction()["cancels"].([]interface{})[0].(map[string]interface{})
+	if cancel["a"] != float64(0) || cancel["o"] != float64(77738308) {
+		t.Errorf("sent cancel %v, want order 77738308 of asset 0", cancel)
+	}
+
+	adapter, _ = newTestAdapter(t, `{"status":"ok","response":{"type":"cancel","data":{"statuses":[{"error":"Order was never placed, already canceled, or filled. asset=0"}]}}}`)
+	if err := adapter.CancelOrder(context.Background(), "", "BTC", 77738308); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("CancelOrder returned %v, want ErrOrderNotFound", err)
+	}
+}
+
+func TestModifyOrderAcceptsDefaultResponse(t *testing.T) {
+	adapter, fake := newTestAdapter(t, `{"status":"ok","response":{"type":"default"}}`)
+
+	result, err := adapter.ModifyOrder(context.Background(), 77738310, &OrderRequest{
+		Symbol: "ETH", Side: SideBuy, Type: OrderTypeLimit, Size: 0.5, Price: 3150,
+	})
+	if err != nil {
+		t.Fatalf("ModifyOrder: %v", err)
+	}
+	if result.OrderID != 77738310 || result.Status != OrderStatusResting {
+		t.Errorf("result %+v, want order 77738310 resting", *result)
+	}
+	if action := fake.lastAction(); action["type"] != "modify" || action["oid"] != float64(77738310) {
+		t.Errorf("sent action %v, want a modify of 77738310", action)
+	}
+}
@@ -0,0 +1,164 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Precision rules of Hyperliquid perpetuals. Sizes are multiples of 10^-szDecimals;
+// prices have at most five significant figures (integer prices are always valid)
+// and at most six minus szDecimals decimals.
+const (
+	perpMaxPriceDecimals    = 6
+	priceSignificantFigures = 5
+
+	// minOrderValue is the smallest order value in USD the exchange accepts
+	minOrderValue = 10.0
+)
+
+// AssetInfo is the exchange's metadata for a perpetual
+type AssetInfo struct {
+	Symbol        string
+	Index         int // asset ID used in order actions
+	SizeDecimals  int
+	PriceDecimals int // decimals allowed in a price, before the significant figure limit
+	MaxLeverage   int
+	OnlyIsolated  bool // positions cannot use cross margin
+	Delisted      bool
+}
+
+// RoundSize rounds a size down to the asset's lot size, so an order never exceeds
+// the size it was asked for
+func (a *AssetInfo) RoundSize(size float64) float64 {
+	step := math.Pow10(a.SizeDecimals)
+	// The factor absorbs float error such as 0.3*10 = 2.9999999999999996
+	return math.Floor(size*step*(1+1e-12)) / step
+}
+
+// RoundPrice rounds a price to a valid tick. Buy prices are rounded down and sell
+// prices up, so a limit never becomes worse than the one asked for.
+func (a *AssetInfo) RoundPrice(price float64, side Side) float64 {
+	if price <= 0 {
+		return 0
+	}
+
+	decimals := a.priceDecimals(price)
+	step := math.Pow10(decimals)
+	if side == SideBuy {
+		return math.Floor(price*step*(1+1e-12)) / step
+	}
+	return math.Ceil(price*step*(1-1e-12)) / step
+}
+
+// priceDecimals returns the decimals a price may carry under both the decimal and
+// the significant figure limit
+func (a *AssetInfo) priceDecimals(price float64) int {
+	integerDigits := int(math.Floor(math.Log10(price))) + 1
+	decimals := priceSignificantFigures - integerDigits
+	if decimals < 0 {
+		decimals = 0
+	}
+	if decimals > a.PriceDecimals {
+		decimals = a.PriceDecimals
+	}
+	return decimals
+}
+
+type hlMeta struct {
+	Universe []struct {
+		Name         string `json:"name"`
+		SzDecimals   int    `json:"szDecimals"`
+		MaxLeverage  int    `json:"maxLeverage"`
+		OnlyIsolated bool   `json:"onlyIsolated"`
+		IsDelisted   bool   `json:"isDelisted"`
+	} `json:"universe"`
+}
+
+// AssetInfo returns the metadata of a perpetual, loading the exchange's metadata on
+// first use and again once it is older than the refresh interval
+func (h *HyperliquidAdapter) AssetInfo(ctx context.Context, symbol string) (*AssetInfo, error) {
+	asset, err := h.asset(ctx, symbol, priorityInfo)
+	if err != nil {
+		return nil, err
+	}
+	info := *asset
+	return &info, nil
+}
+
+// asset looks up a perpetual, loading the metadata at the given priority if needed
+func (h *HyperliquidAdapter) asset(ctx context.Context, symbol string, priority requestPriority) (*AssetInfo, error) {
+	h.assetsMu.RLock()
+	assets, loadedAt := h.assets, h.assetsLoadedAt
+	h.assetsMu.RUnlock()
+
+	if assets == nil || time.Since(loadedAt) >= h.metaRefresh {
+		loaded, err := h.loadAssets(ctx, priority)
+		switch {
+		case err == nil:
+			assets = loaded
+		case assets == nil:
+			return nil, err
+		default:
+			// Tick and lot sizes rarely change; keep trading on the previous metadata
+			log.Printf("Warning: using cached asset metadata: %v", err)
+		}
+	}
+
+	asset, ok := assets[symbol]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSymbol, symbol)
+	}
+	return asset, nil
+}
+
+// loadAssets fetches the perpetuals' metadata and replaces the cache. Concurrent
+// loads share one request.
+func (h *HyperliquidAdapter) loadAssets(ctx context.Context, priority requestPriority) (map[string]*AssetInfo, error) {
+	result, err := h.coalesce(ctx, "meta", func() (interface{}, error) {
+		var meta hlMeta
+		err := h.infoAt(ctx, priority, map[string]interface{}{"type": "meta"}, &meta)
+		return &meta, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load asset metadata: %w", err)
+	}
+	meta := result.(*hlMeta)
+
+	assets := make(map[string]*AssetInfo, len(meta.Universe))
+	for i, a := range meta.Universe {
+		assets[a.Name] = &AssetInfo{
+			Symbol:        a.Name,
+			Index:         i,
+			SizeDecimals:  a.SzDecimals,
+			PriceDecimals: perpMaxPriceDecimals - a.SzDecimals,
+			MaxLeverage:   a.MaxLeverage,
+			OnlyIsolated:  a.OnlyIsolated,
+			Delisted:      a.IsDelisted,
+		}
+	}
+
+	h.assetsMu.Lock()
+	h.assets = assets
+	h.assetsLoadedAt = time.Now()
+	h.assetsMu.Unlock()
+
+	return assets, nil
+}
+
+// formatDecimal renders a rounded value with at most decimals digits and without
+// trailing zeros, the canonical form the exchange checks against its tick
+func formatDecimal(value float64, decimals int) string {
+	s := strconv.FormatFloat(value, 'f', decimals, 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
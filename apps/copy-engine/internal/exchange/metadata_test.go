@@ -0,0 +1,164 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+var (
+	testBTC   = &AssetInfo{Symbol: "BTC", SizeDecimals: 5, PriceDecimals: 1}
+	testETH   = &AssetInfo{Symbol: "ETH", SizeDecimals: 4, PriceDecimals: 2}
+	testSOL   = &AssetInfo{Symbol: "SOL", SizeDecimals: 2, PriceDecimals: 4}
+	testWhole = &AssetInfo{Symbol: "PEPE", SizeDecimals: 0, PriceDecimals: 6}
+)
+
+func TestRoundSize(t *testing.T) {
+	tests := []struct {
+		name  string
+		asset *AssetInfo
+		size  float64
+		want  float64
+	}{
+		{"float error", &AssetInfo{SizeDecimals: 1}, 0.1 + 0.2, 0.3},
+		{"exact lot", testETH, 0.5, 0.5},
+		{"rounds down", testETH, 1.23456, 1.2345},
+		{"never up", testSOL, 2.999, 2.99},
+		{"high price asset", testBTC, 0.0156789, 0.01567},
+		{"below the lot size", testBTC, 0.000009, 0},
+		{"whole units", testWhole, 1234567.9, 1234567},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.asset.RoundSize(tt.size); got != tt.want {
+				t.Errorf("RoundSize(%v) = %v, want %v", tt.size, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundPrice(t *testing.T) {
+	tests := []struct {
+		name     string
+		asset    *AssetInfo
+		price    float64
+		wantBuy  float64
+		wantSell float64
+	}{
+		{"high price asset", testBTC, 64000.5, 64000, 64001},
+		{"above five figures", testBTC, 123456.7, 123456, 123457},
+		{"four integer digits", testETH, 3200.37, 3200.3, 3200.4},
+		{"on the tick", testETH, 3200.3, 3200.3, 3200.3},
+		{"decimal limit", testSOL, 1.234567, 1.2345, 1.2346},
+		{"float error", testWhole, 0.1 + 0.2, 0.3, 0.3},
+		{"small price", testWhole, 0.000123456, 0.000123, 0.000124},
+		{"not positive", testETH, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.asset.RoundPrice(tt.price, SideBuy); got != tt.wantBuy {
+				t.Errorf("buy RoundPrice(%v) = %v, want %v", tt.price, got, tt.wantBuy)
+			}
+			if got := tt.asset.RoundPrice(tt.price, SideSell); got != tt.wantSell {
+				t.Errorf("sell RoundPrice(%v) = %v, want %v", tt.price, got, tt.wantSell)
+			}
+		})
+	}
+}
+
+func TestPriceDecimals(t *testing.T) {
+	tests := []struct {
+		name  string
+		asset *AssetInfo
+		price float64
+		want  int
+	}{
+		{"five integer digits", testBTC, 64000.5, 0},
+		{"six integer digits", testBTC, 123456.7, 0},
+		{"four integer digits", testETH, 3200.25, 1},
+		{"capped by the asset", testETH, 1.5, 2},
+		{"one integer digit", testSOL, 1.5, 4},
+		{"below one", testWhole, 0.3, 5},
+		{"capped below one", testWhole, 0.000123456, 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.asset.priceDecimals(tt.price); got != tt.want {
+				t.Errorf("priceDecimals(%v) = %d, want %d", tt.price, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatDecimal(t *testing.T) {
+	tests := []struct {
+		value    float64
+		decimals int
+		want     string
+	}{
+		{64000, 0, "64000"},
+		{64001, 1, "64001"},
+		{3200.3, 1, "3200.3"},
+		{0.5, 4, "0.5"},
+		{0.1 + 0.2, 5, "0.3"},
+		{0.00001, 5, "0.00001"},
+		{100, 2, "100"},
+		{-0.00000001, 4, "0"},
+	}
+
+	for _, tt := range tests {
+		if got := formatDecimal(tt.value, tt.decimals); got != tt.want {
+			t.Errorf("formatDecimal(%v, %d) = %q, want %q", tt.value, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestBuildOrderWireChecksMinimumValue(t *testing.T) {
+	tests := []struct {
+		name       string
+		symbol     string
+		size       float64
+		price      float64
+		reduceOnly bool
+		wantErr    error
+		wantPrice  string
+		wantSize   string
+	}{
+		{name: "above the minimum", symbol: "ETH", size: 0.0032, price: 3200.37, wantPrice: "3200.3", wantSize: "0.0032"},
+		{name: "at the minimum", symbol: "ETH", size: 0.004, price: 2500, wantPrice: "2500", wantSize: "0.004"},
+		{name: "below the minimum", symbol: "ETH", size: 0.003, price: 3200, wantErr: ErrOrderTooSmall},
+		{name: "reduce only below the minimum", symbol: "ETH", size: 0.003, price: 3200, reduceOnly: true, wantPrice: "3200", wantSize: "0.003"},
+		{name: "below the minimum after rounding", symbol: "BTC", size: 0.000159, price: 64000.5, wantErr: ErrOrderTooSmall},
+		{name: "high price asset", symbol: "BTC", size: 0.00016, price: 64000.5, wantPrice: "64000", wantSize: "0.00016"},
+		{name: "below the lot size", symbol: "BTC", size: 0.000009, price: 64000.5, reduceOnly: true, wantErr: ErrOrderTooSmall},
+	}
+
+	adapter, _ := newTestAdapter(t, "")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, err := adapter.buildOrderWire(context.Background(), &OrderRequest{
+				Symbol:     tt.symbol,
+				Side:       SideBuy,
+				Type:       OrderTypeLimit,
+				Size:       tt.size,
+				Price:      tt.price,
+				ReduceOnly: tt.reduceOnly,
+			})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("buildOrderWire returned %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildOrderWire: %v", err)
+			}
+			if order.Price != tt.wantPrice || order.Size != tt.wantSize || order.ReduceOnly != tt.reduceOnly {
+				t.Errorf("order %+v, want %s at %s", *order, tt.wantSize, tt.wantPrice)
+			}
+		})
+	}
+}
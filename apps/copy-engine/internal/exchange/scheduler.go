@@ -0,0 +1,316 @@
+package exchange
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Request weights of the Hyperliquid REST API. Every IP may spend a budget of
+// weight per minute; /exchange actions weigh 1 plus 1 per 40 orders in a batch.
+const (
+	weightExchangeAction = 1
+	weightLightInfo      = 2 // allMids, clearinghouseState, l2Book, orderStatus
+	weightInfo           = 20
+)
+
+// requestPriority orders the queues of the scheduler; lower values go first
+type requestPriority int
+
+const (
+	priorityOrder requestPriority = iota
+	priorityInfo
+	priorityCount
+)
+
+// RateLimitMetrics describes how the adapter's request budget is being spent
+type RateLimitMetrics struct {
+	Requests      uint64        // requests granted weight
+	Throttled     uint64        // requests that had to wait for weight
+	Coalesced     uint64        // info queries answered from a concurrent or recent identical query
+	Cancelled     uint64        // requests abandoned while waiting for weight
+	WeightSpent   uint64        // total weight granted
+	TotalWait     time.Duration // time throttled requests spent waiting
+	MaxWait       time.Duration
+	Available     float64 // weight currently available
+	QueuedOrders  int     // order placements waiting for weight
+	QueuedQueries int     // info queries waiting for weight
+}
+
+// weightScheduler is a token bucket refilled at the exchange's per-minute budget.
+// Requests that cannot be served immediately wait in per-priority FIFO queues, and
+// an info query never takes weight while an order placement is waiting.
+type weightScheduler struct {
+	capacity float64
+	refill   float64 // weight per second
+	tokens   float64
+	last     time.Time
+
+	queues [priorityCount][]*weightWaiter
+	timer  *time.Timer
+
+	metrics RateLimitMetrics
+	mu      sync.Mutex
+}
+
+type weightWaiter struct {
+	weight float64
+	queued time.Time
+	ready  chan struct{}
+}
+
+func newWeightScheduler(weightPerMinute int) *weightScheduler {
+	capacity := float64(weightPerMinute)
+	return &weightScheduler{
+		capacity: capacity,
+		refill:   capacity / 60,
+		tokens:   capacity,
+		last:     time.Now(),
+	}
+}
+
+// acquire blocks until weight is granted at the given priority or ctx ends
+func (s *weightScheduler) acquire(ctx context.Context, priority requestPriority, weight int) error {
+	s.mu.Lock()
+
+	w := float64(weight)
+	if w > s.capacity {
+		// A request heavier than the whole budget would never be served otherwise
+		w = s.capacity
+	}
+
+	s.refillLocked(time.Now())
+	if s.queuedAheadLocked(priority) == 0 && s.tokens >= w {
+		s.grantLocked(w)
+		s.mu.Unlock()
+		return nil
+	}
+
+	waiter := &weightWaiter{weight: w, queued: time.Now(), ready: make(chan struct{})}
+	s.queues[priority] = append(s.queues[priority], waiter)
+	s.metrics.Throttled++
+	s.scheduleLocked()
+	s.mu.Unlock()
+
+	select {
+	case <-waiter.ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		select {
+		case <-waiter.ready:
+			// Granted while cancelling; the weight is spent either way
+			return nil
+		default:
+		}
+		s.removeLocked(priority, waiter)
+		s.metrics.Cancelled++
+		// The head of the queue may have changed
+		s.dispatchLocked()
+		return ctx.Err()
+	}
+}
+
+// Metrics returns a snapshot of the scheduler's counters
+func (s *weightScheduler) Metrics() RateLimitMetrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refillLocked(time.Now())
+	metrics := s.metrics
+	metrics.Available = s.tokens
+	metrics.QueuedOrders = len(s.queues[priorityOrder])
+	metrics.QueuedQueries = len(s.queues[priorityInfo])
+	return metrics
+}
+
+func (s *weightScheduler) recordCoalesced() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metrics.Coalesced++
+}
+
+func (s *weightScheduler) refillLocked(now time.Time) {
+	elapsed := now.Sub(s.last).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	s.tokens += elapsed * s.refill
+	if s.tokens > s.capacity {
+		s.tokens = s.capacity
+	}
+	s.last = now
+}
+
+// queuedAheadLocked counts waiters that must be served before a new request of
+// the given priority
+func (s *weightScheduler) queuedAheadLocked(priority requestPriority) int {
+	queued := 0
+	for p := requestPriority(0); p <= priority; p++ {
+		queued += len(s.queues[p])
+	}
+	return queued
+}
+
+func (s *weightScheduler) grantLocked(weight float64) {
+	s.tokens -= weight
+	s.metrics.Requests++
+	s.metrics.WeightSpent += uint64(weight)
+}
+
+// dispatchLocked serves waiters in priority order for as long as the head of the
+// highest non-empty queue fits in the bucket, then arms the timer for the rest
+func (s *weightScheduler) dispatchLocked() {
+	now := time.Now()
+	s.refillLocked(now)
+
+	for p := requestPriority(0); p < priorityCount; p++ {
+		for len(s.queues[p]) > 0 {
+			waiter := s.queues[p][0]
+			if s.tokens < waiter.weight {
+				s.scheduleLocked()
+				return
+			}
+
+			s.queues[p] = s.queues[p][1:]
+			s.grantLocked(waiter.weight)
+
+			wait := now.Sub(waiter.queued)
+			s.metrics.TotalWait += wait
+			if wait > s.metrics.MaxWait {
+				s.metrics.MaxWait = wait
+			}
+			close(waiter.ready)
+		}
+	}
+}
+
+// scheduleLocked arms the timer for when the next waiter's weight is available
+func (s *weightScheduler) scheduleLocked() {
+	if s.timer != nil {
+		return
+	}
+
+	var next *weightWaiter
+	for p := requestPriority(0); p < priorityCount && next == nil; p++ {
+		if len(s.queues[p]) > 0 {
+			next = s.queues[p][0]
+		}
+	}
+	if next == nil {
+		return
+	}
+
+	delay := time.Duration((next.weight - s.tokens) / s.refill * float64(time.Second))
+	if delay < time.Millisecond {
+		delay = time.Millisecond
+	}
+	s.timer = time.AfterFunc(delay, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.timer = nil
+		s.dispatchLocked()
+	})
+}
+
+func (s *weightScheduler) removeLocked(priority requestPriority, waiter *weightWaiter) {
+	queue := s.queues[priority]
+	for i, queued := range queue {
+		if queued == waiter {
+			s.queues[priority] = append(queue[:i:i], queue[i+1:]...)
+			return
+		}
+	}
+}
+
+// queryCoalescer shares the result of an info query between identical queries
+// issued while it runs or within window after it completed, so that strategies
+// polling the same account in one tick cost a single request
+type queryCoalescer struct {
+	window time.Duration
+	calls  map[string]*coalescedCall
+	mu     sync.Mutex
+}
+
+type coalescedCall struct {
+	done     chan struct{}
+	result   interface{}
+	err      error
+	finished time.Time
+	stale    bool // forgotten while running; shared with concurrent callers only
+}
+
+func newQueryCoalescer(window time.Duration) *queryCoalescer {
+	return &queryCoalescer{window: window, calls: make(map[string]*coalescedCall)}
+}
+
+// do runs query once per key and window and reports whether the result was shared.
+// Failed queries are never shared after they complete. Callers joining a running
+// query get its outcome even if it fails because the first caller's context ended.
+func (c *queryCoalescer) do(ctx context.Context, key string, query func() (interface{}, error)) (interface{}, bool, error) {
+	c.mu.Lock()
+	if call, ok := c.calls[key]; ok {
+		select {
+		case <-call.done:
+			if call.err == nil && time.Since(call.finished) < c.window {
+				c.mu.Unlock()
+				return call.result, true, nil
+			}
+		default:
+			c.mu.Unlock()
+			select {
+			case <-call.done:
+				return call.result, true, call.err
+			case <-ctx.Done():
+				return nil, false, ctx.Err()
+			}
+		}
+	}
+
+	call := &coalescedCall{done: make(chan struct{})}
+	c.calls[key] = call
+	c.mu.Unlock()
+
+	call.result, call.err = query()
+
+	c.mu.Lock()
+	call.finished = time.Now()
+	if (call.err != nil || call.stale) && c.calls[key] == call {
+		delete(c.calls, key)
+	}
+	close(call.done)
+	c.pruneLocked(call.finished)
+	c.mu.Unlock()
+
+	return call.result, false, call.err
+}
+
+// forget drops a result, e.g. after an order changed the account. A query still
+// running is not shared with callers arriving after its completion.
+func (c *queryCoalescer) forget(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if call, ok := c.calls[key]; ok {
+		select {
+		case <-call.done:
+			delete(c.calls, key)
+		default:
+			call.stale = true
+		}
+	}
+}
+
+func (c *queryCoalescer) pruneLocked(now time.Time) {
+	for key, call := range c.calls {
+		select {
+		case <-call.done:
+			if now.Sub(call.finished) >= c.window {
+				delete(c.calls, key)
+			}
+		default:
+		}
+	}
+}
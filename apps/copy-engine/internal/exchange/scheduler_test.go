@@ -0,0 +1,242 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWeightSchedulerRefill(t *testing.T) {
+	s := newWeightScheduler(600)
+	start := s.last
+
+	s.tokens = 0
+	s.refillLocked(start.Add(3 * time.Second))
+	if s.tokens != 30 {
+		t.Errorf("tokens after 3s = %v, want 30 at 10 per second", s.tokens)
+	}
+
+	s.refillLocked(start.Add(time.Second))
+	if s.tokens != 30 {
+		t.Errorf("tokens after a clock step back = %v, want 30", s.tokens)
+	}
+
+	s.refillLocked(start.Add(time.Hour))
+	if s.tokens != 600 {
+		t.Errorf("tokens after an hour = %v, want the capacity of 600", s.tokens)
+	}
+}
+
+func TestWeightSchedulerWaitsForWeight(t *testing.T) {
+	s := newWeightScheduler(600)
+	ctx := context.Background()
+
+	// Heavier than the whole budget: capped rather than never served
+	if err := s.acquire(ctx, priorityInfo, 1000); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	start := time.Now()
+	if err := s.acquire(ctx, priorityInfo, weightLightInfo); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	if waited := time.Since(start); waited < 150*time.Millisecond {
+		t.Errorf("waited %v for 2 weight at 10 per second, want about 200ms", waited)
+	}
+
+	metrics := s.Metrics()
+	if metrics.Requests != 2 || metrics.Throttled != 1 || metrics.WeightSpent != 602 || metrics.MaxWait == 0 {
+		t.Errorf("metrics %+v, want 2 requests, 1 throttled and 602 weight spent", metrics)
+	}
+}
+
+func TestWeightSchedulerServesOrdersFirst(t *testing.T) {
+	s := newWeightScheduler(6000)
+	ctx := context.Background()
+	if err := s.acquire(ctx, priorityOrder, 6000); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	granted := make(chan requestPriority, 2)
+	acquire := func(priority requestPriority) {
+		if err := s.acquire(ctx, priority, weightInfo); err != nil {
+			t.Errorf("acquire: %v", err)
+		}
+		granted <- priority
+	}
+
+	go acquire(priorityInfo)
+	waitFor(t, func() bool { return s.Metrics().QueuedQueries == 1 })
+	go acquire(priorityOrder)
+	waitFor(t, func() bool { return s.Metrics().QueuedOrders == 1 })
+
+	// A query arriving now queues behind the waiting order rather than taking weight
+	s.mu.Lock()
+	ahead := s.queuedAheadLocked(priorityInfo)
+	s.mu.Unlock()
+	if ahead != 2 {
+		t.Errorf("%d requests ahead of a new query, want 2", ahead)
+	}
+
+	for _, want := range []requestPriority{priorityOrder, priorityInfo} {
+		select {
+		case got := <-granted:
+			if got != want {
+				t.Fatalf("granted priority %d, want %d", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for weight")
+		}
+	}
+}
+
+func TestWeightSchedulerCancelledWaiterLeavesQueue(t *testing.T) {
+	s := newWeightScheduler(60)
+	if err := s.acquire(context.Background(), priorityOrder, 60); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := s.acquire(ctx, priorityInfo, weightInfo); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("acquire returned %v, want the context's error", err)
+	}
+
+	metrics := s.Metrics()
+	if metrics.Cancelled != 1 || metrics.QueuedQueries != 0 || metrics.Requests != 1 {
+		t.Errorf("metrics %+v, want 1 cancelled and nothing queued", metrics)
+	}
+}
+
+func TestQueryCoalescerSharesConcurrentQueries(t *testing.T) {
+	c := newQueryCoalescer(time.Minute)
+	ctx := context.Background()
+
+	var calls int32
+	release := make(chan struct{})
+	query := func() (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "mids", nil
+	}
+
+	first := make(chan bool)
+	go func() {
+		_, shared, _ := c.do(ctx, "allMids", query)
+		first <- shared
+	}()
+	waitFor(t, func() bool { return atomic.LoadInt32(&calls) == 1 })
+
+	joined := make(chan bool)
+	go func() {
+		result, shared, err := c.do(ctx, "allMids", query)
+		joined <- shared && err == nil && result == "mids"
+	}()
+	close(release)
+
+	if <-first {
+		t.Error("the first caller's result was reported as shared")
+	}
+	if !<-joined {
+		t.Error("a concurrent caller did not share the running query")
+	}
+
+	if _, shared, _ := c.do(ctx, "allMids", query); !shared {
+		t.Error("a caller within the window did not share the result")
+	}
+	if _, shared, _ := c.do(ctx, "meta", query); shared {
+		t.Error("a query for another key was shared")
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("ran %d queries, want one per key", got)
+	}
+}
+
+func TestQueryCoalescerDoesNotShareExpiredOrFailedResults(t *testing.T) {
+	ctx := context.Background()
+	var calls int32
+	query := func() (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, nil
+	}
+
+	expiring := newQueryCoalescer(20 * time.Millisecond)
+	expiring.do(ctx, "allMids", query)
+	time.Sleep(30 * time.Millisecond)
+	if _, shared, _ := expiring.do(ctx, "allMids", query); shared || atomic.LoadInt32(&calls) != 2 {
+		t.Error("a result was shared after the window")
+	}
+
+	failing := newQueryCoalescer(time.Minute)
+	errUnavailable := errors.New("unavailable")
+	if _, _, err := failing.do(ctx, "allMids", func() (interface{}, error) { return nil, errUnavailable }); err != errUnavailable {
+		t.Fatalf("do returned %v, want the query's error", err)
+	}
+	if _, shared, err := failing.do(ctx, "allMids", query); shared || err != nil {
+		t.Errorf("a failed result was shared: shared %v, err %v", shared, err)
+	}
+}
+
+func TestQueryCoalescerForget(t *testing.T) {
+	ctx := context.Background()
+	var calls int32
+	query := func() (interface{}, error) {
+		return atomic.AddInt32(&calls, 1), nil
+	}
+
+	c := newQueryCoalescer(time.Minute)
+	c.do(ctx, "clearinghouseState", query)
+	c.forget("clearinghouseState")
+	if result, shared, _ := c.do(ctx, "clearinghouseState", query); shared || result != int32(2) {
+		t.Errorf("got result %v (shared %v) after forget, want a new query", result, shared)
+	}
+
+	// Forgotten while running: shared with the callers already waiting on it, but
+	// not with callers arriving after it completes
+	release := make(chan struct{})
+	running := make(chan interface{})
+	go func() {
+		result, _, _ := c.do(ctx, "openOrders", func() (interface{}, error) {
+			<-release
+			return query()
+		})
+		running <- result
+	}()
+	waitFor(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.calls["openOrders"] != nil
+	})
+
+	joined := make(chan bool)
+	go func() {
+		_, shared, _ := c.do(ctx, "openOrders", query)
+		joined <- shared
+	}()
+	// Give the second caller time to join the running query
+	time.Sleep(20 * time.Millisecond)
+	c.forget("openOrders")
+	close(release)
+
+	stale := <-running
+	if !<-joined {
+		t.Error("a caller waiting on the forgotten query did not share it")
+	}
+	if result, shared, _ := c.do(ctx, "openOrders", query); shared || result == stale {
+		t.Errorf("got result %v (shared %v) after the forgotten query completed, want a new query", result, shared)
+	}
+}
+
+// waitFor polls until condition holds
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
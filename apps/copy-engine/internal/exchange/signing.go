@@ -0,0 +1,156 @@
+package exchange
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/sha3"
+)
+
+// L1 actions are signed as an EIP-712 "Agent" message over a fixed domain. The
+// message commits to the action through its connectionId, the hash of the msgpack
+// encoded action, nonce and vault address.
+var (
+	eip712DomainTypeHash = keccak256([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"))
+	agentTypeHash        = keccak256([]byte("Agent(string source,bytes32 connectionId)"))
+	l1DomainSeparator    = keccak256(
+		eip712DomainTypeHash,
+		keccak256([]byte("Exchange")),
+		keccak256([]byte("1")),
+		leftPad32(big.NewInt(1337).Bytes()),
+		leftPad32(make([]byte, 20)),
+	)
+)
+
+// PrivateKeySigner signs L1 actions with a secp256k1 key, typically an agent
+// wallet approved by the trading account
+type PrivateKeySigner struct {
+	key     *secp256k1.PrivateKey
+	address string
+	mainnet bool
+}
+
+// NewPrivateKeySigner creates a signer from a hex encoded private key. Signatures
+// made for mainnet are rejected by testnet and the other way round.
+func NewPrivateKeySigner(hexKey string, mainnet bool) (*PrivateKeySigner, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(hexKey), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode private key: %w", err)
+	}
+	if len(raw) != 32 {
+		return nil, fmt.Errorf("private key must be 32 bytes, got %d", len(raw))
+	}
+
+	key := secp256k1.PrivKeyFromBytes(raw)
+	if key.Key.IsZero() {
+		return nil, fmt.Errorf("private key is not a valid secp256k1 scalar")
+	}
+
+	return &PrivateKeySigner{
+		key:     key,
+		address: publicKeyAddress(key.PubKey()),
+		mainnet: mainnet,
+	}, nil
+}
+
+// Address returns the lower case 0x address of the signing key
+func (s *PrivateKeySigner) Address() string {
+	return s.address
+}
+
+// SignAction signs an L1 action. The action must be built from wireMaps so its
+// msgpack encoding matches the exchange's; vaultAddress is set when trading for a
+// vault or sub-account.
+func (s *PrivateKeySigner) SignAction(action interface{}, nonce int64, vaultAddress *string) (*Signature, error) {
+	connectionID, err := actionHash(action, nonce, vaultAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	source := "b"
+	if s.mainnet {
+		source = "a"
+	}
+	message := keccak256(agentTypeHash, keccak256([]byte(source)), connectionID)
+	digest := keccak256([]byte{0x19, 0x01}, l1DomainSeparator, message)
+
+	// The compact form is <27 + recovery id><R><S>, which is Ethereum's v, r, s
+	compact := ecdsa.SignCompact(s.key, digest, false)
+
+	return &Signature{
+		R: "0x" + hex.EncodeToString(compact[1:33]),
+		S: "0x" + hex.EncodeToString(compact[33:65]),
+		V: int(compact[0]),
+	}, nil
+}
+
+// actionHash commits to the action, its nonce and the vault it trades for
+func actionHash(action interface{}, nonce int64, vaultAddress *string) ([]byte, error) {
+	data, err := packMsgpack(action)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode action: %w", err)
+	}
+
+	data = binary.BigEndian.AppendUint64(data, uint64(nonce))
+	if vaultAddress == nil {
+		data = append(data, 0x00)
+	} else {
+		vault, err := hex.DecodeString(strings.TrimPrefix(*vaultAddress, "0x"))
+		if err != nil || len(vault) != 20 {
+			return nil, fmt.Errorf("invalid vault address %q", *vaultAddress)
+		}
+		data = append(data, 0x01)
+		data = append(data, vault...)
+	}
+
+	return keccak256(data), nil
+}
+
+func publicKeyAddress(key *secp256k1.PublicKey) string {
+	// The address is the last 20 bytes of the hash of the uncompressed key without its 0x04 prefix
+	hash := keccak256(key.SerializeUncompressed()[1:])
+	return "0x" + hex.EncodeToString(hash[12:])
+}
+
+func keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+func leftPad32(b []byte) []byte {
+	padded := make([]byte, 32)
+	copy(padded[32-len(b):], b)
+	return padded
+}
+
+// NonceManager issues the nonces of /exchange requests. Hyperliquid expects nonces
+// close to the current time in milliseconds and rejects any a signer already used,
+// so nonces are millisecond timestamps bumped past the last one issued when two
+// requests land in the same millisecond. It is safe for concurrent use.
+type NonceManager struct {
+	last atomic.Int64
+}
+
+// Next returns a nonce greater than every nonce returned before
+func (n *NonceManager) Next() int64 {
+	for {
+		last := n.last.Load()
+		next := time.Now().UnixMilli()
+		if next <= last {
+			next = last + 1
+		}
+		if n.last.CompareAndSwap(last, next) {
+			return next
+		}
+	}
+}
@@ -0,0 +1,641 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SimulatedConfig configures the in-memory exchange
+type SimulatedConfig struct {
+	InitialBalance float64
+	SlippageBps    float64 // price impact applied to taker fills
+	TakerFeeBps    float64
+	MakerFeeBps    float64
+	MaxLeverage    float64
+}
+
+// DefaultSimulatedConfig returns a configuration resembling Hyperliquid's base tier
+func DefaultSimulatedConfig() SimulatedConfig {
+	return SimulatedConfig{
+		InitialBalance: 10000,
+		SlippageBps:    2,
+		TakerFeeBps:    4.5,
+		MakerFeeBps:    1.5,
+		MaxLeverage:    20,
+	}
+}
+
+// SimulatedAdapter is an in-memory Adapter used for tests and paper trading.
+// Prices are set directly or scripted as paths that advance with Step.
+type SimulatedAdapter struct {
+	config SimulatedConfig
+	now    func() time.Time
+
+	accounts   map[string]*simAccount
+	aliases    map[string]string
+	prices     map[string]float64
+	pricePaths map[string][]float64
+	liquidity  map[string]float64
+	book       map[string][]*simOrder
+	placed     map[string]*simPlacedOrder
+	nextID     int64
+	mu         sync.Mutex
+}
+
+type simAccount struct {
+	cash      float64
+	positions map[string]*simPosition
+	fills     []*Fill
+}
+
+type simPosition struct {
+	size       float64
+	entryPrice float64
+}
+
+type simOrder struct {
+	account string
+	order   *Order
+	reduce  bool
+}
+
+// simPlacedOrder remembers an order placed with a client order ID
+type simPlacedOrder struct {
+	orderID int64
+	size    float64
+}
+
+// NewSimulatedAdapter creates an in-memory exchange
+func NewSimulatedAdapter(cfg SimulatedConfig) *SimulatedAdapter {
+	if cfg.MaxLeverage <= 0 {
+		cfg.MaxLeverage = DefaultSimulatedConfig().MaxLeverage
+	}
+
+	return &SimulatedAdapter{
+		config:     cfg,
+		now:        time.Now,
+		accounts:   make(map[string]*simAccount),
+		aliases:    make(map[string]string),
+		prices:     make(map[string]float64),
+		pricePaths: make(map[string][]float64),
+		liquidity:  make(map[string]float64),
+		book:       make(map[string][]*simOrder),
+		placed:     make(map[string]*simPlacedOrder),
+	}
+}
+
+// SetClock overrides the time source used to timestamp fills and orders
+func (s *SimulatedAdapter) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// BindAccount makes accountID an alias of address so both share one account
+func (s *SimulatedAdapter) BindAccount(accountID, address string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.aliases[accountID] = address
+}
+
+// Deposit credits cash to an account, creating it if needed
+func (s *SimulatedAdapter) Deposit(account string, amount float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.account(account).cash += amount
+}
+
+// SetPosition overwrites an account's position, e.g. to script a trader being followed
+func (s *SimulatedAdapter) SetPosition(account, symbol string, size, entryPrice float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	acct := s.account(account)
+	if size == 0 {
+		delete(acct.positions, symbol)
+		return
+	}
+	acct.positions[symbol] = &simPosition{size: size, entryPrice: entryPrice}
+}
+
+// SetPrice sets the mid price of a symbol and matches resting orders against it
+func (s *SimulatedAdapter) SetPrice(symbol string, price float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prices[symbol] = price
+	s.matchBook(symbol)
+}
+
+// SetLiquidity caps the size a single taker order can fill on a symbol.
+// The remainder of a market or IOC order is cancelled, producing a partial fill.
+func (s *SimulatedAdapter) SetLiquidity(symbol string, maxFillSize float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.liquidity[symbol] = maxFillSize
+}
+
+// LoadPricePath queues prices that Step applies one at a time
+func (s *SimulatedAdapter) LoadPricePath(symbol string, path []float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pricePaths[symbol] = append(s.pricePaths[symbol], path...)
+}
+
+// Step advances every scripted price path by one tick.
+// It returns false once all paths are exhausted.
+func (s *SimulatedAdapter) Step() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	advanced := false
+	for symbol, path := range s.pricePaths {
+		if len(path) == 0 {
+			continue
+		}
+		s.prices[symbol] = path[0]
+		s.pricePaths[symbol] = path[1:]
+		s.matchBook(symbol)
+		advanced = true
+	}
+
+	return advanced
+}
+
+func (s *SimulatedAdapter) account(account string) *simAccount {
+	if alias, ok := s.aliases[account]; ok {
+		account = alias
+	}
+
+	acct, ok := s.accounts[account]
+	if !ok {
+		acct = &simAccount{
+			cash:      s.config.InitialBalance,
+			positions: make(map[string]*simPosition),
+		}
+		s.accounts[account] = acct
+	}
+	return acct
+}
+
+func (s *SimulatedAdapter) accountKey(account string) string {
+	if alias, ok := s.aliases[account]; ok {
+		return alias
+	}
+	return account
+}
+
+func (s *SimulatedAdapter) GetCurrentPositions(account string) (map[string]float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make(map[string]float64)
+	for symbol, position := range s.account(account).positions {
+		result[symbol] = position.size
+	}
+	return result, nil
+}
+
+func (s *SimulatedAdapter) GetPositions(ctx context.Context, account string) ([]*Position, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	acct := s.account(account)
+	equity := s.equity(acct)
+
+	positions := make([]*Position, 0, len(acct.positions))
+	for symbol, position := range acct.positions {
+		mark := s.markPrice(symbol, position)
+		value := math.Abs(position.size) * mark
+
+		leverage := 0.0
+		if equity > 0 {
+			leverage = value / equity
+		}
+
+		positions = append(positions, &Position{
+			Symbol:        symbol,
+			Size:          position.size,
+			EntryPrice:    position.entryPrice,
+			PositionValue: value,
+			UnrealizedPnL: position.size * (mark - position.entryPrice),
+			Leverage:      leverage,
+			MarginUsed:    value / s.config.MaxLeverage,
+		})
+	}
+
+	sort.Slice(positions, func(i, j int) bool { return positions[i].Symbol < positions[j].Symbol })
+	return positions, nil
+}
+
+func (s *SimulatedAdapter) GetOpenOrders(ctx context.Context, account string) ([]*Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := s.accountKey(account)
+	var orders []*Order
+	for _, resting := range s.book {
+		for _, o := range resting {
+			if o.account == key {
+				order := *o.order
+				orders = append(orders, &order)
+			}
+		}
+	}
+
+	sort.Slice(orders, func(i, j int) bool { return orders[i].OrderID < orders[j].OrderID })
+	return orders, nil
+}
+
+func (s *SimulatedAdapter) GetBalances(ctx context.Context, account string) (*Balance, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	acct := s.account(account)
+	notional := s.notional(acct)
+	equity := s.equity(acct)
+	marginUsed := notional / s.config.MaxLeverage
+
+	return &Balance{
+		AccountValue:    equity,
+		TotalNotional:   notional,
+		TotalMarginUsed: marginUsed,
+		Withdrawable:    math.Max(0, equity-marginUsed),
+	}, nil
+}
+
+func (s *SimulatedAdapter) GetMidPrices(ctx context.Context) (map[string]float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mids := make(map[string]float64, len(s.prices))
+	for symbol, price := range s.prices {
+		mids[symbol] = price
+	}
+	return mids, nil
+}
+
+func (s *SimulatedAdapter) GetFills(ctx context.Context, account string, since time.Time) ([]*Fill, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var fills []*Fill
+	for _, fill := range s.account(account).fills {
+		if !fill.Time.Before(since) {
+			f := *fill
+			fills = append(fills, &f)
+		}
+	}
+	return fills, nil
+}
+
+func (s *SimulatedAdapter) PlaceOrder(ctx context.Context, req *OrderRequest) (*OrderResult, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mid, ok := s.prices[req.Symbol]
+	if !ok || mid <= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSymbol, req.Symbol)
+	}
+
+	s.nextID++
+	orderID := s.nextID
+	acct := s.account(req.Account)
+	if req.ClientOrderID != "" {
+		s.placed[s.accountKey(req.Account)+":"+req.ClientOrderID] = &simPlacedOrder{orderID: orderID, size: req.Size}
+	}
+
+	size := req.Size
+	if req.ReduceOnly {
+		size = s.reducibleSize(acct, req.Symbol, req.Side, size)
+		if size <= 0 {
+			return &OrderResult{OrderID: orderID, Status: OrderStatusRejected, Error: "reduce only order would increase position"},
+				fmt.Errorf("%w: reduce only order would increase position", ErrInvalidOrder)
+		}
+	}
+
+	takerPrice := s.takerPrice(req.Side, mid)
+	limit := req.Price
+	tif := req.TimeInForce
+	if req.Type == OrderTypeMarket {
+		slippageBps := req.MaxSlippageBps
+		if slippageBps <= 0 {
+			slippageBps = defaultMarketSlippageBps
+		}
+		if req.Side == SideBuy {
+			limit = mid * (1 + slippageBps/10000)
+		} else {
+			limit = mid * (1 - slippageBps/10000)
+		}
+		tif = TimeInForceIOC
+	}
+
+	marketable := (req.Side == SideBuy && takerPrice <= limit) || (req.Side == SideSell && takerPrice >= limit)
+
+	if tif == TimeInForceALO && marketable {
+		return &OrderResult{OrderID: orderID, Status: OrderStatusRejected, Error: "post only order would cross"},
+			fmt.Errorf("%w: post only order would cross", ErrInvalidOrder)
+	}
+
+	if !marketable {
+		if tif == TimeInForceIOC {
+			return &OrderResult{OrderID: orderID, Status: OrderStatusRejected, Error: "order could not immediately match"},
+				fmt.Errorf("%w: order could not immediately match", ErrInvalidOrder)
+		}
+		s.book[req.Symbol] = append(s.book[req.Symbol], &simOrder{
+			account: s.accountKey(req.Account),
+			reduce:  req.ReduceOnly,
+			order: &Order{
+				OrderID:   orderID,
+				Symbol:    req.Symbol,
+				Side:      req.Side,
+				Price:     limit,
+				Size:      size,
+				Timestamp: s.now(),
+			},
+		})
+		return &OrderResult{OrderID: orderID, Status: OrderStatusResting}, nil
+	}
+
+	fillSize := size
+	if maxFill, ok := s.liquidity[req.Symbol]; ok && maxFill > 0 && fillSize > maxFill {
+		fillSize = maxFill
+	}
+
+	if err := s.checkMargin(acct, req.Symbol, req.Side, fillSize, takerPrice); err != nil {
+		return &OrderResult{OrderID: orderID, Status: OrderStatusRejected, Error: err.Error()}, err
+	}
+
+	s.applyFill(acct, orderID, req.Symbol, req.Side, fillSize, takerPrice, s.config.TakerFeeBps)
+
+	result := &OrderResult{
+		OrderID:    orderID,
+		Status:     OrderStatusFilled,
+		FilledSize: fillSize,
+		AvgPrice:   takerPrice,
+	}
+
+	if fillSize < size {
+		if tif == TimeInForceIOC {
+			result.Status = OrderStatusPartial
+		} else {
+			// The unfilled remainder of a GTC order rests at its limit
+			s.book[req.Symbol] = append(s.book[req.Symbol], &simOrder{
+				account: s.accountKey(req.Account),
+				reduce:  req.ReduceOnly,
+				order: &Order{
+					OrderID:   orderID,
+					Symbol:    req.Symbol,
+					Side:      req.Side,
+					Price:     limit,
+					Size:      size - fillSize,
+					Timestamp: s.now(),
+				},
+			})
+			result.Status = OrderStatusPartial
+		}
+	}
+
+	return result, nil
+}
+
+func (s *SimulatedAdapter) GetOrderStatus(ctx context.Context, account string, clientOrderID string) (*OrderResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	placed, ok := s.placed[s.accountKey(account)+":"+clientOrderID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrOrderNotFound, clientOrderID)
+	}
+
+	result := &OrderResult{OrderID: placed.orderID}
+	var notional float64
+	for _, fill := range s.account(account).fills {
+		if fill.OrderID == placed.orderID {
+			result.FilledSize += fill.Size
+			notional += fill.Size * fill.Price
+		}
+	}
+	if result.FilledSize > 0 {
+		result.AvgPrice = notional / result.FilledSize
+	}
+
+	resting := false
+	for _, orders := range s.book {
+		for _, o := range orders {
+			resting = resting || o.order.OrderID == placed.orderID
+		}
+	}
+
+	switch {
+	case result.FilledSize >= placed.size-1e-12:
+		result.Status = OrderStatusFilled
+	case result.FilledSize > 0:
+		result.Status = OrderStatusPartial
+	case resting:
+		result.Status = OrderStatusResting
+	default:
+		result.Status = OrderStatusRejected
+		result.Error = "order was cancelled without filling"
+	}
+
+	return result, nil
+}
+
+func (s *SimulatedAdapter) CancelOrder(ctx context.Context, account string, symbol string, orderID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := s.accountKey(account)
+	resting := s.book[symbol]
+	for i, o := range resting {
+		if o.order.OrderID == orderID && o.account == key {
+			s.book[symbol] = append(resting[:i], resting[i+1:]...)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %d", ErrOrderNotFound, orderID)
+}
+
+func (s *SimulatedAdapter) ModifyOrder(ctx context.Context, orderID int64, req *OrderRequest) (*OrderResult, error) {
+	if err := s.CancelOrder(ctx, req.Account, req.Symbol, orderID); err != nil {
+		return nil, err
+	}
+	return s.PlaceOrder(ctx, req)
+}
+
+// matchBook fills resting orders that the current mid price has crossed.
+// Callers must hold s.mu.
+func (s *SimulatedAdapter) matchBook(symbol string) {
+	mid, ok := s.prices[symbol]
+	if !ok {
+		return
+	}
+
+	var remaining []*simOrder
+	for _, o := range s.book[symbol] {
+		crossed := (o.order.Side == SideBuy && mid <= o.order.Price) ||
+			(o.order.Side == SideSell && mid >= o.order.Price)
+		if !crossed {
+			remaining = append(remaining, o)
+			continue
+		}
+
+		acct := s.account(o.account)
+		size := o.order.Size
+		if o.reduce {
+			size = s.reducibleSize(acct, symbol, o.order.Side, size)
+		}
+		if size <= 0 {
+			continue
+		}
+		if err := s.checkMargin(acct, symbol, o.order.Side, size, o.order.Price); err != nil {
+			// Mirror the exchange: orders that no longer have margin are cancelled
+			continue
+		}
+
+		s.applyFill(acct, o.order.OrderID, symbol, o.order.Side, size, o.order.Price, s.config.MakerFeeBps)
+	}
+
+	s.book[symbol] = remaining
+}
+
+func (s *SimulatedAdapter) takerPrice(side Side, mid float64) float64 {
+	if side == SideBuy {
+		return mid * (1 + s.config.SlippageBps/10000)
+	}
+	return mid * (1 - s.config.SlippageBps/10000)
+}
+
+func (s *SimulatedAdapter) reducibleSize(acct *simAccount, symbol string, side Side, size float64) float64 {
+	position, ok := acct.positions[symbol]
+	if !ok {
+		return 0
+	}
+	if (side == SideSell && position.size > 0) || (side == SideBuy && position.size < 0) {
+		return math.Min(size, math.Abs(position.size))
+	}
+	return 0
+}
+
+func (s *SimulatedAdapter) checkMargin(acct *simAccount, symbol string, side Side, size, price float64) error {
+	signed := size
+	if side == SideSell {
+		signed = -size
+	}
+
+	current := 0.0
+	if position, ok := acct.positions[symbol]; ok {
+		current = position.size
+	}
+
+	// Reducing exposure never requires additional margin
+	if math.Abs(current+signed) <= math.Abs(current) {
+		return nil
+	}
+
+	equity := s.equity(acct) - size*price*s.config.TakerFeeBps/10000
+	notional := s.notional(acct) - math.Abs(current)*price + math.Abs(current+signed)*price
+	if equity <= 0 || notional/equity > s.config.MaxLeverage {
+		return fmt.Errorf("%w: notional %.2f exceeds %.1fx of equity %.2f",
+			ErrInsufficientMargin, notional, s.config.MaxLeverage, equity)
+	}
+
+	return nil
+}
+
+func (s *SimulatedAdapter) applyFill(acct *simAccount, orderID int64, symbol string, side Side, size, price, feeBps float64) {
+	signed := size
+	if side == SideSell {
+		signed = -size
+	}
+
+	position, ok := acct.positions[symbol]
+	if !ok {
+		position = &simPosition{}
+		acct.positions[symbol] = position
+	}
+
+	startPosition := position.size
+	closedPnL := 0.0
+	direction := "Open Long"
+
+	switch {
+	case position.size == 0 || (position.size > 0) == (signed > 0):
+		// Opening or adding: average the entry price
+		newSize := position.size + signed
+		position.entryPrice = (position.entryPrice*math.Abs(position.size) + price*size) / math.Abs(newSize)
+		position.size = newSize
+		if signed < 0 {
+			direction = "Open Short"
+		}
+	default:
+		closed := math.Min(size, math.Abs(position.size))
+		if position.size > 0 {
+			closedPnL = closed * (price - position.entryPrice)
+			direction = "Close Long"
+		} else {
+			closedPnL = closed * (position.entryPrice - price)
+			direction = "Close Short"
+		}
+		position.size += signed
+		if math.Abs(position.size) < 1e-12 {
+			position.size = 0
+		} else if (startPosition > 0) != (position.size > 0) {
+			// Flipped through zero: the remainder opens at the fill price
+			position.entryPrice = price
+		}
+	}
+
+	if position.size == 0 {
+		delete(acct.positions, symbol)
+	}
+
+	fee := size * price * feeBps / 10000
+	acct.cash += closedPnL - fee
+
+	acct.fills = append(acct.fills, &Fill{
+		Symbol:        symbol,
+		Side:          side,
+		Price:         price,
+		Size:          size,
+		Fee:           fee,
+		ClosedPnL:     closedPnL,
+		StartPosition: startPosition,
+		Direction:     direction,
+		OrderID:       orderID,
+		TradeID:       int64(len(acct.fills) + 1),
+		Time:          s.now(),
+	})
+}
+
+func (s *SimulatedAdapter) markPrice(symbol string, position *simPosition) float64 {
+	if price, ok := s.prices[symbol]; ok {
+		return price
+	}
+	return position.entryPrice
+}
+
+func (s *SimulatedAdapter) equity(acct *simAccount) float64 {
+	equity := acct.cash
+	for symbol, position := range acct.positions {
+		equity += position.size * (s.markPrice(symbol, position) - position.entryPrice)
+	}
+	return equity
+}
+
+func (s *SimulatedAdapter) notional(acct *simAccount) float64 {
+	var notional float64
+	for symbol, position := range acct.positions {
+		notional += math.Abs(position.size) * s.markPrice(symbol, position)
+	}
+	return notional
+}
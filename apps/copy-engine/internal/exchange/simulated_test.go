@@ -0,0 +1,243 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+const simAccountID = "follower-1"
+
+// newTestSimulator quotes ETH at 2000 with 10 bps of taker slippage, so taker buys
+// fill at 2002 and taker sells at 1998
+func newTestSimulator() *SimulatedAdapter {
+	sim := NewSimulatedAdapter(SimulatedConfig{
+		InitialBalance: 10000,
+		SlippageBps:    10,
+		TakerFeeBps:    5,
+		MakerFeeBps:    2,
+		MaxLeverage:    5,
+	})
+	sim.SetClock(func() time.Time { return time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC) })
+	sim.SetPrice("ETH", 2000)
+	return sim
+}
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func positionSize(t *testing.T, sim *SimulatedAdapter, symbol string) float64 {
+	t.Helper()
+	positions, err := sim.GetCurrentPositions(simAccountID)
+	if err != nil {
+		t.Fatalf("GetCurrentPositions: %v", err)
+	}
+	return positions[symbol]
+}
+
+func TestSimulatedMarketOrder(t *testing.T) {
+	sim := newTestSimulator()
+	ctx := context.Background()
+
+	result, err := sim.PlaceOrder(ctx, &OrderRequest{Account: simAccountID, Symbol: "ETH", Side: SideBuy, Type: OrderTypeMarket, Size: 1})
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	if result.Status != OrderStatusFilled || result.FilledSize != 1 || !approxEqual(result.AvgPrice, 2002) {
+		t.Errorf("result %+v, want 1 ETH filled at 2002", *result)
+	}
+
+	balance, _ := sim.GetBalances(ctx, simAccountID)
+	// 1.001 of fees and 2 of unrealized loss against the mid
+	if !approxEqual(balance.AccountValue, 10000-1.001-2) || !approxEqual(balance.TotalNotional, 2000) {
+		t.Errorf("balance %+v, want 9996.999 of equity and 2000 notional", *balance)
+	}
+
+	// The simulator's slippage exceeds what this order allows
+	_, err = sim.PlaceOrder(ctx, &OrderRequest{Account: simAccountID, Symbol: "ETH", Side: SideSell, Type: OrderTypeMarket, Size: 1, MaxSlippageBps: 5})
+	if !errors.Is(err, ErrInvalidOrder) {
+		t.Errorf("market order beyond its slippage returned %v, want ErrInvalidOrder", err)
+	}
+
+	if _, err := sim.PlaceOrder(ctx, &OrderRequest{Account: simAccountID, Symbol: "DOGE", Side: SideBuy, Type: OrderTypeMarket, Size: 1}); !errors.Is(err, ErrUnknownSymbol) {
+		t.Errorf("order for an unquoted symbol returned %v, want ErrUnknownSymbol", err)
+	}
+}
+
+func TestSimulatedLimitOrder(t *testing.T) {
+	sim := newTestSimulator()
+	ctx := context.Background()
+
+	result, err := sim.PlaceOrder(ctx, &OrderRequest{Account: simAccountID, Symbol: "ETH", Side: SideBuy, Type: OrderTypeLimit, Size: 1, Price: 1990, ClientOrderID: "0x01"})
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	if result.Status != OrderStatusResting {
+		t.Fatalf("status %s, want resting below the mid", result.Status)
+	}
+	if orders, _ := sim.GetOpenOrders(ctx, simAccountID); len(orders) != 1 || orders[0].Price != 1990 {
+		t.Errorf("open orders %v, want the resting buy at 1990", orders)
+	}
+	if status, _ := sim.GetOrderStatus(ctx, simAccountID, "0x01"); status.Status != OrderStatusResting {
+		t.Errorf("looked up status %s, want resting", status.Status)
+	}
+
+	sim.SetPrice("ETH", 1995)
+	if size := positionSize(t, sim, "ETH"); size != 0 {
+		t.Fatalf("filled %v before the mid crossed the limit", size)
+	}
+
+	sim.SetPrice("ETH", 1985)
+	if size := positionSize(t, sim, "ETH"); size != 1 {
+		t.Fatalf("position %v after the mid crossed the limit, want 1", size)
+	}
+	fills, _ := sim.GetFills(ctx, simAccountID, time.Time{})
+	if len(fills) != 1 || fills[0].Price != 1990 || !approxEqual(fills[0].Fee, 1990*2/10000.0) {
+		t.Errorf("fills %+v, want one maker fill at the limit", fills)
+	}
+	if status, _ := sim.GetOrderStatus(ctx, simAccountID, "0x01"); status.Status != OrderStatusFilled || status.AvgPrice != 1990 {
+		t.Errorf("looked up %+v, want filled at 1990", *status)
+	}
+	if _, err := sim.GetOrderStatus(ctx, simAccountID, "0x02"); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("unknown client order ID returned %v, want ErrOrderNotFound", err)
+	}
+
+	// A marketable limit takes liquidity at the taker price, not its limit
+	result, err = sim.PlaceOrder(ctx, &OrderRequest{Account: simAccountID, Symbol: "ETH", Side: SideSell, Type: OrderTypeLimit, Size: 1, Price: 1900})
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	if result.Status != OrderStatusFilled || !approxEqual(result.AvgPrice, 1985*0.999) {
+		t.Errorf("result %+v, want filled at the taker price", *result)
+	}
+}
+
+func TestSimulatedTimeInForce(t *testing.T) {
+	tests := []struct {
+		name       string
+		tif        TimeInForce
+		price      float64
+		wantStatus OrderStatus
+		wantErr    error
+	}{
+		{"ioc marketable", TimeInForceIOC, 2010, OrderStatusFilled, nil},
+		{"ioc not marketable", TimeInForceIOC, 1990, OrderStatusRejected, ErrInvalidOrder},
+		{"alo resting", TimeInForceALO, 1990, OrderStatusResting, nil},
+		{"alo would cross", TimeInForceALO, 2010, OrderStatusRejected, ErrInvalidOrder},
+		{"gtc resting", TimeInForceGTC, 1990, OrderStatusResting, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim := newTestSimulator()
+			result, err := sim.PlaceOrder(context.Background(), &OrderRequest{
+				Account: simAccountID, Symbol: "ETH", Side: SideBuy, Type: OrderTypeLimit, Size: 1, Price: tt.price, TimeInForce: tt.tif,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PlaceOrder returned %v, want %v", err, tt.wantErr)
+			}
+			if result.Status != tt.wantStatus {
+				t.Errorf("status %s, want %s", result.Status, tt.wantStatus)
+			}
+			orders, _ := sim.GetOpenOrders(context.Background(), simAccountID)
+			if resting := len(orders) == 1; resting != (tt.wantStatus == OrderStatusResting) {
+				t.Errorf("%d open orders with status %s", len(orders), result.Status)
+			}
+		})
+	}
+}
+
+func TestSimulatedReduceOnly(t *testing.T) {
+	sim := newTestSimulator()
+	ctx := context.Background()
+
+	_, err := sim.PlaceOrder(ctx, &OrderRequest{Account: simAccountID, Symbol: "ETH", Side: SideSell, Type: OrderTypeMarket, Size: 1, ReduceOnly: true})
+	if !errors.Is(err, ErrInvalidOrder) {
+		t.Fatalf("reduce only order without a position returned %v, want ErrInvalidOrder", err)
+	}
+
+	sim.SetPosition(simAccountID, "ETH", 2, 1900)
+	_, err = sim.PlaceOrder(ctx, &OrderRequest{Account: simAccountID, Symbol: "ETH", Side: SideBuy, Type: OrderTypeMarket, Size: 1, ReduceOnly: true})
+	if !errors.Is(err, ErrInvalidOrder) {
+		t.Fatalf("reduce only buy against a long returned %v, want ErrInvalidOrder", err)
+	}
+
+	// Capped at the position rather than flipping it
+	result, err := sim.PlaceOrder(ctx, &OrderRequest{Account: simAccountID, Symbol: "ETH", Side: SideSell, Type: OrderTypeMarket, Size: 5, ReduceOnly: true})
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	if result.FilledSize != 2 || positionSize(t, sim, "ETH") != 0 {
+		t.Errorf("filled %v leaving %v, want the 2 ETH closed", result.FilledSize, positionSize(t, sim, "ETH"))
+	}
+
+	fills, _ := sim.GetFills(ctx, simAccountID, time.Time{})
+	if len(fills) != 1 || fills[0].Direction != "Close Long" || !approxEqual(fills[0].ClosedPnL, 2*(1998-1900)) {
+		t.Errorf("fills %+v, want one closing fill realizing 196", fills)
+	}
+}
+
+func TestSimulatedMargin(t *testing.T) {
+	sim := newTestSimulator()
+	ctx := context.Background()
+
+	// 30 ETH is about 60000 of notional, beyond 5x of 10000
+	_, err := sim.PlaceOrder(ctx, &OrderRequest{Account: simAccountID, Symbol: "ETH", Side: SideBuy, Type: OrderTypeMarket, Size: 30})
+	if !errors.Is(err, ErrInsufficientMargin) {
+		t.Fatalf("oversized order returned %v, want ErrInsufficientMargin", err)
+	}
+
+	if _, err := sim.PlaceOrder(ctx, &OrderRequest{Account: simAccountID, Symbol: "ETH", Side: SideBuy, Type: OrderTypeMarket, Size: 20}); err != nil {
+		t.Fatalf("order within the leverage limit: %v", err)
+	}
+
+	// After the price falls the account is over its limit, but reducing stays allowed
+	sim.SetPrice("ETH", 1800)
+	if _, err := sim.PlaceOrder(ctx, &OrderRequest{Account: simAccountID, Symbol: "ETH", Side: SideBuy, Type: OrderTypeMarket, Size: 1}); !errors.Is(err, ErrInsufficientMargin) {
+		t.Errorf("adding to an overleveraged position returned %v, want ErrInsufficientMargin", err)
+	}
+	if _, err := sim.PlaceOrder(ctx, &OrderRequest{Account: simAccountID, Symbol: "ETH", Side: SideSell, Type: OrderTypeMarket, Size: 5}); err != nil {
+		t.Errorf("reducing an overleveraged position: %v", err)
+	}
+}
+
+func TestSimulatedPartialFills(t *testing.T) {
+	sim := newTestSimulator()
+	sim.SetLiquidity("ETH", 0.5)
+	ctx := context.Background()
+
+	result, err := sim.PlaceOrder(ctx, &OrderRequest{Account: simAccountID, Symbol: "ETH", Side: SideBuy, Type: OrderTypeMarket, Size: 2})
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	if result.Status != OrderStatusPartial || result.FilledSize != 0.5 {
+		t.Errorf("market result %+v, want 0.5 of 2 filled", *result)
+	}
+	if orders, _ := sim.GetOpenOrders(ctx, simAccountID); len(orders) != 0 {
+		t.Errorf("the remainder of a market order rests: %v", orders)
+	}
+
+	// The remainder of a GTC order rests at its limit and fills once crossed
+	result, err = sim.PlaceOrder(ctx, &OrderRequest{Account: simAccountID, Symbol: "ETH", Side: SideBuy, Type: OrderTypeLimit, Size: 2, Price: 2005, ClientOrderID: "0x01"})
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	if result.Status != OrderStatusPartial || result.FilledSize != 0.5 {
+		t.Errorf("limit result %+v, want 0.5 of 2 filled", *result)
+	}
+	if status, _ := sim.GetOrderStatus(ctx, simAccountID, "0x01"); status.Status != OrderStatusPartial || status.FilledSize != 0.5 {
+		t.Errorf("looked up %+v, want partially filled", *status)
+	}
+
+	sim.SetPrice("ETH", 2004)
+	status, _ := sim.GetOrderStatus(ctx, simAccountID, "0x01")
+	if status.Status != OrderStatusFilled || status.FilledSize != 2 || !approxEqual(status.AvgPrice, (0.5*2002+1.5*2005)/2) {
+		t.Errorf("looked up %+v, want 2 filled across both prices", *status)
+	}
+	if size := positionSize(t, sim, "ETH"); size != 2.5 {
+		t.Errorf("position %v, want 2.5", size)
+	}
+}
@@ -0,0 +1,382 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/hyperdash/copy-engine/internal/config"
+)
+
+const (
+	// Hyperliquid closes connections that send nothing for a minute
+	streamPingInterval = 30 * time.Second
+	// streamReadTimeout bounds the silence tolerated before the connection is
+	// considered dead; pings are answered, so a live connection is never this quiet
+	streamReadTimeout  = 90 * time.Second
+	streamWriteTimeout = 10 * time.Second
+
+	minStreamBackoff = time.Second
+	maxStreamBackoff = 30 * time.Second
+)
+
+// StreamHandler receives the account events delivered by a HyperliquidStream.
+// Handlers are called from the stream's read loop, so a slow handler holds back
+// the connection rather than losing events.
+type StreamHandler interface {
+	// OnFills receives new fills of a subscribed address, oldest first
+	OnFills(address string, fills []*Fill)
+	// OnOrderUpdates receives order status changes. Hyperliquid does not name the
+	// user on orderUpdates messages, so updates of every subscribed address arrive
+	// here unattributed.
+	OnOrderUpdates(updates []*OrderUpdate)
+}
+
+// OrderUpdate is a status change of an order, as reported by the orderUpdates
+// subscription
+type OrderUpdate struct {
+	Order         Order     `json:"order"`
+	OriginalSize  float64   `json:"original_size"`
+	ClientOrderID string    `json:"client_order_id,omitempty"`
+	Status        string    `json:"status"` // open, filled, canceled, rejected, ...
+	StatusTime    time.Time `json:"status_time"`
+}
+
+// HyperliquidStream keeps userFills and orderUpdates subscriptions open for a set
+// of addresses over the Hyperliquid WebSocket API, reconnecting with backoff and
+// resubscribing whenever the connection drops
+type HyperliquidStream struct {
+	url    string
+	dialer *websocket.Dialer
+
+	// users maps each subscribed address (lower case) to the time of the latest
+	// fill delivered for it; zero until its first snapshot arrived
+	users map[string]time.Time
+	conn  *websocket.Conn
+	mu    sync.Mutex
+
+	writeMu sync.Mutex // gorilla connections allow a single concurrent writer
+}
+
+// NewHyperliquidStream creates a stream for the configured network. WSURL overrides
+// the endpoint derived from BaseURL, e.g. to point at a local stand-in.
+func NewHyperliquidStream(cfg config.HyperliquidConfig) *HyperliquidStream {
+	url := cfg.WSURL
+	if url == "" {
+		url = resolveBaseURL(cfg) + "/ws"
+		url = strings.Replace(url, "https://", "wss://", 1)
+		url = strings.Replace(url, "http://", "ws://", 1)
+	}
+
+	return &HyperliquidStream{
+		url:    url,
+		dialer: &websocket.Dialer{HandshakeTimeout: 10 * time.Second},
+		users:  make(map[string]time.Time),
+	}
+}
+
+// SetAddresses replaces the subscribed addresses. Addresses that are new are
+// subscribed and dropped ones unsubscribed straight away when connected; the full
+// set is subscribed again on every reconnect.
+func (s *HyperliquidStream) SetAddresses(addresses []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wanted := make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		wanted[strings.ToLower(address)] = true
+	}
+
+	var added, removed []string
+	for address := range wanted {
+		if _, ok := s.users[address]; !ok {
+			s.users[address] = time.Time{}
+			added = append(added, address)
+		}
+	}
+	for address := range s.users {
+		if !wanted[address] {
+			delete(s.users, address)
+			removed = append(removed, address)
+		}
+	}
+
+	if s.conn == nil {
+		return
+	}
+
+	for _, address := range removed {
+		if err := s.subscribe(s.conn, "unsubscribe", address); err != nil {
+			log.Printf("Warning: failed to unsubscribe %s from Hyperliquid stream: %v", address, err)
+			s.conn.Close()
+			return
+		}
+	}
+	for _, address := range added {
+		if err := s.subscribe(s.conn, "subscribe", address); err != nil {
+			// Closing the connection makes Run reconnect and subscribe everything
+			log.Printf("Warning: failed to subscribe %s to Hyperliquid stream: %v", address, err)
+			s.conn.Close()
+			return
+		}
+	}
+}
+
+// Run connects and delivers events to handler until ctx is done, reconnecting
+// with jittered exponential backoff. Fills missed while disconnected are recovered
+// from the snapshot sent on resubscription, so handlers may see a fill twice
+// around a reconnect and should deduplicate on its trade ID.
+func (s *HyperliquidStream) Run(ctx context.Context, handler StreamHandler) error {
+	backoff := minStreamBackoff
+
+	for {
+		received, err := s.session(ctx, handler)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if received {
+			backoff = minStreamBackoff
+		}
+
+		// Equal jitter keeps a fleet of engines from reconnecting in lockstep
+		wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		log.Printf("Hyperliquid stream disconnected: %v; reconnecting in %s", err, wait)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+
+		backoff *= 2
+		if backoff > maxStreamBackoff {
+			backoff = maxStreamBackoff
+		}
+	}
+}
+
+// session runs one connection until it fails and reports whether any message was
+// received on it
+func (s *HyperliquidStream) session(ctx context.Context, handler StreamHandler) (bool, error) {
+	conn, _, err := s.dialer.DialContext(ctx, s.url, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to %s: %w", s.url, err)
+	}
+	defer conn.Close()
+
+	if err := s.attach(conn); err != nil {
+		return false, err
+	}
+	defer s.detach(conn)
+
+	done := make(chan struct{})
+	defer close(done)
+	go s.keepAlive(ctx, conn, done)
+
+	received := false
+	for {
+		conn.SetReadDeadline(time.Now().Add(streamReadTimeout))
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return received, fmt.Errorf("failed to read from stream: %w", err)
+		}
+		received = true
+
+		if err := s.dispatch(data, handler); err != nil {
+			log.Printf("Warning: ignoring Hyperliquid stream message: %v", err)
+		}
+	}
+}
+
+// attach makes conn the live connection and subscribes every address on it
+func (s *HyperliquidStream) attach(conn *websocket.Conn) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for address := range s.users {
+		if err := s.subscribe(conn, "subscribe", address); err != nil {
+			return fmt.Errorf("failed to subscribe %s: %w", address, err)
+		}
+	}
+	s.conn = conn
+
+	return nil
+}
+
+func (s *HyperliquidStream) detach(conn *websocket.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == conn {
+		s.conn = nil
+	}
+}
+
+// keepAlive pings the server and closes the connection when ctx ends, which
+// unblocks the read loop
+func (s *HyperliquidStream) keepAlive(ctx context.Context, conn *websocket.Conn, done <-chan struct{}) {
+	ticker := time.NewTicker(streamPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+			conn.Close()
+			return
+		case <-ticker.C:
+			if err := s.write(conn, map[string]string{"method": "ping"}); err != nil {
+				conn.Close()
+				return
+			}
+		}
+	}
+}
+
+func (s *HyperliquidStream) subscribe(conn *websocket.Conn, method, address string) error {
+	for _, channel := range []string{"userFills", "orderUpdates"} {
+		err := s.write(conn, map[string]interface{}{
+			"method": method,
+			"subscription": map[string]string{
+				"type": channel,
+				"user": address,
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *HyperliquidStream) write(conn *websocket.Conn, message interface{}) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	return conn.WriteJSON(message)
+}
+
+type hlStreamMessage struct {
+	Channel string          `json:"channel"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type hlUserFills struct {
+	IsSnapshot bool     `json:"isSnapshot"`
+	User       string   `json:"user"`
+	Fills      []hlFill `json:"fills"`
+}
+
+type hlOrderUpdate struct {
+	Order struct {
+		Coin      string  `json:"coin"`
+		Side      string  `json:"side"`
+		LimitPx   string  `json:"limitPx"`
+		Sz        string  `json:"sz"`
+		Oid       int64   `json:"oid"`
+		Timestamp int64   `json:"timestamp"`
+		OrigSz    string  `json:"origSz"`
+		Cloid     *string `json:"cloid"`
+	} `json:"order"`
+	Status          string `json:"status"`
+	StatusTimestamp int64  `json:"statusTimestamp"`
+}
+
+func (s *HyperliquidStream) dispatch(data []byte, handler StreamHandler) error {
+	var message hlStreamMessage
+	if err := json.Unmarshal(data, &message); err != nil {
+		return fmt.Errorf("failed to decode message: %w", err)
+	}
+
+	switch message.Channel {
+	case "userFills":
+		var fills hlUserFills
+		if err := json.Unmarshal(message.Data, &fills); err != nil {
+			return fmt.Errorf("failed to decode fills: %w", err)
+		}
+		address := strings.ToLower(fills.User)
+		if delivered := s.admitFills(address, &fills); len(delivered) > 0 {
+			handler.OnFills(address, delivered)
+		}
+	case "orderUpdates":
+		var raw []hlOrderUpdate
+		if err := json.Unmarshal(message.Data, &raw); err != nil {
+			return fmt.Errorf("failed to decode order updates: %w", err)
+		}
+		updates := make([]*OrderUpdate, 0, len(raw))
+		for _, u := range raw {
+			update := &OrderUpdate{
+				Order: Order{
+					OrderID:   u.Order.Oid,
+					Symbol:    u.Order.Coin,
+					Side:      parseSide(u.Order.Side),
+					Price:     parseFloat(u.Order.LimitPx),
+					Size:      parseFloat(u.Order.Sz),
+					Timestamp: time.UnixMilli(u.Order.Timestamp),
+				},
+				OriginalSize: parseFloat(u.Order.OrigSz),
+				Status:       u.Status,
+				StatusTime:   time.UnixMilli(u.StatusTimestamp),
+			}
+			if u.Order.Cloid != nil {
+				update.ClientOrderID = *u.Order.Cloid
+			}
+			updates = append(updates, update)
+		}
+		if len(updates) > 0 {
+			handler.OnOrderUpdates(updates)
+		}
+	case "error":
+		return fmt.Errorf("stream error: %s", string(message.Data))
+	}
+
+	// subscriptionResponse and pong need no handling
+	return nil
+}
+
+// admitFills returns the fills of a message to deliver and advances the address's
+// watermark. The first snapshot of an address is history and only sets the
+// watermark; later snapshots follow a reconnect and deliver the fills at or after
+// it. Fills of addresses no longer subscribed are dropped.
+func (s *HyperliquidStream) admitFills(address string, message *hlUserFills) []*Fill {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	watermark, ok := s.users[address]
+	if !ok {
+		return nil
+	}
+
+	latest := watermark
+	var fills []*Fill
+	for i := range message.Fills {
+		fill := message.Fills[i].fill()
+		if fill.Time.After(latest) {
+			latest = fill.Time
+		}
+		if message.IsSnapshot && (watermark.IsZero() || fill.Time.Before(watermark)) {
+			continue
+		}
+		fills = append(fills, fill)
+	}
+
+	if message.IsSnapshot && latest.IsZero() {
+		// An address without fills still needs a watermark so the next snapshot is
+		// not mistaken for its first
+		latest = time.Now()
+	}
+	s.users[address] = latest
+
+	// Hyperliquid sends snapshots newest first
+	sort.SliceStable(fills, func(i, j int) bool { return fills[i].Time.Before(fills[j].Time) })
+	return fills
+}
@@ -0,0 +1,147 @@
+package exchange
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperdash/copy-engine/internal/config"
+	"github.com/hyperdash/copy-engine/internal/hyperliquidtest"
+)
+
+const streamTestUser = "0x00000000000000000000000000000000000000aa"
+
+// recordingHandler keeps the trade IDs of delivered fills
+type recordingHandler struct {
+	mu    sync.Mutex
+	fills []int64
+}
+
+func (h *recordingHandler) OnFills(address string, fills []*Fill) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, fill := range fills {
+		h.fills = append(h.fills, fill.TradeID)
+	}
+}
+
+func (h *recordingHandler) OnOrderUpdates(updates []*OrderUpdate) {}
+
+func (h *recordingHandler) delivered() []int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]int64(nil), h.fills...)
+}
+
+func (h *recordingHandler) has(tradeID int64) bool {
+	for _, id := range h.delivered() {
+		if id == tradeID {
+			return true
+		}
+	}
+	return false
+}
+
+// eventually polls until the condition holds
+func eventually(t *testing.T, timeout time.Duration, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func startTestStream(t *testing.T, server *hyperliquidtest.Server, addresses ...string) (*HyperliquidStream, *recordingHandler) {
+	t.Helper()
+	stream := NewHyperliquidStream(config.HyperliquidConfig{WSURL: server.URL()})
+	stream.SetAddresses(addresses)
+
+	handler := &recordingHandler{}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		stream.Run(ctx, handler)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return stream, handler
+}
+
+func TestStreamSkipsHistoryAndDeliversLiveFills(t *testing.T) {
+	server := hyperliquidtest.NewServer()
+	defer server.Close()
+
+	base := time.Now().Add(-time.Minute).Truncate(time.Millisecond)
+	server.AddFills(streamTestUser, hyperliquidtest.Fill{Coin: "ETH", Side: "B", Time: base, TradeID: 1})
+
+	_, handler := startTestStream(t, server, streamTestUser)
+	eventually(t, 2*time.Second, "the subscription", func() bool { return server.Subscribed(streamTestUser) })
+
+	server.AddFills(streamTestUser, hyperliquidtest.Fill{Coin: "ETH", Side: "A", Price: 3200.5, Size: 0.25, Time: base.Add(time.Second), TradeID: 2})
+	eventually(t, 2*time.Second, "the live fill", func() bool { return handler.has(2) })
+
+	if delivered := handler.delivered(); len(delivered) != 1 {
+		t.Errorf("delivered %v, want only the live fill 2", delivered)
+	}
+}
+
+func TestStreamResubscribesAndRecoversFillsAfterReconnect(t *testing.T) {
+	server := hyperliquidtest.NewServer()
+	defer server.Close()
+
+	_, handler := startTestStream(t, server, streamTestUser)
+	eventually(t, 2*time.Second, "the subscription", func() bool { return server.Subscribed(streamTestUser) })
+
+	// The empty first snapshot sets the watermark to the time it arrived, so fills
+	// must be later to count as new
+	base := time.Now().Add(time.Second).Truncate(time.Millisecond)
+
+	server.AddFills(streamTestUser, hyperliquidtest.Fill{Coin: "ETH", Side: "B", Time: base, TradeID: 1})
+	eventually(t, 2*time.Second, "the live fill", func() bool { return handler.has(1) })
+
+	// The stream waits at least half a second before reconnecting, so this fill is
+	// recorded while nobody is subscribed and only reaches it in the next snapshot
+	server.DropConnections()
+	server.AddFills(streamTestUser, hyperliquidtest.Fill{Coin: "BTC", Side: "A", Time: base.Add(time.Second), TradeID: 2})
+
+	eventually(t, 3*time.Second, "the resubscription", func() bool { return server.Subscribed(streamTestUser) })
+	eventually(t, 2*time.Second, "the fill missed while disconnected", func() bool { return handler.has(2) })
+
+	// The snapshot may repeat fill 1, which sits on the watermark, but must put the
+	// missed fill after it
+	delivered := handler.delivered()
+	if delivered[0] != 1 || delivered[len(delivered)-1] != 2 {
+		t.Errorf("delivered %v, want fill 1 first and the recovered fill 2 last", delivered)
+	}
+}
+
+func TestStreamSubscribesAddressesAddedWhileConnected(t *testing.T) {
+	server := hyperliquidtest.NewServer()
+	defer server.Close()
+
+	const other = "0x00000000000000000000000000000000000000BB"
+	stream, handler := startTestStream(t, server, streamTestUser)
+	eventually(t, 2*time.Second, "the subscription", func() bool { return server.Subscribed(streamTestUser) })
+
+	stream.SetAddresses([]string{other})
+	eventually(t, time.Second, "the new address to be subscribed", func() bool {
+		return server.Subscribed(other) && !server.Subscribed(streamTestUser)
+	})
+
+	// Wait out the first snapshot of the new address, which only sets its watermark
+	time.Sleep(100 * time.Millisecond)
+	server.AddFills(other, hyperliquidtest.Fill{Coin: "SOL", Side: "B", Time: time.Now(), TradeID: 7})
+	server.AddFills(streamTestUser, hyperliquidtest.Fill{Coin: "SOL", Side: "B", Time: time.Now(), TradeID: 8})
+	eventually(t, 2*time.Second, "the new address's fill", func() bool { return handler.has(7) })
+
+	if handler.has(8) {
+		t.Errorf("delivered a fill of the dropped address")
+	}
+}
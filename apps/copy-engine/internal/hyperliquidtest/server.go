@@ -0,0 +1,209 @@
+// Package hyperliquidtest provides a local stand-in for the Hyperliquid WebSocket
+// API so the copy engine's fill stream can be exercised without the exchange.
+package hyperliquidtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Fill is a fill as the stand-in reports it. Unset prices and sizes default to 1.
+type Fill struct {
+	Coin    string
+	Side    string // "B" or "A"
+	Price   float64
+	Size    float64
+	Time    time.Time
+	TradeID int64
+	Hash    string
+}
+
+// Server accepts WebSocket connections, tracks their userFills subscriptions and
+// answers each subscription with a snapshot of the user's fills, like Hyperliquid
+type Server struct {
+	server   *httptest.Server
+	upgrader websocket.Upgrader
+
+	fills       map[string][]Fill // history per user, used for snapshots
+	connections map[*connection]bool
+	mu          sync.Mutex
+}
+
+type connection struct {
+	conn          *websocket.Conn
+	subscriptions map[string]bool // users subscribed to userFills
+	writeMu       sync.Mutex
+}
+
+// NewServer starts a stand-in server
+func NewServer() *Server {
+	s := &Server{
+		fills:       make(map[string][]Fill),
+		connections: make(map[*connection]bool),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// URL returns the ws:// endpoint to configure as HYPERLIQUID_WS_URL
+func (s *Server) URL() string {
+	return "ws" + strings.TrimPrefix(s.server.URL, "http")
+}
+
+// Close drops every connection and stops the server
+func (s *Server) Close() {
+	s.DropConnections()
+	s.server.Close()
+}
+
+// Subscribed reports whether any connection is subscribed to the user's fills
+func (s *Server) Subscribed(user string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for c := range s.connections {
+		if c.subscriptions[strings.ToLower(user)] {
+			return true
+		}
+	}
+	return false
+}
+
+// AddFills records fills in the user's history and pushes them to subscribers.
+// Fills recorded while nobody is connected reach the client in the snapshot of
+// its next subscription.
+func (s *Server) AddFills(user string, fills ...Fill) {
+	user = strings.ToLower(user)
+
+	s.mu.Lock()
+	s.fills[user] = append(s.fills[user], fills...)
+	var subscribers []*connection
+	for c := range s.connections {
+		if c.subscriptions[user] {
+			subscribers = append(subscribers, c)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, c := range subscribers {
+		c.send(userFillsMessage(user, fills, false))
+	}
+}
+
+// DropConnections closes every open connection, as the exchange does on restarts
+func (s *Server) DropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for c := range s.connections {
+		c.conn.Close()
+		delete(s.connections, c)
+	}
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	c := &connection{conn: conn, subscriptions: make(map[string]bool)}
+	s.mu.Lock()
+	s.connections[c] = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.connections, c)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	for {
+		var request struct {
+			Method       string `json:"method"`
+			Subscription struct {
+				Type string `json:"type"`
+				User string `json:"user"`
+			} `json:"subscription"`
+		}
+		if err := conn.ReadJSON(&request); err != nil {
+			return
+		}
+
+		switch request.Method {
+		case "ping":
+			c.send(map[string]string{"channel": "pong"})
+		case "subscribe", "unsubscribe":
+			user := strings.ToLower(request.Subscription.User)
+			c.send(map[string]interface{}{"channel": "subscriptionResponse", "data": request})
+			if request.Subscription.Type != "userFills" {
+				continue
+			}
+
+			s.mu.Lock()
+			c.subscriptions[user] = request.Method == "subscribe"
+			history := append([]Fill(nil), s.fills[user]...)
+			s.mu.Unlock()
+
+			if request.Method == "subscribe" {
+				c.send(userFillsMessage(user, history, true))
+			}
+		}
+	}
+}
+
+func (c *connection) send(message interface{}) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.conn.WriteJSON(message)
+}
+
+// userFillsMessage renders fills in the exchange's wire format. Snapshots list the
+// newest fill first.
+func userFillsMessage(user string, fills []Fill, snapshot bool) map[string]interface{} {
+	wire := make([]map[string]interface{}, 0, len(fills))
+	for _, fill := range fills {
+		price, size := fill.Price, fill.Size
+		if price == 0 {
+			price = 1
+		}
+		if size == 0 {
+			size = 1
+		}
+		wire = append(wire, map[string]interface{}{
+			"coin":          fill.Coin,
+			"px":            strconv.FormatFloat(price, 'f', -1, 64),
+			"sz":            strconv.FormatFloat(size, 'f', -1, 64),
+			"side":          fill.Side,
+			"time":          fill.Time.UnixMilli(),
+			"startPosition": "0",
+			"dir":           "Open Long",
+			"closedPnl":     "0",
+			"hash":          fill.Hash,
+			"oid":           fill.TradeID,
+			"fee":           "0",
+			"tid":           fill.TradeID,
+		})
+	}
+	if snapshot {
+		for i, j := 0, len(wire)-1; i < j; i, j = i+1, j-1 {
+			wire[i], wire[j] = wire[j], wire[i]
+		}
+	}
+
+	data, _ := json.Marshal(map[string]interface{}{
+		"isSnapshot": snapshot,
+		"user":       user,
+		"fills":      wire,
+	})
+	return map[string]interface{}{"channel": "userFills", "data": json.RawMessage(data)}
+}
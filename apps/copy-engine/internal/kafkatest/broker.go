@@ -0,0 +1,241 @@
+// Package kafkatest provides an in-process stand-in for a Kafka cluster so the
+// copy engine's Kafka ingestion and event publishing can be exercised without a
+// broker.
+package kafkatest
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Broker holds topics in memory together with the offsets committed by each
+// consumer group. Readers created from it behave like a consumer group with a
+// single member that owns every partition.
+type Broker struct {
+	topics    map[string][][]kafka.Message
+	committed map[groupPartition]int64
+	notify    chan struct{} // closed and replaced whenever a message is produced
+	mu        sync.Mutex
+}
+
+type groupPartition struct {
+	group     string
+	topic     string
+	partition int
+}
+
+// NewBroker creates an empty broker
+func NewBroker() *Broker {
+	return &Broker{
+		topics:    make(map[string][][]kafka.Message),
+		committed: make(map[groupPartition]int64),
+		notify:    make(chan struct{}),
+	}
+}
+
+// CreateTopic adds a topic with the given number of partitions
+func (b *Broker) CreateTopic(topic string, partitions int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, exists := b.topics[topic]; exists {
+		return fmt.Errorf("topic %s already exists", topic)
+	}
+	if partitions <= 0 {
+		return fmt.Errorf("topic %s needs at least one partition", topic)
+	}
+
+	b.topics[topic] = make([][]kafka.Message, partitions)
+	return nil
+}
+
+// Produce appends a message to the partition its key hashes to, like the default
+// partitioner of the platform's producers, and returns where it was written
+func (b *Broker) Produce(topic string, key, value []byte) (partition int, offset int64, err error) {
+	return b.produce(topic, kafka.Message{Key: key, Value: value})
+}
+
+func (b *Broker) produce(topic string, message kafka.Message) (partition int, offset int64, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	partitions, exists := b.topics[topic]
+	if !exists {
+		return 0, 0, fmt.Errorf("unknown topic %s", topic)
+	}
+
+	h := fnv.New32a()
+	h.Write(message.Key)
+	partition = int(h.Sum32() % uint32(len(partitions)))
+	offset = int64(len(partitions[partition]))
+
+	message.Topic = topic
+	message.Partition = partition
+	message.Offset = offset
+	if message.Time.IsZero() {
+		message.Time = time.Now()
+	}
+	partitions[partition] = append(partitions[partition], message)
+
+	close(b.notify)
+	b.notify = make(chan struct{})
+
+	return partition, offset, nil
+}
+
+// Committed returns the next offset the group will read from a partition, or -1
+// if the group never committed it
+func (b *Broker) Committed(group, topic string, partition int) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	offset, ok := b.committed[groupPartition{group: group, topic: topic, partition: partition}]
+	if !ok {
+		return -1
+	}
+	return offset
+}
+
+// NewReader joins a consumer group on a topic. The reader starts at the group's
+// committed offsets, or at the beginning of partitions it never committed, so a
+// new reader after Close observes exactly what a restarted service would.
+func (b *Broker) NewReader(group, topic string) (*Reader, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	partitions, exists := b.topics[topic]
+	if !exists {
+		return nil, fmt.Errorf("unknown topic %s", topic)
+	}
+
+	positions := make([]int64, len(partitions))
+	for partition := range partitions {
+		if offset, ok := b.committed[groupPartition{group: group, topic: topic, partition: partition}]; ok {
+			positions[partition] = offset
+		}
+	}
+
+	return &Reader{
+		broker:    b,
+		group:     group,
+		topic:     topic,
+		positions: positions,
+		closed:    make(chan struct{}),
+	}, nil
+}
+
+// Reader reads a topic for a consumer group. It has the FetchMessage,
+// CommitMessages and Close methods of *kafka.Reader.
+type Reader struct {
+	broker    *Broker
+	group     string
+	topic     string
+	positions []int64 // next offset to fetch per partition
+	next      int     // partition to look at first, for round-robin fetching
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// FetchMessage returns the next message, blocking until one is produced, the
+// context ends or the reader is closed. It does not commit the message.
+func (r *Reader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	for {
+		r.broker.mu.Lock()
+		partitions := r.broker.topics[r.topic]
+		for i := range partitions {
+			partition := (r.next + i) % len(partitions)
+			if r.positions[partition] < int64(len(partitions[partition])) {
+				message := partitions[partition][r.positions[partition]]
+				r.positions[partition]++
+				r.next = (partition + 1) % len(partitions)
+				r.broker.mu.Unlock()
+				return message, nil
+			}
+		}
+		notify := r.broker.notify
+		r.broker.mu.Unlock()
+
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return kafka.Message{}, ctx.Err()
+		case <-r.closed:
+			return kafka.Message{}, fmt.Errorf("reader closed")
+		}
+	}
+}
+
+// CommitMessages commits the offset after each message for its partition. Like a
+// real group commit, a lower offset than the one already committed moves it back.
+func (r *Reader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	select {
+	case <-r.closed:
+		return fmt.Errorf("reader closed")
+	default:
+	}
+
+	r.broker.mu.Lock()
+	defer r.broker.mu.Unlock()
+
+	for _, message := range msgs {
+		if message.Topic != r.topic {
+			return fmt.Errorf("message from topic %s committed on a reader of %s", message.Topic, r.topic)
+		}
+		key := groupPartition{group: r.group, topic: message.Topic, partition: message.Partition}
+		r.broker.committed[key] = message.Offset + 1
+	}
+
+	return nil
+}
+
+// Close leaves the group. Uncommitted messages are read again by the next reader.
+func (r *Reader) Close() error {
+	r.closeOnce.Do(func() { close(r.closed) })
+	return nil
+}
+
+// NewWriter creates a producer for a topic
+func (b *Broker) NewWriter(topic string) *Writer {
+	return &Writer{broker: b, topic: topic}
+}
+
+// Writer produces to a topic. It has the WriteMessages and Close methods of
+// *kafka.Writer.
+type Writer struct {
+	broker *Broker
+	topic  string
+	closed bool
+	mu     sync.Mutex
+}
+
+// WriteMessages appends the messages in order, keeping their headers and time
+func (w *Writer) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return fmt.Errorf("writer closed")
+	}
+	for _, message := range msgs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, _, err := w.broker.produce(w.topic, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close stops the writer; later writes fail
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	return nil
+}
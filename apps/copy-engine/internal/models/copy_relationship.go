@@ -3,6 +3,7 @@ package models
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"errors"
 	"time"
 )
 
@@ -37,9 +38,12 @@ type CopyStrategy struct {
 type StrategyType string
 
 const (
-	StrategyProportional StrategyType = "proportional"
-	StrategyFixed        StrategyType = "fixed"
-	StrategyAdaptive     StrategyType = "adaptive"
+	StrategyProportional   StrategyType = "proportional"
+	StrategyFixed          StrategyType = "fixed"
+	StrategyAdaptive       StrategyType = "adaptive"
+	StrategyRiskBased      StrategyType = "risk_based"
+	StrategyMartingale     StrategyType = "martingale"
+	StrategyAntiMartingale StrategyType = "anti_martingale"
 )
 
 // StrategyParams represents strategy parameters
@@ -147,15 +151,16 @@ const (
 
 // CopyExecution represents the execution of a copy signal
 type CopyExecution struct {
-	ID           string                 `json:"id"`
-	SignalID     string                 `json:"signal_id"`
-	Relationship *CopyRelationship      `json:"relationship"`
-	Trade        *Trade                 `json:"trade"`
-	Status       ExecutionStatus        `json:"status"`
-	ErrorMessage *string                `json:"error_message"`
-	Parameters   map[string]interface{} `json:"parameters"`
-	CreatedAt    time.Time              `json:"created_at"`
-	UpdatedAt    time.Time              `json:"updated_at"`
+	ID              string                 `json:"id"`
+	SignalID        string                 `json:"signal_id"`
+	OriginalTradeID string                 `json:"original_trade_id"`
+	Relationship    *CopyRelationship      `json:"relationship"`
+	Trade           *Trade                 `json:"trade"`
+	Status          ExecutionStatus        `json:"status"`
+	ErrorMessage    *string                `json:"error_message"`
+	Parameters      map[string]interface{} `json:"parameters"`
+	CreatedAt       time.Time              `json:"created_at"`
+	UpdatedAt       time.Time              `json:"updated_at"`
 }
 
 // ExecutionStatus represents the status of a copy execution
@@ -164,11 +169,65 @@ type ExecutionStatus string
 const (
 	StatusPending   ExecutionStatus = "pending"
 	StatusExecuting ExecutionStatus = "executing"
+	StatusRetrying  ExecutionStatus = "retrying"
 	StatusCompleted ExecutionStatus = "completed"
 	StatusFailed    ExecutionStatus = "failed"
 	StatusCancelled ExecutionStatus = "cancelled"
 )
 
+// ErrInvalidTransition is returned (wrapped) when an execution cannot move to a status
+var ErrInvalidTransition = errors.New("invalid execution status transition")
+
+// executionTransitions lists the statuses each execution status may move to. A
+// failure that will be retried moves to retrying; failed is always final.
+var executionTransitions = map[ExecutionStatus][]ExecutionStatus{
+	StatusPending:   {StatusExecuting, StatusRetrying, StatusFailed, StatusCancelled},
+	StatusExecuting: {StatusCompleted, StatusRetrying, StatusFailed},
+	StatusRetrying:  {StatusExecuting, StatusFailed, StatusCancelled},
+}
+
+// executionReplays lists the statuses an operator replay may reopen a terminal
+// execution to. Replays are outside the state machine, so failed stays terminal.
+var executionReplays = map[ExecutionStatus][]ExecutionStatus{
+	StatusFailed: {StatusRetrying},
+}
+
+// CanTransitionTo reports whether an execution in this status may move to next
+func (s ExecutionStatus) CanTransitionTo(next ExecutionStatus) bool {
+	for _, allowed := range executionTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// CanReplayTo reports whether an operator replay may reopen an execution in this
+// status to next
+func (s ExecutionStatus) CanReplayTo(next ExecutionStatus) bool {
+	for _, allowed := range executionReplays[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminal reports whether no further transitions are allowed from this status
+func (s ExecutionStatus) IsTerminal() bool {
+	return len(executionTransitions[s]) == 0
+}
+
+// ExecutionTransition records a single status change of a copy execution
+type ExecutionTransition struct {
+	ID          string          `json:"id"`
+	ExecutionID string          `json:"execution_id"`
+	FromStatus  ExecutionStatus `json:"from_status"` // empty for the initial status
+	ToStatus    ExecutionStatus `json:"to_status"`
+	Reason      string          `json:"reason"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
 // PerformanceMetrics represents performance metrics for a copy relationship
 type PerformanceMetrics struct {
 	RelationshipID string    `json:"relationship_id"`
@@ -195,3 +254,68 @@ type RiskMetrics struct {
 	LiquidityRisk     float64   `json:"liquidity_risk"`
 	LastUpdated       time.Time `json:"last_updated"`
 }
+
+// PositionDrift records a difference between a stored position and the exchange
+type PositionDrift struct {
+	ID             string      `json:"id"`
+	FollowerID     string      `json:"follower_id"`
+	RelationshipID *string     `json:"relationship_id"`
+	TokenSymbol    string      `json:"token_symbol"`
+	RecordedSize   float64     `json:"recorded_size"` // signed, negative for short
+	ExchangeSize   float64     `json:"exchange_size"` // signed, negative for short
+	ExpectedSize   *float64    `json:"expected_size"` // signed, from the followed traders
+	Action         DriftAction `json:"action"`
+	DetectedAt     time.Time   `json:"detected_at"`
+}
+
+// DriftAction represents what the reconciler did about a drift
+type DriftAction string
+
+const (
+	DriftInserted  DriftAction = "inserted"
+	DriftUpdated   DriftAction = "updated"
+	DriftClosed    DriftAction = "closed"
+	DriftCorrected DriftAction = "corrected"
+	DriftOutOfSync DriftAction = "out_of_sync"
+)
+
+// DeadLetter is a copy execution that exhausted its retries, kept with everything
+// needed to replay it
+type DeadLetter struct {
+	ID             string     `json:"id"`
+	ExecutionID    string     `json:"execution_id"`
+	RelationshipID string     `json:"relationship_id"`
+	OriginalTrade  *Trade     `json:"original_trade"`
+	CopySize       float64    `json:"copy_size"`
+	Attempts       int        `json:"attempts"`
+	LastError      string     `json:"last_error"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ReplayedAt     *time.Time `json:"replayed_at"`
+}
+
+// TradeDeadLetter is a trader trade the ingestor gave up submitting to the copy
+// engine after repeated failures
+type TradeDeadLetter struct {
+	ID        string    `json:"id"`
+	TradeID   string    `json:"trade_id"`
+	TraderID  string    `json:"trader_id"`
+	Source    string    `json:"source"` // transport and position the trade was read from
+	Trade     *Trade    `json:"trade"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AgentWallet is an agent key a user approved to trade their exchange account.
+// The private key is stored encrypted and only decrypted when orders are signed.
+type AgentWallet struct {
+	ID                  string    `json:"id" db:"id"`
+	UserID              string    `json:"user_id" db:"user_id"`
+	Exchange            string    `json:"exchange" db:"exchange"`
+	Address             string    `json:"address" db:"address"`               // the agent's own address, which signs
+	MasterAddress       string    `json:"master_address" db:"wallet_address"` // the user's trading account the agent acts for
+	Status              string    `json:"status" db:"status"`
+	EncryptedPrivateKey *string   `json:"-" db:"encrypted_private_key"`
+	CreatedAt           time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import "testing"
+
+func TestExecutionStatusIsTerminal(t *testing.T) {
+	tests := []struct {
+		status   ExecutionStatus
+		terminal bool
+	}{
+		{StatusPending, false},
+		{StatusExecuting, false},
+		{StatusRetrying, false},
+		{StatusCompleted, true},
+		{StatusFailed, true},
+		{StatusCancelled, true},
+	}
+	for _, tt := range tests {
+		if got := tt.status.IsTerminal(); got != tt.terminal {
+			t.Errorf("%s.IsTerminal() = %v, want %v", tt.status, got, tt.terminal)
+		}
+	}
+}
+
+func TestFailedExecutionOnlyReopensThroughReplay(t *testing.T) {
+	if StatusFailed.CanTransitionTo(StatusRetrying) {
+		t.Error("failed -> retrying is allowed outside a replay")
+	}
+	if !StatusFailed.CanReplayTo(StatusRetrying) {
+		t.Error("failed executions cannot be replayed")
+	}
+	if StatusCompleted.CanReplayTo(StatusRetrying) {
+		t.Error("completed executions can be replayed")
+	}
+}
+
+func TestExecutionStatusTransitions(t *testing.T) {
+	tests := []struct {
+		from, to ExecutionStatus
+		allowed  bool
+	}{
+		{StatusPending, StatusExecuting, true},
+		{StatusPending, StatusRetrying, true},
+		{StatusPending, StatusCancelled, true},
+		{StatusPending, StatusCompleted, false},
+		{StatusExecuting, StatusCompleted, true},
+		{StatusExecuting, StatusRetrying, true},
+		{StatusExecuting, StatusFailed, true},
+		{StatusExecuting, StatusCancelled, false},
+		{StatusExecuting, StatusPending, false},
+		{StatusRetrying, StatusExecuting, true},
+		{StatusRetrying, StatusCompleted, false},
+		{StatusCompleted, StatusExecuting, false},
+		{StatusCancelled, StatusPending, false},
+	}
+	for _, tt := range tests {
+		if got := tt.from.CanTransitionTo(tt.to); got != tt.allowed {
+			t.Errorf("%s -> %s allowed = %v, want %v", tt.from, tt.to, got, tt.allowed)
+		}
+	}
+}
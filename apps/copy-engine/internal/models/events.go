@@ -0,0 +1,145 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventSchemaVersion is the version of the event envelope and payloads below. It is
+// bumped on any change that is not a pure field addition.
+const EventSchemaVersion = 1
+
+// EventType identifies what an outbox event reports
+type EventType string
+
+const (
+	EventExecutionStatusChanged EventType = "copy_execution.status_changed"
+	EventPositionOpened         EventType = "position.opened"
+	EventPositionChanged        EventType = "position.changed"
+	EventPositionClosed         EventType = "position.closed"
+	EventRiskBreach             EventType = "risk.breach"
+)
+
+// OutboxEvent is an event written in the same transaction as the change it
+// describes and published afterwards
+type OutboxEvent struct {
+	ID            string          `json:"id"`
+	Type          EventType       `json:"type"`
+	SchemaVersion int             `json:"schema_version"`
+	AggregateID   string          `json:"aggregate_id"` // events of one aggregate are published in order
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Data          json.RawMessage `json:"data"`
+	Attempts      int             `json:"-"`
+	PublishedAt   *time.Time      `json:"-"`
+}
+
+// ExecutionEventData is the payload of copy_execution.status_changed
+type ExecutionEventData struct {
+	ExecutionID     string          `json:"execution_id"`
+	RelationshipID  string          `json:"relationship_id"`
+	FollowerID      string          `json:"follower_id,omitempty"`
+	TraderID        string          `json:"trader_id,omitempty"`
+	OriginalTradeID string          `json:"original_trade_id,omitempty"`
+	TradeID         string          `json:"trade_id,omitempty"`
+	FromStatus      ExecutionStatus `json:"from_status,omitempty"`
+	Status          ExecutionStatus `json:"status"`
+	Terminal        bool            `json:"terminal"`
+	Reason          string          `json:"reason,omitempty"`
+	CopySize        float64         `json:"copy_size,omitempty"`
+	Error           string          `json:"error,omitempty"`
+}
+
+// PositionEventData is the payload of the position.* events
+type PositionEventData struct {
+	PositionID         string       `json:"position_id"`
+	UserID             string       `json:"user_id,omitempty"`
+	TraderID           string       `json:"trader_id,omitempty"`
+	CopyRelationshipID string       `json:"copy_relationship_id,omitempty"`
+	TokenSymbol        string       `json:"token_symbol"`
+	Side               PositionSide `json:"side"`
+	Size               float64      `json:"size"`
+	EntryPrice         float64      `json:"entry_price"`
+	Leverage           float64      `json:"leverage"`
+	UnrealizedPnL      float64      `json:"unrealized_pnl"`
+	IsCopyTrade        bool         `json:"is_copy_trade"`
+}
+
+// RiskBreachEventData is the payload of risk.breach
+type RiskBreachEventData struct {
+	ExecutionID    string `json:"execution_id,omitempty"`
+	RelationshipID string `json:"relationship_id"`
+	FollowerID     string `json:"follower_id,omitempty"`
+	Reason         string `json:"reason"`
+	Message        string `json:"message"`
+}
+
+// NewOutboxEvent wraps a payload in a versioned event envelope
+func NewOutboxEvent(eventType EventType, aggregateID string, data interface{}) (*OutboxEvent, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s event: %w", eventType, err)
+	}
+
+	return &OutboxEvent{
+		ID:            uuid.New().String(),
+		Type:          eventType,
+		SchemaVersion: EventSchemaVersion,
+		AggregateID:   aggregateID,
+		OccurredAt:    time.Now(),
+		Data:          payload,
+	}, nil
+}
+
+// NewExecutionEvent describes an execution's move through a transition
+func NewExecutionEvent(execution *CopyExecution, transition *ExecutionTransition) (*OutboxEvent, error) {
+	data := ExecutionEventData{
+		ExecutionID:     execution.ID,
+		OriginalTradeID: execution.OriginalTradeID,
+		FromStatus:      transition.FromStatus,
+		Status:          transition.ToStatus,
+		Terminal:        transition.ToStatus.IsTerminal(),
+		Reason:          transition.Reason,
+	}
+	if execution.Relationship != nil {
+		data.RelationshipID = execution.Relationship.ID
+		data.FollowerID = execution.Relationship.FollowerID
+		data.TraderID = execution.Relationship.TraderID
+	}
+	if execution.Trade != nil {
+		data.TradeID = execution.Trade.ID
+		data.CopySize = execution.Trade.Size
+	}
+	if execution.ErrorMessage != nil {
+		data.Error = *execution.ErrorMessage
+	}
+
+	return NewOutboxEvent(EventExecutionStatusChanged, execution.ID, data)
+}
+
+// NewPositionEvent describes the current state of a position
+func NewPositionEvent(eventType EventType, position *Position) (*OutboxEvent, error) {
+	data := PositionEventData{
+		PositionID:    position.ID,
+		TokenSymbol:   position.TokenSymbol,
+		Side:          position.Side,
+		Size:          position.Size,
+		EntryPrice:    position.EntryPrice,
+		Leverage:      position.Leverage,
+		UnrealizedPnL: position.UnrealizedPnL,
+		IsCopyTrade:   position.IsCopyTrade,
+	}
+	if position.UserID != nil {
+		data.UserID = *position.UserID
+	}
+	if position.TraderID != nil {
+		data.TraderID = *position.TraderID
+	}
+	if position.CopyRelationshipID != nil {
+		data.CopyRelationshipID = *position.CopyRelationshipID
+	}
+
+	return NewOutboxEvent(eventType, position.ID, data)
+}
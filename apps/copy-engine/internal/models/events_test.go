@@ -0,0 +1,37 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestExecutionEventTerminal(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to ExecutionStatus
+		terminal bool
+	}{
+		{"retry scheduled", StatusExecuting, StatusRetrying, false},
+		{"failed permanently", StatusExecuting, StatusFailed, true},
+		{"retries exhausted", StatusRetrying, StatusFailed, true},
+		{"replayed", StatusFailed, StatusRetrying, false},
+		{"completed", StatusExecuting, StatusCompleted, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			execution := &CopyExecution{ID: "execution-1", Relationship: &CopyRelationship{ID: "relationship-1"}}
+			event, err := NewExecutionEvent(execution, &ExecutionTransition{FromStatus: tt.from, ToStatus: tt.to})
+			if err != nil {
+				t.Fatalf("NewExecutionEvent: %v", err)
+			}
+
+			var data ExecutionEventData
+			if err := json.Unmarshal(event.Data, &data); err != nil {
+				t.Fatal(err)
+			}
+			if data.Terminal != tt.terminal {
+				t.Fatalf("terminal = %v, want %v", data.Terminal, tt.terminal)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package risk
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/hyperdash/copy-engine/internal/config"
+	"github.com/hyperdash/copy-engine/internal/models"
+)
+
+// RejectionReason identifies which risk limit rejected a strategy or order
+type RejectionReason string
+
+const (
+	ReasonInvalidAllocation RejectionReason = "invalid_allocation"
+	ReasonMaxLeverage       RejectionReason = "max_leverage"
+	ReasonMaxPositionSize   RejectionReason = "max_position_size"
+	ReasonMaxSlippage       RejectionReason = "max_slippage"
+	ReasonMinOrderSize      RejectionReason = "min_order_size"
+	ReasonMaxDailyLoss      RejectionReason = "max_daily_loss"
+)
+
+// RejectionError is returned when a strategy or order violates a risk limit
+type RejectionError struct {
+	Reason  RejectionReason
+	Message string
+}
+
+func (e *RejectionError) Error() string {
+	return fmt.Sprintf("risk rejection (%s): %s", e.Reason, e.Message)
+}
+
+// RecordOn records this rejection on a copy execution. The caller is responsible
+// for transitioning the execution to failed.
+func (e *RejectionError) RecordOn(execution *models.CopyExecution) {
+	message := e.Error()
+	execution.ErrorMessage = &message
+	if execution.Parameters == nil {
+		execution.Parameters = make(map[string]interface{})
+	}
+	execution.Parameters["rejection_reason"] = string(e.Reason)
+	execution.UpdatedAt = time.Now()
+}
+
+// ReasonOf extracts the rejection reason from an error chain
+func ReasonOf(err error) (RejectionReason, bool) {
+	var rejection *RejectionError
+	if errors.As(err, &rejection) {
+		return rejection.Reason, true
+	}
+	return "", false
+}
+
+func reject(reason RejectionReason, format string, args ...interface{}) error {
+	return &RejectionError{Reason: reason, Message: fmt.Sprintf(format, args...)}
+}
+
+// Limits are the risk parameters a strategy asks for
+type Limits struct {
+	MaxLeverage  float64
+	MaxSlippage  float64 // in basis points
+	MinOrderSize float64
+}
+
+// Allocation is a weighted trader followed by a strategy
+type Allocation struct {
+	TraderID string
+	Weight   float64
+}
+
+// Strategy is the view of an engine strategy that the risk manager validates
+type Strategy interface {
+	StrategyID() string
+	RiskLimits() Limits
+	TraderAllocations() []Allocation
+}
+
+// OrderCheck describes a proposed order for pre-trade validation
+type OrderCheck struct {
+	StrategyID      string
+	Symbol          string
+	Size            float64 // absolute order size
+	Price           float64 // worst execution price the order accepts
+	SlippageBps     float64 // slippage from the mid price the order accepts, in basis points
+	PositionSize    float64 // signed position size before the order
+	ResultingSize   float64 // signed position size after the order
+	AccountValue    float64
+	AccountNotional float64 // total notional after the order
+	Limits          Limits
+}
+
+// Manager enforces the service-wide RiskConfig on strategies and orders
+type Manager struct {
+	config config.RiskConfig
+
+	dailyLoss   map[string]float64
+	dailyLossAt time.Time
+	mutex       sync.Mutex
+}
+
+// NewManager creates a risk manager from configuration
+func NewManager(cfg config.RiskConfig) *Manager {
+	return &Manager{
+		config:      cfg,
+		dailyLoss:   make(map[string]float64),
+		dailyLossAt: startOfDay(time.Now()),
+	}
+}
+
+// ValidateStrategy checks a strategy's requested limits and allocations
+func (m *Manager) ValidateStrategy(strategy Strategy) error {
+	allocations := strategy.TraderAllocations()
+	if len(allocations) == 0 {
+		return reject(ReasonInvalidAllocation, "strategy %s follows no traders", strategy.StrategyID())
+	}
+
+	var totalWeight float64
+	seen := make(map[string]bool)
+	for _, allocation := range allocations {
+		if allocation.TraderID == "" {
+			return reject(ReasonInvalidAllocation, "allocation is missing a trader ID")
+		}
+		if seen[allocation.TraderID] {
+			return reject(ReasonInvalidAllocation, "trader %s is allocated more than once", allocation.TraderID)
+		}
+		if allocation.Weight <= 0 || allocation.Weight > 1 {
+			return reject(ReasonInvalidAllocation, "weight %.4f for trader %s must be in (0, 1]",
+				allocation.Weight, allocation.TraderID)
+		}
+		seen[allocation.TraderID] = true
+		totalWeight += allocation.Weight
+	}
+
+	if totalWeight > 1+1e-9 {
+		return reject(ReasonInvalidAllocation, "allocation weights sum to %.4f, must not exceed 1", totalWeight)
+	}
+
+	limits := strategy.RiskLimits()
+	if limits.MaxLeverage <= 0 || limits.MaxLeverage > m.config.MaxLeverage {
+		return reject(ReasonMaxLeverage, "requested leverage %.2f must be in (0, %.2f]",
+			limits.MaxLeverage, m.config.MaxLeverage)
+	}
+	if limits.MaxSlippage < 0 || limits.MaxSlippage > m.config.MaxSlippage {
+		return reject(ReasonMaxSlippage, "requested slippage %.1f bps must be in [0, %.1f]",
+			limits.MaxSlippage, m.config.MaxSlippage)
+	}
+	if limits.MinOrderSize < m.config.MinOrderSize {
+		return reject(ReasonMinOrderSize, "requested minimum order size %.2f is below %.2f",
+			limits.MinOrderSize, m.config.MinOrderSize)
+	}
+
+	if loss := m.DailyLoss(strategy.StrategyID()); loss >= m.config.MaxDailyLoss {
+		return reject(ReasonMaxDailyLoss, "strategy %s has lost %.2f today, limit is %.2f",
+			strategy.StrategyID(), loss, m.config.MaxDailyLoss)
+	}
+
+	return nil
+}
+
+// CheckOrder validates a single order against the strategy and service limits.
+// Orders that only reduce exposure bypass the leverage, size and loss limits.
+func (m *Manager) CheckOrder(order OrderCheck) error {
+	notional := order.Size * order.Price
+
+	minOrderSize := math.Max(order.Limits.MinOrderSize, m.config.MinOrderSize)
+	if notional < minOrderSize {
+		return reject(ReasonMinOrderSize, "%s order notional %.2f is below %.2f", order.Symbol, notional, minOrderSize)
+	}
+
+	maxSlippage := m.config.MaxSlippage
+	if order.Limits.MaxSlippage > 0 {
+		maxSlippage = math.Min(maxSlippage, order.Limits.MaxSlippage)
+	}
+	// The slippage is compared as requested; re-deriving it from the worst price
+	// would let float round-off push an order at the limit just over it
+	if order.SlippageBps > maxSlippage {
+		return reject(ReasonMaxSlippage, "%s order allows %.1f bps of slippage, limit is %.1f bps",
+			order.Symbol, order.SlippageBps, maxSlippage)
+	}
+
+	if math.Abs(order.ResultingSize) <= math.Abs(order.PositionSize) &&
+		(order.ResultingSize == 0 || (order.ResultingSize > 0) == (order.PositionSize > 0)) {
+		return nil
+	}
+
+	if loss := m.DailyLoss(order.StrategyID); loss >= m.config.MaxDailyLoss {
+		return reject(ReasonMaxDailyLoss, "strategy %s has lost %.2f today, limit is %.2f",
+			order.StrategyID, loss, m.config.MaxDailyLoss)
+	}
+
+	positionValue := math.Abs(order.ResultingSize) * order.Price
+	if positionValue > m.config.MaxPositionSize {
+		return reject(ReasonMaxPositionSize, "%s position value %.2f exceeds %.2f",
+			order.Symbol, positionValue, m.config.MaxPositionSize)
+	}
+
+	maxLeverage := m.config.MaxLeverage
+	if order.Limits.MaxLeverage > 0 {
+		maxLeverage = math.Min(maxLeverage, order.Limits.MaxLeverage)
+	}
+	if order.AccountValue <= 0 {
+		return reject(ReasonMaxLeverage, "account has no equity to open %s exposure", order.Symbol)
+	}
+	if leverage := order.AccountNotional / order.AccountValue; leverage > maxLeverage {
+		return reject(ReasonMaxLeverage, "resulting leverage %.2fx exceeds %.2fx", leverage, maxLeverage)
+	}
+
+	return nil
+}
+
+// RecordPnL accumulates realized PnL for the daily loss limit
+func (m *Manager) RecordPnL(strategyID string, pnl float64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.rollover()
+	m.dailyLoss[strategyID] -= pnl
+
+	if m.dailyLoss[strategyID] >= m.config.MaxDailyLoss {
+		log.Printf("Strategy %s reached daily loss limit: %.2f", strategyID, m.dailyLoss[strategyID])
+	}
+}
+
+// DailyLoss returns the net realized loss of a strategy since midnight UTC
+func (m *Manager) DailyLoss(strategyID string) float64 {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.rollover()
+	return m.dailyLoss[strategyID]
+}
+
+// rollover resets daily losses at the UTC day boundary. Callers must hold m.mutex.
+func (m *Manager) rollover() {
+	today := startOfDay(time.Now())
+	if today.After(m.dailyLossAt) {
+		m.dailyLoss = make(map[string]float64)
+		m.dailyLossAt = today
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
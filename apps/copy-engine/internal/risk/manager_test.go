@@ -0,0 +1,155 @@
+package risk
+
+import (
+	"testing"
+
+	"github.com/hyperdash/copy-engine/internal/config"
+)
+
+var testRiskConfig = config.RiskConfig{
+	MaxLeverage:     5,
+	MaxPositionSize: 100000,
+	MaxSlippage:     10,
+	MinOrderSize:    10,
+	MaxDailyLoss:    1000,
+}
+
+type testStrategy struct {
+	id          string
+	limits      Limits
+	allocations []Allocation
+}
+
+func (s testStrategy) StrategyID() string              { return s.id }
+func (s testStrategy) RiskLimits() Limits              { return s.limits }
+func (s testStrategy) TraderAllocations() []Allocation { return s.allocations }
+
+func TestValidateStrategy(t *testing.T) {
+	valid := Limits{MaxLeverage: 3, MaxSlippage: 10, MinOrderSize: 10}
+	oneTrader := []Allocation{{TraderID: "trader-1", Weight: 0.5}}
+
+	tests := []struct {
+		name        string
+		limits      Limits
+		allocations []Allocation
+		dailyLoss   float64
+		want        RejectionReason
+	}{
+		{name: "valid", limits: valid, allocations: oneTrader},
+		{name: "full allocation", limits: valid, allocations: []Allocation{{"trader-1", 0.6}, {"trader-2", 0.4}}},
+		{name: "service limits", limits: Limits{MaxLeverage: 5, MaxSlippage: 10, MinOrderSize: 10}, allocations: oneTrader},
+		{name: "no traders", limits: valid, want: ReasonInvalidAllocation},
+		{name: "missing trader ID", limits: valid, allocations: []Allocation{{"", 0.5}}, want: ReasonInvalidAllocation},
+		{name: "duplicate trader", limits: valid, allocations: []Allocation{{"trader-1", 0.3}, {"trader-1", 0.3}}, want: ReasonInvalidAllocation},
+		{name: "zero weight", limits: valid, allocations: []Allocation{{"trader-1", 0}}, want: ReasonInvalidAllocation},
+		{name: "weight above one", limits: valid, allocations: []Allocation{{"trader-1", 1.5}}, want: ReasonInvalidAllocation},
+		{name: "weights above one", limits: valid, allocations: []Allocation{{"trader-1", 0.6}, {"trader-2", 0.5}}, want: ReasonInvalidAllocation},
+		{name: "no leverage", limits: Limits{MaxSlippage: 10, MinOrderSize: 10}, allocations: oneTrader, want: ReasonMaxLeverage},
+		{name: "leverage above the service", limits: Limits{MaxLeverage: 6, MaxSlippage: 10, MinOrderSize: 10}, allocations: oneTrader, want: ReasonMaxLeverage},
+		{name: "slippage above the service", limits: Limits{MaxLeverage: 3, MaxSlippage: 11, MinOrderSize: 10}, allocations: oneTrader, want: ReasonMaxSlippage},
+		{name: "negative slippage", limits: Limits{MaxLeverage: 3, MaxSlippage: -1, MinOrderSize: 10}, allocations: oneTrader, want: ReasonMaxSlippage},
+		{name: "order size below the service", limits: Limits{MaxLeverage: 3, MaxSlippage: 10, MinOrderSize: 5}, allocations: oneTrader, want: ReasonMinOrderSize},
+		{name: "daily loss reached", limits: valid, allocations: oneTrader, dailyLoss: 1000, want: ReasonMaxDailyLoss},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager(testRiskConfig)
+			m.RecordPnL("strategy-1", -tt.dailyLoss)
+
+			err := m.ValidateStrategy(testStrategy{id: "strategy-1", limits: tt.limits, allocations: tt.allocations})
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("ValidateStrategy: %v", err)
+				}
+				return
+			}
+			if reason, ok := ReasonOf(err); !ok || reason != tt.want {
+				t.Errorf("ValidateStrategy returned %v, want a %s rejection", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckOrder(t *testing.T) {
+	limits := Limits{MaxLeverage: 3, MaxSlippage: 8, MinOrderSize: 20}
+
+	// A buy of 1 ETH at 3000 opening a position in a 10000 account holding nothing else
+	opening := OrderCheck{
+		StrategyID:      "strategy-1",
+		Symbol:          "ETH",
+		Size:            1,
+		Price:           3000,
+		SlippageBps:     8,
+		ResultingSize:   1,
+		AccountValue:    10000,
+		AccountNotional: 3000,
+		Limits:          limits,
+	}
+
+	tests := []struct {
+		name      string
+		modify    func(order *OrderCheck)
+		dailyLoss float64
+		want      RejectionReason
+	}{
+		{name: "valid", modify: func(o *OrderCheck) {}},
+		{name: "below the strategy minimum", modify: func(o *OrderCheck) { o.Size = 0.005 }, want: ReasonMinOrderSize},
+		{name: "below the service minimum", modify: func(o *OrderCheck) { o.Size, o.Limits.MinOrderSize = 0.003, 0 }, want: ReasonMinOrderSize},
+		{name: "slippage above the strategy", modify: func(o *OrderCheck) { o.SlippageBps = 9 }, want: ReasonMaxSlippage},
+		{name: "slippage above the service", modify: func(o *OrderCheck) { o.SlippageBps, o.Limits.MaxSlippage = 11, 0 }, want: ReasonMaxSlippage},
+		{name: "position above the maximum", modify: func(o *OrderCheck) {
+			o.Size, o.ResultingSize, o.AccountValue, o.AccountNotional = 40, 40, 100000, 120000
+		}, want: ReasonMaxPositionSize},
+		{name: "leverage above the strategy", modify: func(o *OrderCheck) { o.AccountNotional = 31000 }, want: ReasonMaxLeverage},
+		{name: "leverage above the service", modify: func(o *OrderCheck) { o.AccountNotional, o.Limits.MaxLeverage = 51000, 0 }, want: ReasonMaxLeverage},
+		{name: "no equity", modify: func(o *OrderCheck) { o.AccountValue = 0 }, want: ReasonMaxLeverage},
+		{name: "daily loss reached", modify: func(o *OrderCheck) {}, dailyLoss: 1000, want: ReasonMaxDailyLoss},
+		{name: "reducing bypasses leverage and loss", modify: func(o *OrderCheck) {
+			o.PositionSize, o.ResultingSize, o.AccountValue, o.AccountNotional = 5, 4, 0, 12000
+		}, dailyLoss: 1000},
+		{name: "closing bypasses the position limit", modify: func(o *OrderCheck) {
+			o.Size, o.PositionSize, o.ResultingSize = 40, -40, 0
+		}},
+		{name: "reducing still needs the minimum", modify: func(o *OrderCheck) {
+			o.Size, o.PositionSize, o.ResultingSize = 0.005, 1, 0.995
+		}, want: ReasonMinOrderSize},
+		{name: "flipping is checked as opening", modify: func(o *OrderCheck) {
+			o.Size, o.PositionSize, o.ResultingSize = 2, -1, 1
+		}, dailyLoss: 1000, want: ReasonMaxDailyLoss},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager(testRiskConfig)
+			m.RecordPnL("strategy-1", -tt.dailyLoss)
+
+			order := opening
+			tt.modify(&order)
+			err := m.CheckOrder(order)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("CheckOrder: %v", err)
+				}
+				return
+			}
+			if reason, ok := ReasonOf(err); !ok || reason != tt.want {
+				t.Errorf("CheckOrder returned %v, want a %s rejection", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordPnLAccumulatesDailyLoss(t *testing.T) {
+	m := NewManager(testRiskConfig)
+	m.RecordPnL("strategy-1", -300)
+	m.RecordPnL("strategy-1", 100)
+	m.RecordPnL("strategy-2", -50)
+
+	if loss := m.DailyLoss("strategy-1"); loss != 200 {
+		t.Errorf("strategy-1 lost %v, want 200", loss)
+	}
+	if loss := m.DailyLoss("strategy-2"); loss != 50 {
+		t.Errorf("strategy-2 lost %v, want 50", loss)
+	}
+}
@@ -0,0 +1,315 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/hyperdash/copy-engine/internal/engine"
+	"github.com/hyperdash/copy-engine/internal/risk"
+)
+
+type handler struct {
+	engine     *engine.Engine
+	rateLimits RateLimitReporter
+}
+
+type allocationDTO struct {
+	TraderID string  `json:"traderId" binding:"required"`
+	Weight   float64 `json:"weight" binding:"required,gt=0,lte=1"`
+}
+
+type riskParamsDTO struct {
+	MaxLeverage          float64 `json:"maxLeverage" binding:"required,gt=0"`
+	SlippageBps          float64 `json:"slippageBps" binding:"gte=0"`
+	MinOrderUsd          float64 `json:"minOrderUsd" binding:"gte=0"` // 0 takes the configured minimum
+	FollowNewEntriesOnly bool    `json:"followNewEntriesOnly"`
+}
+
+type createStrategyRequest struct {
+	UserID      string          `json:"userId" binding:"required"`
+	Name        string          `json:"name" binding:"required"`
+	Allocations []allocationDTO `json:"allocations" binding:"required,min=1,dive"`
+	RiskParams  riskParamsDTO   `json:"riskParams" binding:"required"`
+	Start       bool            `json:"start"`
+}
+
+type updateStrategyRequest struct {
+	Name        *string         `json:"name"`
+	Allocations []allocationDTO `json:"allocations" binding:"omitempty,min=1,dive"`
+	RiskParams  *riskParamsDTO  `json:"riskParams"`
+}
+
+type strategyResponse struct {
+	ID            string          `json:"id"`
+	UserID        string          `json:"userId"`
+	Name          string          `json:"name"`
+	Status        string          `json:"status"`
+	AlignmentRate float64         `json:"alignmentRate"`
+	LastExecution *time.Time      `json:"lastExecution"`
+	Allocations   []allocationDTO `json:"allocations"`
+	RiskParams    riskParamsDTO   `json:"riskParams"`
+}
+
+type positionResponse struct {
+	Symbol        string  `json:"symbol"`
+	Quantity      float64 `json:"quantity"`
+	EntryPrice    float64 `json:"entryPrice"`
+	CurrentPrice  float64 `json:"currentPrice"`
+	UnrealizedPnL float64 `json:"unrealizedPnl"`
+	Leverage      float64 `json:"leverage"`
+}
+
+type metricsResponse struct {
+	TotalStrategies      int     `json:"totalStrategies"`
+	ActiveStrategies     int     `json:"activeStrategies"`
+	TotalPositions       int     `json:"totalPositions"`
+	SuccessfulExecutions int     `json:"successfulExecutions"`
+	FailedExecutions     int     `json:"failedExecutions"`
+	AverageLatencyMs     float64 `json:"averageLatencyMs"`
+	AlignmentRate        float64 `json:"alignmentRate"`
+	OrdersPlaced         int     `json:"ordersPlaced"`
+	PartialFills         int     `json:"partialFills"`
+	RejectedOrders       int     `json:"rejectedOrders"`
+
+	RateLimit *rateLimitResponse `json:"rateLimit,omitempty"`
+}
+
+type rateLimitResponse struct {
+	Requests      uint64  `json:"requests"`
+	Throttled     uint64  `json:"throttled"`
+	Coalesced     uint64  `json:"coalesced"`
+	Cancelled     uint64  `json:"cancelled"`
+	WeightSpent   uint64  `json:"weightSpent"`
+	AverageWaitMs float64 `json:"averageWaitMs"`
+	MaxWaitMs     float64 `json:"maxWaitMs"`
+	Available     float64 `json:"available"`
+	QueuedOrders  int     `json:"queuedOrders"`
+	QueuedQueries int     `json:"queuedQueries"`
+}
+
+func (h *handler) listStrategies(c *gin.Context) {
+	strategies := h.engine.ListStrategies()
+
+	response := make([]strategyResponse, 0, len(strategies))
+	for _, strategy := range strategies {
+		if status := c.Query("status"); status != "" && string(strategy.CurrentStatus()) != status {
+			continue
+		}
+		response = append(response, toStrategyResponse(strategy))
+	}
+
+	sort.Slice(response, func(i, j int) bool { return response[i].Name < response[j].Name })
+	c.JSON(http.StatusOK, gin.H{"strategies": response})
+}
+
+func (h *handler) getStrategy(c *gin.Context) {
+	strategy, err := h.engine.GetStrategy(c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toStrategyResponse(strategy))
+}
+
+func (h *handler) createStrategy(c *gin.Context) {
+	var req createStrategyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	strategy := &engine.Strategy{
+		ID:         uuid.New().String(),
+		UserID:     req.UserID,
+		Name:       req.Name,
+		Traders:    toTraderAllocations(req.Allocations),
+		RiskParams: toRiskParameters(req.RiskParams),
+		Positions:  make(map[string]*engine.Position),
+	}
+
+	if err := h.engine.CreateStrategy(strategy); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if req.Start {
+		if err := h.engine.ResumeStrategy(strategy.ID); err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusCreated, toStrategyResponse(strategy))
+}
+
+func (h *handler) updateStrategy(c *gin.Context) {
+	var req updateStrategyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	update := engine.StrategyUpdate{Name: req.Name}
+	if req.Allocations != nil {
+		update.Traders = toTraderAllocations(req.Allocations)
+	}
+	if req.RiskParams != nil {
+		riskParams := toRiskParameters(*req.RiskParams)
+		update.RiskParams = &riskParams
+	}
+
+	if err := h.engine.UpdateStrategy(c.Param("id"), update); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"id": c.Param("id"), "status": "update_queued"})
+}
+
+func (h *handler) startStrategy(c *gin.Context) {
+	if err := h.engine.ResumeStrategy(c.Param("id")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"id": c.Param("id"), "status": "start_queued"})
+}
+
+func (h *handler) stopStrategy(c *gin.Context) {
+	if err := h.engine.StopStrategy(c.Param("id")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": c.Param("id"), "status": engine.StatusPaused})
+}
+
+func (h *handler) getStrategyPositions(c *gin.Context) {
+	strategy, err := h.engine.GetStrategy(c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	positions := strategy.GetPositions()
+	response := make([]positionResponse, 0, len(positions))
+	for _, position := range positions {
+		response = append(response, positionResponse{
+			Symbol:        position.Symbol,
+			Quantity:      position.Quantity,
+			EntryPrice:    position.EntryPrice,
+			CurrentPrice:  position.CurrentPrice,
+			UnrealizedPnL: position.UnrealizedPnL,
+			Leverage:      position.Leverage,
+		})
+	}
+
+	sort.Slice(response, func(i, j int) bool { return response[i].Symbol < response[j].Symbol })
+	c.JSON(http.StatusOK, gin.H{"strategyId": strategy.ID, "positions": response})
+}
+
+func (h *handler) getMetrics(c *gin.Context) {
+	metrics := h.engine.GetMetrics()
+
+	response := metricsResponse{
+		TotalStrategies:      metrics.TotalStrategies,
+		ActiveStrategies:     metrics.ActiveStrategies,
+		TotalPositions:       metrics.TotalPositions,
+		SuccessfulExecutions: metrics.SuccessfulExecutions,
+		FailedExecutions:     metrics.FailedExecutions,
+		AverageLatencyMs:     float64(metrics.AverageLatency) / float64(time.Millisecond),
+		AlignmentRate:        metrics.AlignmentRate,
+		OrdersPlaced:         metrics.OrdersPlaced,
+		PartialFills:         metrics.PartialFills,
+		RejectedOrders:       metrics.RejectedOrders,
+	}
+
+	if h.rateLimits != nil {
+		limits := h.rateLimits.RateLimitMetrics()
+		response.RateLimit = &rateLimitResponse{
+			Requests:      limits.Requests,
+			Throttled:     limits.Throttled,
+			Coalesced:     limits.Coalesced,
+			Cancelled:     limits.Cancelled,
+			WeightSpent:   limits.WeightSpent,
+			MaxWaitMs:     float64(limits.MaxWait) / float64(time.Millisecond),
+			Available:     limits.Available,
+			QueuedOrders:  limits.QueuedOrders,
+			QueuedQueries: limits.QueuedQueries,
+		}
+		if waited := limits.Throttled - limits.Cancelled; waited > 0 {
+			response.RateLimit.AverageWaitMs = float64(limits.TotalWait) / float64(waited) / float64(time.Millisecond)
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func respondError(c *gin.Context, err error) {
+	if reason, ok := risk.ReasonOf(err); ok {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "reason": reason})
+		return
+	}
+
+	switch {
+	case errors.Is(err, engine.ErrStrategyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, engine.ErrStrategyExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func toStrategyResponse(s *engine.Strategy) strategyResponse {
+	strategy := s.Snapshot()
+
+	allocations := make([]allocationDTO, 0, len(strategy.Traders))
+	for _, trader := range strategy.Traders {
+		allocations = append(allocations, allocationDTO{TraderID: trader.TraderID, Weight: trader.Weight})
+	}
+
+	response := strategyResponse{
+		ID:            strategy.ID,
+		UserID:        strategy.UserID,
+		Name:          strategy.Name,
+		Status:        string(strategy.Status),
+		AlignmentRate: strategy.AlignmentRate,
+		Allocations:   allocations,
+		RiskParams: riskParamsDTO{
+			MaxLeverage:          strategy.RiskParams.MaxLeverage,
+			SlippageBps:          strategy.RiskParams.MaxSlippage,
+			MinOrderUsd:          strategy.RiskParams.MinOrderSize,
+			FollowNewEntriesOnly: strategy.RiskParams.FollowNewEntries,
+		},
+	}
+
+	if !strategy.LastExecution.IsZero() {
+		lastExecution := strategy.LastExecution
+		response.LastExecution = &lastExecution
+	}
+
+	return response
+}
+
+func toTraderAllocations(allocations []allocationDTO) []engine.TraderAllocation {
+	traders := make([]engine.TraderAllocation, 0, len(allocations))
+	for _, allocation := range allocations {
+		traders = append(traders, engine.TraderAllocation{TraderID: allocation.TraderID, Weight: allocation.Weight})
+	}
+	return traders
+}
+
+func toRiskParameters(params riskParamsDTO) engine.RiskParameters {
+	return engine.RiskParameters{
+		MaxLeverage:      params.MaxLeverage,
+		MaxSlippage:      params.SlippageBps,
+		MinOrderSize:     params.MinOrderUsd,
+		FollowNewEntries: params.FollowNewEntriesOnly,
+	}
+}
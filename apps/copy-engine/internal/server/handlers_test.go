@@ -0,0 +1,246 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hyperdash/copy-engine/internal/engine"
+	"github.com/hyperdash/copy-engine/internal/exchange"
+)
+
+type staticRateLimits exchange.RateLimitMetrics
+
+func (s staticRateLimits) RateLimitMetrics() exchange.RateLimitMetrics {
+	return exchange.RateLimitMetrics(s)
+}
+
+func decode(t *testing.T, body []byte, out interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(body, out); err != nil {
+		t.Fatalf("failed to decode %s: %v", body, err)
+	}
+}
+
+func TestCreateStrategyValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantReason string
+	}{
+		{
+			name:       "valid",
+			body:       `{"userId":"user-1","name":"Momentum","allocations":[{"traderId":"trader-1","weight":0.5}],"riskParams":{"maxLeverage":3,"slippageBps":8}}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "malformed",
+			body:       `{"userId":`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "missing user",
+			body:       `{"name":"Momentum","allocations":[{"traderId":"trader-1","weight":0.5}],"riskParams":{"maxLeverage":3}}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "no allocations",
+			body:       `{"userId":"user-1","name":"Momentum","allocations":[],"riskParams":{"maxLeverage":3}}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "weight above one",
+			body:       `{"userId":"user-1","name":"Momentum","allocations":[{"traderId":"trader-1","weight":1.5}],"riskParams":{"maxLeverage":3}}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "weights above one",
+			body:       `{"userId":"user-1","name":"Momentum","allocations":[{"traderId":"trader-1","weight":0.6},{"traderId":"trader-2","weight":0.6}],"riskParams":{"maxLeverage":3}}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantReason: "invalid_allocation",
+		},
+		{
+			name:       "leverage above the service",
+			body:       `{"userId":"user-1","name":"Momentum","allocations":[{"traderId":"trader-1","weight":0.5}],"riskParams":{"maxLeverage":10}}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantReason: "max_leverage",
+		},
+		{
+			name:       "order size below the service",
+			body:       `{"userId":"user-1","name":"Momentum","allocations":[{"traderId":"trader-1","weight":0.5}],"riskParams":{"maxLeverage":3,"minOrderUsd":2}}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantReason: "min_order_size",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, _ := newTestRouter(t, "")
+
+			recorder := serve(router, http.MethodPost, "/api/v1/strategies", tt.body, nil)
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+			if tt.wantReason != "" {
+				var response struct{ Reason string }
+				decode(t, recorder.Body.Bytes(), &response)
+				if response.Reason != tt.wantReason {
+					t.Errorf("reason = %q, want %q", response.Reason, tt.wantReason)
+				}
+			}
+		})
+	}
+}
+
+func TestStrategyLifecycle(t *testing.T) {
+	router, copyEngine := newTestRouter(t, "")
+
+	body := `{"userId":"user-1","name":"Momentum","allocations":[{"traderId":"trader-1","weight":0.5}],"riskParams":{"maxLeverage":3,"slippageBps":8}}`
+	recorder := serve(router, http.MethodPost, "/api/v1/strategies", body, nil)
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("create status = %d: %s", recorder.Code, recorder.Body)
+	}
+	var created strategyResponse
+	decode(t, recorder.Body.Bytes(), &created)
+	if created.Status != string(engine.StatusPaused) || created.RiskParams.SlippageBps != 8 || len(created.Allocations) != 1 {
+		t.Errorf("created %+v, want a paused strategy with the requested parameters", created)
+	}
+	path := "/api/v1/strategies/" + created.ID
+
+	var fetched strategyResponse
+	recorder = serve(router, http.MethodGet, path, "", nil)
+	decode(t, recorder.Body.Bytes(), &fetched)
+	if recorder.Code != http.StatusOK || fetched.ID != created.ID || fetched.Name != "Momentum" {
+		t.Errorf("get returned %d %+v", recorder.Code, fetched)
+	}
+
+	if recorder = serve(router, http.MethodPost, path+"/start", "", nil); recorder.Code != http.StatusAccepted {
+		t.Errorf("start status = %d: %s", recorder.Code, recorder.Body)
+	}
+
+	// Updates are validated before they are queued
+	if recorder = serve(router, http.MethodPut, path, `{"riskParams":{"maxLeverage":10}}`, nil); recorder.Code != http.StatusUnprocessableEntity {
+		t.Errorf("invalid update status = %d, want %d", recorder.Code, http.StatusUnprocessableEntity)
+	}
+	if recorder = serve(router, http.MethodPut, path, `{"name":"Momentum 2","allocations":[{"traderId":"trader-2","weight":1}]}`, nil); recorder.Code != http.StatusAccepted {
+		t.Errorf("update status = %d: %s", recorder.Code, recorder.Body)
+	}
+
+	recorder = serve(router, http.MethodPost, path+"/stop", "", nil)
+	if recorder.Code != http.StatusOK {
+		t.Errorf("stop status = %d: %s", recorder.Code, recorder.Body)
+	}
+	strategy, err := copyEngine.GetStrategy(created.ID)
+	if err != nil || strategy.CurrentStatus() != engine.StatusPaused {
+		t.Errorf("strategy after stop: %v, %v", strategy, err)
+	}
+
+	var listed struct{ Strategies []strategyResponse }
+	decode(t, serve(router, http.MethodGet, "/api/v1/strategies?status=paused", "", nil).Body.Bytes(), &listed)
+	if len(listed.Strategies) != 1 || listed.Strategies[0].ID != created.ID {
+		t.Errorf("paused strategies %+v, want the created one", listed.Strategies)
+	}
+	decode(t, serve(router, http.MethodGet, "/api/v1/strategies?status=active", "", nil).Body.Bytes(), &listed)
+	if len(listed.Strategies) != 0 {
+		t.Errorf("active strategies %+v, want none", listed.Strategies)
+	}
+}
+
+func TestUnknownStrategy(t *testing.T) {
+	router, _ := newTestRouter(t, "")
+
+	requests := []struct{ method, path, body string }{
+		{http.MethodGet, "/api/v1/strategies/missing", ""},
+		{http.MethodGet, "/api/v1/strategies/missing/positions", ""},
+		{http.MethodPut, "/api/v1/strategies/missing", `{"name":"Renamed"}`},
+		{http.MethodPost, "/api/v1/strategies/missing/start", ""},
+		{http.MethodPost, "/api/v1/strategies/missing/stop", ""},
+	}
+	for _, r := range requests {
+		if got := serve(router, r.method, r.path, r.body, nil).Code; got != http.StatusNotFound {
+			t.Errorf("%s %s status = %d, want %d", r.method, r.path, got, http.StatusNotFound)
+		}
+	}
+}
+
+func TestGetStrategyPositions(t *testing.T) {
+	router, copyEngine := newTestRouter(t, "")
+
+	strategy := &engine.Strategy{
+		ID:         "strategy-1",
+		UserID:     "user-1",
+		Name:       "Momentum",
+		Traders:    []engine.TraderAllocation{{TraderID: "trader-1", Weight: 0.5}},
+		RiskParams: engine.RiskParameters{MaxLeverage: 3},
+		Positions: map[string]*engine.Position{
+			"SOL": {Symbol: "SOL", Quantity: -4, EntryPrice: 150, CurrentPrice: 145, UnrealizedPnL: 20},
+			"ETH": {Symbol: "ETH", Quantity: 1.5, EntryPrice: 3000, CurrentPrice: 3100, UnrealizedPnL: 150},
+		},
+	}
+	if err := copyEngine.CreateStrategy(strategy); err != nil {
+		t.Fatalf("CreateStrategy: %v", err)
+	}
+
+	recorder := serve(router, http.MethodGet, "/api/v1/strategies/strategy-1/positions", "", nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", recorder.Code, recorder.Body)
+	}
+	var response struct {
+		StrategyID string
+		Positions  []positionResponse
+	}
+	decode(t, recorder.Body.Bytes(), &response)
+
+	want := []positionResponse{
+		{Symbol: "ETH", Quantity: 1.5, EntryPrice: 3000, CurrentPrice: 3100, UnrealizedPnL: 150},
+		{Symbol: "SOL", Quantity: -4, EntryPrice: 150, CurrentPrice: 145, UnrealizedPnL: 20},
+	}
+	if response.StrategyID != "strategy-1" || len(response.Positions) != len(want) {
+		t.Fatalf("response %+v, want both positions of strategy-1", response)
+	}
+	for i := range want {
+		if response.Positions[i] != want[i] {
+			t.Errorf("position %d = %+v, want %+v", i, response.Positions[i], want[i])
+		}
+	}
+}
+
+func TestGetMetricsReportsRateLimits(t *testing.T) {
+	_, copyEngine := newTestRouter(t, "")
+
+	for _, tt := range []struct {
+		name       string
+		rateLimits RateLimitReporter
+	}{
+		{"without a rate limited adapter", nil},
+		{"with a rate limited adapter", staticRateLimits{
+			Requests: 40, Throttled: 5, Cancelled: 1, WeightSpent: 120,
+			TotalWait: 400 * time.Millisecond, MaxWait: 250 * time.Millisecond, QueuedOrders: 2,
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			router := SetupRouter(copyEngine, tt.rateLimits, "")
+			recorder := serve(router, http.MethodGet, "/api/v1/metrics", "", nil)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", recorder.Code, recorder.Body)
+			}
+
+			var response metricsResponse
+			decode(t, recorder.Body.Bytes(), &response)
+			if tt.rateLimits == nil {
+				if response.RateLimit != nil {
+					t.Errorf("rate limits %+v reported without a reporter", *response.RateLimit)
+				}
+				return
+			}
+			// 400ms over the 4 throttled requests that were not cancelled
+			want := rateLimitResponse{Requests: 40, Throttled: 5, Cancelled: 1, WeightSpent: 120,
+				AverageWaitMs: 100, MaxWaitMs: 250, QueuedOrders: 2}
+			if response.RateLimit == nil || *response.RateLimit != want {
+				t.Errorf("rate limits %+v, want %+v", response.RateLimit, want)
+			}
+		})
+	}
+}
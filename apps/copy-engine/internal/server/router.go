@@ -0,0 +1,60 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperdash/copy-engine/internal/engine"
+	"github.com/hyperdash/copy-engine/internal/exchange"
+)
+
+// RateLimitReporter reports how the exchange adapter spends its request budget
+type RateLimitReporter interface {
+	RateLimitMetrics() exchange.RateLimitMetrics
+}
+
+// SetupRouter builds the HTTP API over the copy trading engine. rateLimits may be
+// nil when the adapter is not rate limited. When apiToken is set, every /api/v1
+// request must carry it as a bearer token; /health stays open.
+func SetupRouter(copyEngine *engine.Engine, rateLimits RateLimitReporter, apiToken string) *gin.Engine {
+	router := gin.New()
+	router.Use(gin.Logger(), gin.Recovery())
+
+	h := &handler{engine: copyEngine, rateLimits: rateLimits}
+
+	router.GET("/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	v1 := router.Group("/api/v1")
+	if apiToken != "" {
+		v1.Use(requireToken(apiToken))
+	}
+	{
+		v1.GET("/metrics", h.getMetrics)
+
+		strategies := v1.Group("/strategies")
+		strategies.GET("", h.listStrategies)
+		strategies.POST("", h.createStrategy)
+		strategies.GET("/:id", h.getStrategy)
+		strategies.PUT("/:id", h.updateStrategy)
+		strategies.POST("/:id/start", h.startStrategy)
+		strategies.POST("/:id/stop", h.stopStrategy)
+		strategies.GET("/:id/positions", h.getStrategyPositions)
+	}
+
+	return router
+}
+
+// requireToken rejects requests that do not carry the API token as a bearer token
+func requireToken(token string) gin.HandlerFunc {
+	expected := []byte("Bearer " + token)
+	return func(c *gin.Context) {
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), expected) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperdash/copy-engine/internal/config"
+	"github.com/hyperdash/copy-engine/internal/engine"
+	"github.com/hyperdash/copy-engine/internal/risk"
+)
+
+// memoryStore accepts every write and holds nothing
+type memoryStore struct {
+	engine.Store
+}
+
+func (s *memoryStore) SaveStrategy(ctx context.Context, strategy *engine.Strategy) error {
+	return nil
+}
+
+func (s *memoryStore) UpdateStrategyStatus(ctx context.Context, strategyID string, status engine.StrategyStatus) error {
+	return nil
+}
+
+func newTestRouter(t *testing.T, apiToken string) (*gin.Engine, *engine.Engine) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{
+		Risk: config.RiskConfig{
+			MaxLeverage:     5,
+			MaxPositionSize: 100000,
+			MaxSlippage:     10,
+			MinOrderSize:    10,
+			MaxDailyLoss:    1000,
+		},
+	}
+	copyEngine := engine.NewEngine(cfg, nil, risk.NewManager(cfg.Risk), &memoryStore{})
+	return SetupRouter(copyEngine, nil, apiToken), copyEngine
+}
+
+func serve(router *gin.Engine, method, path, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestRouterRequiresAPIToken(t *testing.T) {
+	router, _ := newTestRouter(t, "secret")
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		want          int
+	}{
+		{"health is open", "/health", "", http.StatusOK},
+		{"missing token", "/api/v1/strategies", "", http.StatusUnauthorized},
+		{"wrong token", "/api/v1/strategies", "Bearer wrong", http.StatusUnauthorized},
+		{"token without scheme", "/api/v1/strategies", "secret", http.StatusUnauthorized},
+		{"valid token", "/api/v1/strategies", "Bearer secret", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.authorization != "" {
+				header.Set("Authorization", tt.authorization)
+			}
+			if got := serve(router, http.MethodGet, tt.path, "", header).Code; got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouterWithoutTokenIsOpen(t *testing.T) {
+	router, _ := newTestRouter(t, "")
+
+	if got := serve(router, http.MethodGet, "/api/v1/strategies", "", nil).Code; got != http.StatusOK {
+		t.Errorf("status = %d, want %d", got, http.StatusOK)
+	}
+}
+
+func TestCreateStrategyDefaultsMinOrderSize(t *testing.T) {
+	router, _ := newTestRouter(t, "")
+
+	body := `{"userId":"user-1","name":"Momentum","allocations":[{"traderId":"trader-1","weight":0.5}],"riskParams":{"maxLeverage":3}}`
+	recorder := serve(router, http.MethodPost, "/api/v1/strategies", body, nil)
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusCreated, recorder.Body)
+	}
+
+	var response strategyResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.RiskParams.MinOrderUsd != 10 {
+		t.Errorf("minOrderUsd = %v, want the configured minimum 10", response.RiskParams.MinOrderUsd)
+	}
+}
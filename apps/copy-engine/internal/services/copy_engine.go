@@ -2,13 +2,18 @@ package services
 
 import (
 	"context"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"math"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/hyperdash/copy-engine/internal/config"
 	"github.com/hyperdash/copy-engine/internal/database"
+	"github.com/hyperdash/copy-engine/internal/exchange"
 	"github.com/hyperdash/copy-engine/internal/models"
 	"github.com/sirupsen/logrus"
 )
@@ -18,20 +23,31 @@ type CopyEngine interface {
 	Start(ctx context.Context) error
 	Stop() error
 	ProcessTraderTrade(ctx context.Context, trade *models.Trade) error
+	SubmitTrade(ctx context.Context, trade *models.Trade, onProcessed func(error)) error
 	GetRelationshipsForTrader(ctx context.Context, traderID string) ([]*models.CopyRelationship, error)
 	GetRelationshipsForFollower(ctx context.Context, followerID string) ([]*models.CopyRelationship, error)
 	GetPerformanceMetrics(ctx context.Context, relationshipID string) (*models.PerformanceMetrics, error)
 	GetRiskMetrics(ctx context.Context, relationshipID string) (*models.RiskMetrics, error)
+	SetRelationshipStrategy(ctx context.Context, relationshipID string, strategyType models.StrategyType, params models.StrategyParams) (*models.CopyStrategy, error)
+	ListDeadLetters(ctx context.Context, limit int) ([]*models.DeadLetter, error)
+	ReplayDeadLetter(ctx context.Context, deadLetterID string) error
 }
 
 type copyEngine struct {
 	postgres   database.PostgreSQL
 	redis      database.Redis
+	exchange   exchange.Adapter
 	log        *logrus.Logger
+	config     config.EngineConfig
 	strategies map[models.StrategyType]CopyStrategy
 
-	// Event channels
-	tradeChan chan *models.Trade
+	// Event channels; trades are sharded by trader so each trader's fills apply in order
+	tradeShards []chan *tradeJob
+	retryChan   chan *executionAttempt
+
+	// Executions held behind a retrying one, by relationship
+	holds   map[string]*relationshipHold
+	holdsMu sync.Mutex
 
 	// Control
 	ctx    context.Context
@@ -43,28 +59,54 @@ type copyEngine struct {
 	mu      sync.RWMutex
 }
 
+// executionLockTTL bounds how long a crashed worker can block a redelivered trade
+const executionLockTTL = 5 * time.Minute
+
+// shardQueueSize is how many trades a shard buffers before ProcessTraderTrade blocks
+const shardQueueSize = 100
+
 // CopyStrategy interface for different copy strategies
 type CopyStrategy interface {
 	CalculatePositionSize(ctx context.Context, signal *models.CopySignal, originalTrade *models.Trade) (float64, error)
 	ShouldExecute(ctx context.Context, signal *models.CopySignal, originalTrade *models.Trade) (bool, error)
 	GetStrategyType() models.StrategyType
+	ValidateParams(params models.StrategyParams) error
 }
 
-// NewCopyEngine creates a new copy engine instance
-func NewCopyEngine(postgres database.PostgreSQL, redis database.Redis, log *logrus.Logger) CopyEngine {
+// NewCopyEngine creates a new copy engine instance. Copy orders are placed on the
+// adapter with the follower ID as account, so they are signed with the follower's
+// own agent key when the adapter has an AgentKeyStore.
+func NewCopyEngine(postgres database.PostgreSQL, redis database.Redis, adapter exchange.Adapter, cfg config.EngineConfig, log *logrus.Logger) CopyEngine {
 	strategies := make(map[models.StrategyType]CopyStrategy)
+	history := NewHistoryProvider(postgres, redis, log)
 
 	// Register built-in strategies
 	strategies[models.StrategyProportional] = NewProportionalStrategy(log)
 	strategies[models.StrategyFixed] = NewFixedStrategy(log)
-	strategies[models.StrategyAdaptive] = NewAdaptiveStrategy(log)
+	strategies[models.StrategyAdaptive] = NewAdaptiveStrategy(log, history)
+	strategies[models.StrategyRiskBased] = NewRiskBasedStrategy(log, postgres)
+	strategies[models.StrategyMartingale] = NewMartingaleStrategy(log, history)
+	strategies[models.StrategyAntiMartingale] = NewAntiMartingaleStrategy(log, history)
+
+	workers := cfg.MaxConcurrency
+	if workers < 1 {
+		workers = 1
+	}
+	tradeShards := make([]chan *tradeJob, workers)
+	for i := range tradeShards {
+		tradeShards[i] = make(chan *tradeJob, shardQueueSize)
+	}
 
 	return &copyEngine{
-		postgres:   postgres,
-		redis:      redis,
-		log:        log,
-		strategies: strategies,
-		tradeChan:  make(chan *models.Trade, 1000),
+		postgres:    postgres,
+		redis:       redis,
+		exchange:    adapter,
+		log:         log,
+		config:      cfg,
+		strategies:  strategies,
+		tradeShards: tradeShards,
+		retryChan:   make(chan *executionAttempt, 100),
+		holds:       make(map[string]*relationshipHold),
 	}
 }
 
@@ -78,14 +120,29 @@ func (ce *copyEngine) Start(ctx context.Context) error {
 
 	ce.ctx, ce.cancel = context.WithCancel(ctx)
 
-	// Start trade processor
-	ce.wg.Add(1)
-	go ce.tradeProcessor()
+	// Start one trade processor per shard
+	for _, shard := range ce.tradeShards {
+		ce.wg.Add(1)
+		go ce.tradeProcessor(shard)
+	}
 
 	// Start metrics calculator
 	ce.wg.Add(1)
 	go ce.metricsCalculator()
 
+	// Start retry processor and requeue executions interrupted by the last shutdown
+	ce.wg.Add(1)
+	go ce.retryProcessor()
+
+	ce.wg.Add(1)
+	go ce.staleExecutionMonitor()
+
+	recoverCtx, cancel := context.WithTimeout(ce.ctx, 30*time.Second)
+	defer cancel()
+	if err := ce.recoverRetries(recoverCtx); err != nil {
+		ce.log.Errorf("Failed to recover pending retries: %v", err)
+	}
+
 	ce.running = true
 	ce.log.Info("Copy engine started")
 
@@ -101,7 +158,6 @@ func (ce *copyEngine) Stop() error {
 	}
 
 	ce.cancel()
-	close(ce.tradeChan)
 
 	// Wait for all goroutines to finish
 	done := make(chan struct{})
@@ -121,21 +177,47 @@ func (ce *copyEngine) Stop() error {
 	return nil
 }
 
+// tradeJob is a queued trader trade and the callback to run once it was processed
+type tradeJob struct {
+	trade       *models.Trade
+	onProcessed func(error)
+}
+
 func (ce *copyEngine) ProcessTraderTrade(ctx context.Context, trade *models.Trade) error {
+	return ce.SubmitTrade(ctx, trade, nil)
+}
+
+// SubmitTrade queues a trader trade like ProcessTraderTrade and calls onProcessed,
+// if set, once every relationship has been handled. A nil error means the trade no
+// longer needs to be redelivered.
+func (ce *copyEngine) SubmitTrade(ctx context.Context, trade *models.Trade, onProcessed func(error)) error {
 	if !ce.running {
 		return fmt.Errorf("copy engine is not running")
 	}
 
+	if trade.TraderID == nil {
+		return fmt.Errorf("trade has no trader ID")
+	}
+
+	// Block until the trader's shard has room so a slow shard pushes back on the
+	// producer instead of dropping or reordering its trades
 	select {
-	case ce.tradeChan <- trade:
+	case ce.shardFor(*trade.TraderID) <- &tradeJob{trade: trade, onProcessed: onProcessed}:
 		return nil
 	case <-ctx.Done():
 		return ctx.Err()
-	case <-time.After(5 * time.Second):
-		return fmt.Errorf("trade channel timeout")
+	case <-ce.ctx.Done():
+		return fmt.Errorf("copy engine is stopping")
 	}
 }
 
+// shardFor returns the queue that serializes all trades of a trader
+func (ce *copyEngine) shardFor(traderID string) chan *tradeJob {
+	h := fnv.New32a()
+	h.Write([]byte(traderID))
+	return ce.tradeShards[h.Sum32()%uint32(len(ce.tradeShards))]
+}
+
 func (ce *copyEngine) GetRelationshipsForTrader(ctx context.Context, traderID string) ([]*models.CopyRelationship, error) {
 	return ce.postgres.GetCopyRelationshipsByTrader(ctx, traderID)
 }
@@ -186,20 +268,56 @@ func (ce *copyEngine) GetRiskMetrics(ctx context.Context, relationshipID string)
 	return metrics, nil
 }
 
-func (ce *copyEngine) tradeProcessor() {
+// SetRelationshipStrategy validates and stores the sizing strategy a relationship copies with,
+// replacing any previously active strategy
+func (ce *copyEngine) SetRelationshipStrategy(ctx context.Context, relationshipID string, strategyType models.StrategyType, params models.StrategyParams) (*models.CopyStrategy, error) {
+	strategy, exists := ce.strategies[strategyType]
+	if !exists {
+		return nil, fmt.Errorf("unsupported strategy type: %s", strategyType)
+	}
+
+	if params == nil {
+		params = make(models.StrategyParams)
+	}
+	if err := strategy.ValidateParams(params); err != nil {
+		return nil, fmt.Errorf("invalid %s parameters: %w", strategyType, err)
+	}
+
+	now := time.Now()
+	copyStrategy := &models.CopyStrategy{
+		ID:             uuid.New().String(),
+		RelationshipID: relationshipID,
+		Name:           string(strategyType),
+		StrategyType:   strategyType,
+		Parameters:     params,
+		IsActive:       true,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	if err := ce.postgres.SaveCopyStrategy(ctx, copyStrategy); err != nil {
+		return nil, fmt.Errorf("failed to save copy strategy: %w", err)
+	}
+
+	ce.log.Infof("Relationship %s now copies with %s strategy", relationshipID, strategyType)
+	return copyStrategy, nil
+}
+
+// tradeProcessor applies the trades of one shard one at a time, in arrival order
+func (ce *copyEngine) tradeProcessor(shard <-chan *tradeJob) {
 	defer ce.wg.Done()
 
 	for {
 		select {
 		case <-ce.ctx.Done():
 			return
-		case trade, ok := <-ce.tradeChan:
-			if !ok {
-				return
+		case job := <-shard:
+			err := ce.processTrade(job.trade)
+			if err != nil {
+				ce.log.Errorf("Failed to process trade %s: %v", job.trade.ID, err)
 			}
-
-			if err := ce.processTrade(trade); err != nil {
-				ce.log.Errorf("Failed to process trade %s: %v", trade.ID, err)
+			if job.onProcessed != nil {
+				job.onProcessed(err)
 			}
 		}
 	}
@@ -219,25 +337,42 @@ func (ce *copyEngine) processTrade(trade *models.Trade) error {
 		return fmt.Errorf("failed to get copy relationships: %w", err)
 	}
 
-	// Process each relationship concurrently
-	var wg sync.WaitGroup
+	// Relationships are copied in turn so that a trader's next fill is never applied
+	// to a follower before this one; concurrency comes from the shards. A relationship
+	// that failed before its execution was claimed makes the whole trade fail, so a
+	// redelivery retries it while the claimed ones are skipped as duplicates.
+	var errs []error
 	for _, relationship := range relationships {
-		wg.Add(1)
-		go func(rel *models.CopyRelationship) {
-			defer wg.Done()
-
-			if err := ce.processRelationship(ctx, rel, trade); err != nil {
-				ce.log.Errorf("Failed to process relationship %s for trade %s: %v",
-					rel.ID, trade.ID, err)
-			}
-		}(relationship)
+		relCtx, relCancel := context.WithTimeout(ce.ctx, 30*time.Second)
+		if err := ce.processRelationship(relCtx, relationship, trade); err != nil {
+			ce.log.Errorf("Failed to process relationship %s for trade %s: %v",
+				relationship.ID, trade.ID, err)
+			errs = append(errs, fmt.Errorf("relationship %s: %w", relationship.ID, err))
+		}
+		relCancel()
 	}
 
-	wg.Wait()
-	return nil
+	return errors.Join(errs...)
 }
 
 func (ce *copyEngine) processRelationship(ctx context.Context, relationship *models.CopyRelationship, trade *models.Trade) error {
+	// Only one worker may copy a given trade for a relationship at a time. The database
+	// constraint on the execution row is what makes this hold across redeliveries.
+	lockKey := executionLockKey(trade.ID, relationship.ID)
+	locked, err := ce.redis.SetLock(ctx, lockKey, executionLockTTL)
+	if err != nil {
+		ce.log.Warnf("Failed to acquire execution lock %s, relying on database constraint: %v", lockKey, err)
+	} else if !locked {
+		ce.log.Debugf("Trade %s is already being copied for relationship %s", trade.ID, relationship.ID)
+		return nil
+	} else {
+		defer func() {
+			if err := ce.redis.ReleaseLock(context.Background(), lockKey); err != nil {
+				ce.log.Warnf("Failed to release execution lock %s: %v", lockKey, err)
+			}
+		}()
+	}
+
 	// Check if we should execute copy for this relationship
 	shouldExecute, err := ce.shouldExecuteCopy(ctx, relationship, trade)
 	if err != nil {
@@ -249,20 +384,23 @@ func (ce *copyEngine) processRelationship(ctx context.Context, relationship *mod
 		return nil
 	}
 
-	// Create copy signal
+	// Get the strategy configured for this relationship
+	strategy, params, err := ce.resolveStrategy(ctx, relationship)
+	if err != nil {
+		return err
+	}
+
+	// Create copy signal carrying the strategy parameters used for sizing
 	signal := &models.CopySignal{
 		ID:            uuid.New().String(),
 		Relationship:  relationship,
 		OriginalTrade: trade,
 		SignalType:    ce.determineSignalType(trade),
-		Parameters:    make(map[string]interface{}),
+		Parameters:    make(map[string]interface{}, len(params)),
 		CreatedAt:     time.Now(),
 	}
-
-	// Get the strategy for this relationship
-	strategy, exists := ce.strategies[models.StrategyProportional] // Default to proportional
-	if !exists {
-		return fmt.Errorf("strategy not found: %s", models.StrategyProportional)
+	for key, value := range params {
+		signal.Parameters[key] = value
 	}
 
 	// Check if strategy says we should execute
@@ -284,43 +422,135 @@ func (ce *copyEngine) processRelationship(ctx context.Context, relationship *mod
 
 	// Create copy execution
 	execution := &models.CopyExecution{
-		ID:           uuid.New().String(),
-		SignalID:     signal.ID,
-		Relationship: relationship,
-		Status:       models.StatusPending,
+		ID:              uuid.New().String(),
+		SignalID:        signal.ID,
+		OriginalTradeID: trade.ID,
+		Relationship:    relationship,
+		Status:          models.StatusPending,
 		Parameters: map[string]interface{}{
 			"calculated_size": positionSize,
 			"original_size":   trade.Size,
 			"allocation_pct":  relationship.AllocationPercent,
+			"strategy_type":   string(strategy.GetStrategyType()),
+			"original_trade":  trade,
 		},
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
+	// Claim the execution before copying; a trade that was already copied for this
+	// relationship is rejected by the unique constraint
+	if err := ce.postgres.CreateCopyExecution(ctx, execution); err != nil {
+		if errors.Is(err, database.ErrDuplicate) {
+			ce.log.Infof("Trade %s was already copied for relationship %s, skipping", trade.ID, relationship.ID)
+			return nil
+		}
+		return fmt.Errorf("failed to create copy execution record: %w", err)
+	}
+	ce.cacheExecutionStatus(ctx, execution)
+
 	// Store signal in Redis for monitoring
 	if err := ce.redis.SetCopySignal(ctx, signal); err != nil {
 		ce.log.Warnf("Failed to store copy signal: %v", err)
 	}
 
-	// Execute the copy trade
-	if err := ce.executeCopyTrade(ctx, execution, trade, positionSize); err != nil {
-		execution.Status = models.StatusFailed
-		execution.ErrorMessage = new(string)
-		*execution.ErrorMessage = err.Error()
-		ce.log.Errorf("Failed to execute copy trade: %v", err)
-	} else {
-		execution.Status = models.StatusCompleted
-		ce.log.Infof("Successfully executed copy trade for relationship %s", relationship.ID)
+	// Execute the copy trade, retrying transient failures in the background. While an
+	// earlier trade of the relationship awaits a retry, this one waits behind it.
+	attempt := &executionAttempt{
+		execution:     execution,
+		originalTrade: trade,
+		copySize:      positionSize,
+	}
+	if ce.queueBehindRetry(attempt) {
+		ce.log.Infof("Holding copy execution %s until relationship %s's earlier execution is settled",
+			execution.ID, relationship.ID)
+		return nil
 	}
+	ce.attemptExecution(ctx, attempt)
+	return nil
+}
 
-	// Update execution status
-	if err := ce.postgres.CreateCopyExecution(ctx, execution); err != nil {
-		ce.log.Errorf("Failed to create copy execution record: %v", err)
+// transitionExecution moves an execution to a new status through the state machine.
+// The database is updated first and is the source of truth; the Redis status only
+// ever follows a committed transition.
+func (ce *copyEngine) transitionExecution(ctx context.Context, execution *models.CopyExecution, to models.ExecutionStatus, reason string, events ...*models.OutboxEvent) error {
+	if !execution.Status.CanTransitionTo(to) {
+		return fmt.Errorf("%w: %s -> %s", models.ErrInvalidTransition, execution.Status, to)
 	}
+	return ce.applyTransition(ctx, execution, to, reason, func(transition *models.ExecutionTransition) error {
+		return ce.postgres.TransitionCopyExecution(ctx, execution, transition, events...)
+	})
+}
 
+// applyTransition moves an execution to a status already checked against the state
+// machine. commit stores the transition, along with anything that must change with it.
+func (ce *copyEngine) applyTransition(ctx context.Context, execution *models.CopyExecution, to models.ExecutionStatus, reason string, commit func(transition *models.ExecutionTransition) error) error {
+	from := execution.Status
+	now := time.Now()
+	transition := &models.ExecutionTransition{
+		ID:          uuid.New().String(),
+		ExecutionID: execution.ID,
+		FromStatus:  from,
+		ToStatus:    to,
+		Reason:      reason,
+		CreatedAt:   now,
+	}
+
+	execution.Status = to
+	execution.UpdatedAt = now
+	if err := commit(transition); err != nil {
+		execution.Status = from
+		return err
+	}
+
+	ce.cacheExecutionStatus(ctx, execution)
 	return nil
 }
 
+// cacheExecutionStatus mirrors a committed status into Redis. If the write fails the
+// stale key is removed so readers fall back to the database rather than a wrong status.
+func (ce *copyEngine) cacheExecutionStatus(ctx context.Context, execution *models.CopyExecution) {
+	if err := ce.redis.SetExecutionStatus(ctx, execution.ID, execution.Status); err != nil {
+		ce.log.Warnf("Failed to cache status of execution %s: %v", execution.ID, err)
+		if err := ce.redis.DeleteExecutionStatus(ctx, execution.ID); err != nil {
+			ce.log.Warnf("Failed to clear cached status of execution %s: %v", execution.ID, err)
+		}
+	}
+}
+
+// executionLockKey identifies the copy of one trader trade for one relationship
+func executionLockKey(tradeID, relationshipID string) string {
+	return fmt.Sprintf("copy_execution:%s:%s", tradeID, relationshipID)
+}
+
+// resolveStrategy loads the active CopyStrategy configured for a relationship and
+// returns the matching registered implementation with its parameters. Relationships
+// without a configured strategy fall back to proportional sizing.
+func (ce *copyEngine) resolveStrategy(ctx context.Context, relationship *models.CopyRelationship) (CopyStrategy, models.StrategyParams, error) {
+	strategyType := models.StrategyProportional
+	params := make(models.StrategyParams)
+
+	configured, err := ce.postgres.GetActiveCopyStrategy(ctx, relationship.ID)
+	switch {
+	case err == nil:
+		strategyType = configured.StrategyType
+		if configured.Parameters != nil {
+			params = configured.Parameters
+		}
+	case errors.Is(err, database.ErrNotFound):
+		ce.log.Debugf("No copy strategy configured for relationship %s, using %s", relationship.ID, strategyType)
+	default:
+		return nil, nil, fmt.Errorf("failed to load copy strategy: %w", err)
+	}
+
+	strategy, exists := ce.strategies[strategyType]
+	if !exists {
+		return nil, nil, fmt.Errorf("strategy not found: %s", strategyType)
+	}
+
+	return strategy, params, nil
+}
+
 func (ce *copyEngine) shouldExecuteCopy(ctx context.Context, relationship *models.CopyRelationship, trade *models.Trade) (bool, error) {
 	// Check basic relationship criteria
 	if !relationship.IsActive {
@@ -344,8 +574,10 @@ func (ce *copyEngine) shouldExecuteCopy(ctx context.Context, relationship *model
 	} else {
 		// Check if current exposure exceeds limits
 		if riskMetrics.CurrentExposure > riskMetrics.MaxExposure {
-			ce.log.Warnf("Current exposure (%.2f) exceeds max exposure (%.2f) for relationship %s",
-				riskMetrics.CurrentExposure, riskMetrics.MaxExposure, relationship.ID)
+			message := fmt.Sprintf("current exposure (%.2f) exceeds max exposure (%.2f)",
+				riskMetrics.CurrentExposure, riskMetrics.MaxExposure)
+			ce.log.Warnf("Not copying trade %s for relationship %s: %s", trade.ID, relationship.ID, message)
+			ce.reportRiskBreach(ctx, relationship, "max_exposure", message)
 			return false, nil
 		}
 	}
@@ -353,6 +585,22 @@ func (ce *copyEngine) shouldExecuteCopy(ctx context.Context, relationship *model
 	return true, nil
 }
 
+// reportRiskBreach emits a risk.breach event for a relationship whose trade was not copied
+func (ce *copyEngine) reportRiskBreach(ctx context.Context, relationship *models.CopyRelationship, reason, message string) {
+	event, err := models.NewOutboxEvent(models.EventRiskBreach, relationship.ID, models.RiskBreachEventData{
+		RelationshipID: relationship.ID,
+		FollowerID:     relationship.FollowerID,
+		Reason:         reason,
+		Message:        message,
+	})
+	if err == nil {
+		err = ce.postgres.EnqueueOutboxEvents(ctx, event)
+	}
+	if err != nil {
+		ce.log.Errorf("Failed to record risk breach for relationship %s: %v", relationship.ID, err)
+	}
+}
+
 func (ce *copyEngine) determineSignalType(trade *models.Trade) models.SignalType {
 	// Simple logic: determine signal type based on trade characteristics
 	if trade.PositionID != nil {
@@ -369,10 +617,46 @@ func (ce *copyEngine) determineSignalType(trade *models.Trade) models.SignalType
 }
 
 func (ce *copyEngine) executeCopyTrade(ctx context.Context, execution *models.CopyExecution, originalTrade *models.Trade, copySize float64) error {
-	// Update execution status
-	execution.Status = models.StatusExecuting
-	if err := ce.postgres.UpdateCopyExecution(ctx, execution); err != nil {
-		ce.log.Warnf("Failed to update execution status: %v", err)
+	// Never copy unless this worker moved the execution into executing
+	if err := ce.transitionExecution(ctx, execution, models.StatusExecuting, "copying trade"); err != nil {
+		return fmt.Errorf("failed to start execution: %w", err)
+	}
+
+	// Place the order on the follower's account, unless an earlier attempt already
+	// filled it and only failed to record the trade
+	filledSize := paramFloat(execution.Parameters, "filled_size", 0)
+	avgPrice := paramFloat(execution.Parameters, "avg_price", 0)
+	realizedPnL := paramFloat(execution.Parameters, "realized_pnl", 0)
+	if filledSize <= 0 {
+		side := exchange.SideBuy
+		if originalTrade.Side == models.TradeSell {
+			side = exchange.SideSell
+		}
+		result, err := ce.exchange.PlaceOrder(ctx, &exchange.OrderRequest{
+			Account:       execution.Relationship.FollowerID,
+			Symbol:        originalTrade.TokenSymbol,
+			Side:          side,
+			Type:          exchange.OrderTypeMarket,
+			Size:          copySize,
+			Price:         originalTrade.Price,
+			ClientOrderID: clientOrderID(execution.ID),
+		})
+		if err != nil && orderMayHaveBeenSent(err) {
+			result, err = ce.findPlacedOrder(execution, err)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to place copy order: %w", err)
+		}
+		if result.FilledSize <= 0 {
+			return fmt.Errorf("copy order %d was not filled", result.OrderID)
+		}
+
+		filledSize, avgPrice = result.FilledSize, result.AvgPrice
+		realizedPnL = ce.closedPnL(ctx, execution, result.OrderID)
+		execution.Parameters["order_id"] = result.OrderID
+		execution.Parameters["filled_size"] = filledSize
+		execution.Parameters["avg_price"] = avgPrice
+		execution.Parameters["realized_pnl"] = realizedPnL
 	}
 
 	// Create the copy trade
@@ -383,12 +667,12 @@ func (ce *copyEngine) executeCopyTrade(ctx context.Context, execution *models.Co
 		PositionID:         originalTrade.PositionID,
 		TokenSymbol:        originalTrade.TokenSymbol,
 		Side:               originalTrade.Side,
-		Size:               copySize,
-		Price:              originalTrade.Price,
-		Fee:                originalTrade.Fee * (copySize / originalTrade.Size), // Scale fee proportionally
-		RealizedPnL:        0,                                                   // Will be calculated when position is closed
-		TransactionHash:    nil,                                                 // Will be set by blockchain integration
-		BlockNumber:        nil,                                                 // Will be set by blockchain integration
+		Size:               filledSize,
+		Price:              avgPrice,
+		Fee:                originalTrade.Fee * (filledSize / originalTrade.Size), // Scale fee proportionally
+		RealizedPnL:        realizedPnL,
+		TransactionHash:    nil, // Will be set by blockchain integration
+		BlockNumber:        nil, // Will be set by blockchain integration
 		CreatedAt:          time.Now(),
 		IsCopyTrade:        true,
 		CopyRelationshipID: &execution.Relationship.ID,
@@ -406,6 +690,63 @@ func (ce *copyEngine) executeCopyTrade(ctx context.Context, execution *models.Co
 	return nil
 }
 
+// closedPnL sums the PnL the exchange realized on the fills of an execution's order,
+// which is non-zero when the copy reduced or closed the follower's position. A failed
+// lookup only costs the trade its PnL, so it does not fail the execution.
+func (ce *copyEngine) closedPnL(ctx context.Context, execution *models.CopyExecution, orderID int64) float64 {
+	// The order cannot predate its execution
+	fills, err := ce.exchange.GetFills(ctx, execution.Relationship.FollowerID, execution.CreatedAt)
+	if err != nil {
+		ce.log.Warnf("Failed to get fills of copy order %d: %v", orderID, err)
+		return 0
+	}
+
+	var pnl float64
+	for _, fill := range fills {
+		if fill.OrderID == orderID {
+			pnl += fill.ClosedPnL
+		}
+	}
+	return pnl
+}
+
+// findPlacedOrder asks the exchange what became of an order whose placement failed
+// without an exchange response, e.g. on a timeout. The order may have been received,
+// so it is only placed again once the exchange confirms it never saw it.
+func (ce *copyEngine) findPlacedOrder(execution *models.CopyExecution, placeErr error) (*exchange.OrderResult, error) {
+	// The attempt's context may be what expired
+	ctx, cancel := context.WithTimeout(ce.ctx, 10*time.Second)
+	defer cancel()
+
+	result, err := ce.exchange.GetOrderStatus(ctx, execution.Relationship.FollowerID, clientOrderID(execution.ID))
+	switch {
+	case err == nil:
+		ce.log.Warnf("Copy order of execution %s reached the exchange despite %v", execution.ID, placeErr)
+		return result, nil
+	case errors.Is(err, exchange.ErrOrderNotFound):
+		return nil, placeErr
+	default:
+		return nil, fmt.Errorf("%w: %v, and its status lookup failed: %v", errOrderOutcomeUnknown, placeErr, err)
+	}
+}
+
+// orderMayHaveBeenSent reports whether a failed order placement may still have
+// reached the exchange: the error would be retried but is not an exchange response
+func orderMayHaveBeenSent(err error) bool {
+	var apiErr *exchange.APIError
+	return isRetryable(err) && !errors.As(err, &apiErr)
+}
+
+// clientOrderID derives the exchange client order ID of an execution's order, so
+// the order can be traced back to the execution it was placed for
+func clientOrderID(executionID string) string {
+	id, err := uuid.Parse(executionID)
+	if err != nil {
+		return ""
+	}
+	return "0x" + hex.EncodeToString(id[:])
+}
+
 func (ce *copyEngine) metricsCalculator() {
 	defer ce.wg.Done()
 
@@ -665,6 +1006,11 @@ func (ce *copyEngine) calculateVaR(positions []*models.Position) float64 {
 }
 
 func (ce *copyEngine) calculateConcentrationRisk(positions []*models.Position) float64 {
+	return concentrationRisk(positions)
+}
+
+// concentrationRisk returns the Herfindahl-Hirschman Index of position values by symbol
+func concentrationRisk(positions []*models.Position) float64 {
 	if len(positions) == 0 {
 		return 0
 	}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hyperdash/copy-engine/internal/database"
+	"github.com/hyperdash/copy-engine/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// historyLookback bounds how many closed trades are inspected for the current streak
+const historyLookback = 50
+
+// PerformanceHistory summarises the realized results a copy relationship has seen
+type PerformanceHistory struct {
+	RelationshipID    string
+	WinRate           float64 // share of decided (non-breakeven) trades that won
+	DecidedTrades     int
+	ConsecutiveWins   int
+	ConsecutiveLosses int
+}
+
+// HistoryProvider gives strategies access to a relationship's performance history
+type HistoryProvider interface {
+	GetHistory(ctx context.Context, relationship *models.CopyRelationship) (*PerformanceHistory, error)
+}
+
+type historyProvider struct {
+	postgres database.PostgreSQL
+	redis    database.Redis
+	log      *logrus.Logger
+}
+
+// NewHistoryProvider creates a history provider backed by trades and performance_metrics
+func NewHistoryProvider(postgres database.PostgreSQL, redis database.Redis, log *logrus.Logger) HistoryProvider {
+	return &historyProvider{
+		postgres: postgres,
+		redis:    redis,
+		log:      log,
+	}
+}
+
+// GetHistory returns the relationship's win rate and current streak. The streak comes from
+// the relationship's own closed copy trades, falling back to the followed trader's closed
+// trades while the relationship has none. The win rate prefers the stored performance
+// metrics and falls back to the same trade sample.
+func (h *historyProvider) GetHistory(ctx context.Context, relationship *models.CopyRelationship) (*PerformanceHistory, error) {
+	trades, err := h.postgres.GetClosedTradesByRelationship(ctx, relationship.ID, historyLookback)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get relationship trades: %w", err)
+	}
+
+	if len(trades) == 0 {
+		trades, err = h.postgres.GetClosedTradesByTrader(ctx, relationship.TraderID, historyLookback)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get trader trades: %w", err)
+		}
+	}
+
+	history := &PerformanceHistory{RelationshipID: relationship.ID}
+	history.ConsecutiveWins, history.ConsecutiveLosses = currentStreak(trades)
+
+	metrics, err := h.performanceMetrics(ctx, relationship.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if metrics != nil && metrics.WinningTrades+metrics.LosingTrades > 0 {
+		history.DecidedTrades = metrics.WinningTrades + metrics.LosingTrades
+		history.WinRate = float64(metrics.WinningTrades) / float64(history.DecidedTrades)
+	} else {
+		var wins int
+		for _, trade := range trades {
+			switch {
+			case trade.RealizedPnL > 0:
+				wins++
+				history.DecidedTrades++
+			case trade.RealizedPnL < 0:
+				history.DecidedTrades++
+			}
+		}
+		if history.DecidedTrades > 0 {
+			history.WinRate = float64(wins) / float64(history.DecidedTrades)
+		}
+	}
+
+	return history, nil
+}
+
+// performanceMetrics reads cached metrics first, returning nil when none have been computed yet
+func (h *historyProvider) performanceMetrics(ctx context.Context, relationshipID string) (*models.PerformanceMetrics, error) {
+	if metrics, err := h.redis.GetPerformanceMetrics(ctx, relationshipID); err == nil {
+		return metrics, nil
+	}
+
+	metrics, err := h.postgres.GetPerformanceMetrics(ctx, relationshipID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get performance metrics: %w", err)
+	}
+
+	return metrics, nil
+}
+
+// currentStreak counts the run of same-signed results at the head of trades (newest first)
+func currentStreak(trades []*models.Trade) (wins, losses int) {
+	for _, trade := range trades {
+		switch {
+		case trade.RealizedPnL > 0 && losses == 0:
+			wins++
+		case trade.RealizedPnL < 0 && wins == 0:
+			losses++
+		default:
+			return wins, losses
+		}
+	}
+
+	return wins, losses
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/hyperdash/copy-engine/internal/database"
+	"github.com/hyperdash/copy-engine/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// fakeHistoryStore serves closed trades by relationship and trader without any
+// computed performance metrics
+type fakeHistoryStore struct {
+	database.PostgreSQL
+
+	relationshipTrades []*models.Trade
+	traderTrades       []*models.Trade
+}
+
+func (f *fakeHistoryStore) GetClosedTradesByRelationship(ctx context.Context, relationshipID string, limit int) ([]*models.Trade, error) {
+	return f.relationshipTrades, nil
+}
+
+func (f *fakeHistoryStore) GetClosedTradesByTrader(ctx context.Context, traderID string, limit int) ([]*models.Trade, error) {
+	return f.traderTrades, nil
+}
+
+func (f *fakeHistoryStore) GetPerformanceMetrics(ctx context.Context, relationshipID string) (*models.PerformanceMetrics, error) {
+	return nil, database.ErrNotFound
+}
+
+type emptyMetricsCache struct {
+	database.Redis
+}
+
+func (emptyMetricsCache) GetPerformanceMetrics(ctx context.Context, relationshipID string) (*models.PerformanceMetrics, error) {
+	return nil, database.ErrNotFound
+}
+
+func tradesWithPnL(pnls ...float64) []*models.Trade {
+	trades := make([]*models.Trade, len(pnls))
+	for i, pnl := range pnls {
+		trades[i] = &models.Trade{ID: "trade", RealizedPnL: pnl}
+	}
+	return trades
+}
+
+func TestGetHistoryFromClosedTrades(t *testing.T) {
+	tests := []struct {
+		name        string
+		store       *fakeHistoryStore
+		wantWins    int
+		wantLosses  int
+		wantDecided int
+		wantWinRate float64
+	}{
+		{
+			name:        "relationship trades",
+			store:       &fakeHistoryStore{relationshipTrades: tradesWithPnL(12, 4.5, -3, 0, 8), traderTrades: tradesWithPnL(-1)},
+			wantWins:    2,
+			wantDecided: 4,
+			wantWinRate: 0.75,
+		},
+		{
+			name:        "trader trades while the relationship has none",
+			store:       &fakeHistoryStore{traderTrades: tradesWithPnL(-2, -1, 5)},
+			wantLosses:  2,
+			wantDecided: 3,
+			wantWinRate: 1.0 / 3,
+		},
+		{
+			name:  "no decided trades",
+			store: &fakeHistoryStore{traderTrades: tradesWithPnL(0, 0)},
+		},
+	}
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	relationship := &models.CopyRelationship{ID: "relationship-1", TraderID: "trader-1"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history, err := NewHistoryProvider(tt.store, emptyMetricsCache{}, log).GetHistory(context.Background(), relationship)
+			if err != nil {
+				t.Fatalf("GetHistory: %v", err)
+			}
+			if history.ConsecutiveWins != tt.wantWins || history.ConsecutiveLosses != tt.wantLosses {
+				t.Errorf("streak %d wins, %d losses, want %d and %d",
+					history.ConsecutiveWins, history.ConsecutiveLosses, tt.wantWins, tt.wantLosses)
+			}
+			if history.DecidedTrades != tt.wantDecided || history.WinRate != tt.wantWinRate {
+				t.Errorf("win rate %v of %d decided trades, want %v of %d",
+					history.WinRate, history.DecidedTrades, tt.wantWinRate, tt.wantDecided)
+			}
+		})
+	}
+}
@@ -0,0 +1,514 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hyperdash/copy-engine/internal/config"
+	"github.com/hyperdash/copy-engine/internal/database"
+	"github.com/hyperdash/copy-engine/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// dedupWindow is how long a trade ID is remembered to suppress redelivered events
+const dedupWindow = 10 * time.Minute
+
+// streamReadBlock bounds how long a stream read waits for new events
+const streamReadBlock = 2 * time.Second
+
+// TradeIngestor feeds trader fills published on Redis or Kafka, or streamed from the
+// exchange, into the copy engine
+type TradeIngestor interface {
+	Start(ctx context.Context) error
+	Stop() error
+	GetMetrics() IngestionMetrics
+}
+
+// IngestionMetrics describes the health of trade event ingestion
+type IngestionMetrics struct {
+	Received     uint64        // events read from the subscription or stream
+	Processed    uint64        // trades handed to the copy engine
+	Acked        uint64        // stream events acknowledged or Kafka offsets committed after processing
+	Reclaimed    uint64        // stream events taken over from a consumer that did not acknowledge them
+	Duplicates   uint64        // events for a trade already ingested within the dedup window
+	Invalid      uint64        // events that could not be converted into a trader trade
+	Failed       uint64        // trades the copy engine refused or failed to process
+	DeadLettered uint64        // trades set aside after failing MaxAttempts times
+	Dropped      uint64        // pub/sub events lost before ingestion because the subscriber fell behind
+	OrderUpdates uint64        // trader order updates seen on the exchange websocket
+	LastLag      time.Duration // delay between the event timestamp and ingestion
+	MaxLag       time.Duration
+}
+
+type tradeIngestor struct {
+	redis    database.Redis
+	kafka    KafkaReader
+	stream   FillStream
+	postgres database.PostgreSQL
+	engine   CopyEngine
+	config   config.IngestionConfig
+	log      *logrus.Logger
+
+	seen     map[string]time.Time
+	inFlight map[string]struct{}       // stream entries handed to the engine and not yet acknowledged
+	offsets  *offsetTracker            // Kafka messages not yet committed
+	traders  map[string]string         // trader ID per subscribed websocket address
+	queues   map[string][]*queuedTrade // Kafka and websocket trades per trader, the first one submitted
+	metrics  IngestionMetrics
+	mu       sync.Mutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	running bool
+	runMu   sync.Mutex
+}
+
+// NewTradeIngestor creates an ingestor reading trade_events over the configured transport
+func NewTradeIngestor(redis database.Redis, engine CopyEngine, cfg config.IngestionConfig, log *logrus.Logger) TradeIngestor {
+	return &tradeIngestor{
+		redis:    redis,
+		engine:   engine,
+		config:   cfg,
+		log:      log,
+		seen:     make(map[string]time.Time),
+		inFlight: make(map[string]struct{}),
+	}
+}
+
+func (i *tradeIngestor) Start(ctx context.Context) error {
+	i.runMu.Lock()
+	defer i.runMu.Unlock()
+
+	if i.running {
+		return fmt.Errorf("trade ingestor is already running")
+	}
+
+	i.ctx, i.cancel = context.WithCancel(ctx)
+
+	switch i.config.Transport {
+	case "kafka":
+		if i.kafka == nil {
+			i.cancel()
+			return fmt.Errorf("kafka transport requires a kafka reader")
+		}
+
+		i.wg.Add(1)
+		go i.runKafka()
+	case "websocket":
+		if i.stream == nil {
+			i.cancel()
+			return fmt.Errorf("websocket transport requires a fill stream")
+		}
+		if i.config.RefreshInterval <= 0 {
+			i.cancel()
+			return fmt.Errorf("trader refresh interval must be positive")
+		}
+		if err := i.refreshTraders(); err != nil {
+			i.cancel()
+			return fmt.Errorf("failed to load subscribed traders: %w", err)
+		}
+
+		i.wg.Add(1)
+		go i.runWebSocket()
+	case "streams":
+		if i.config.ClaimIdle <= 0 {
+			i.cancel()
+			return fmt.Errorf("claim idle time must be positive")
+		}
+		if err := i.redis.EnsureTradeStreamGroup(i.ctx, i.config.ConsumerGroup); err != nil {
+			i.cancel()
+			return err
+		}
+
+		i.wg.Add(1)
+		go i.runStream()
+	default:
+		events, err := i.redis.SubscribeToTradeEvents(i.ctx)
+		if err != nil {
+			i.cancel()
+			return fmt.Errorf("failed to subscribe to trade events: %w", err)
+		}
+
+		i.wg.Add(1)
+		go i.run(events)
+	}
+
+	i.running = true
+	i.log.Infof("Trade ingestor started (transport: %s)", i.config.Transport)
+
+	return nil
+}
+
+func (i *tradeIngestor) Stop() error {
+	i.runMu.Lock()
+	defer i.runMu.Unlock()
+
+	if !i.running {
+		return nil
+	}
+
+	i.cancel()
+	i.wg.Wait()
+
+	if i.kafka != nil {
+		if err := i.kafka.Close(); err != nil {
+			i.log.Warnf("Failed to close kafka reader: %v", err)
+		}
+	}
+
+	i.running = false
+	i.log.Info("Trade ingestor stopped")
+	return nil
+}
+
+// GetMetrics returns a snapshot of the ingestion counters
+func (i *tradeIngestor) GetMetrics() IngestionMetrics {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	metrics := i.metrics
+	if i.redis != nil {
+		metrics.Dropped = i.redis.DroppedTradeEvents()
+	}
+	return metrics
+}
+
+// run ingests events from the pub/sub subscription
+func (i *tradeIngestor) run(events <-chan *database.TradeEvent) {
+	defer i.wg.Done()
+
+	ticker := time.NewTicker(dedupWindow)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-i.ctx.Done():
+			return
+		case <-ticker.C:
+			i.maintain()
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			i.ingest(event)
+		}
+	}
+}
+
+// runStream ingests events from the consumer group, reclaiming entries that other
+// consumers left unacknowledged for longer than ClaimIdle
+func (i *tradeIngestor) runStream() {
+	defer i.wg.Done()
+
+	claimIdle := time.Duration(i.config.ClaimIdle) * time.Second
+	claimTicker := time.NewTicker(claimIdle / 2)
+	defer claimTicker.Stop()
+
+	maintenanceTicker := time.NewTicker(dedupWindow)
+	defer maintenanceTicker.Stop()
+
+	i.reclaim(claimIdle)
+
+	for {
+		select {
+		case <-i.ctx.Done():
+			return
+		case <-claimTicker.C:
+			i.reclaim(claimIdle)
+		case <-maintenanceTicker.C:
+			i.maintain()
+			i.trimStream()
+		default:
+		}
+
+		messages, err := i.redis.ReadTradeStream(i.ctx, i.config.ConsumerGroup, i.config.ConsumerName,
+			int64(i.config.BatchSize), streamReadBlock)
+		if err != nil {
+			if i.ctx.Err() != nil {
+				return
+			}
+			i.log.Errorf("Failed to read trade events: %v", err)
+			select {
+			case <-i.ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		for _, message := range messages {
+			i.ingestMessage(message)
+		}
+	}
+}
+
+// ingest converts one pub/sub event and hands it to the engine. ProcessTraderTrade
+// blocks while the trader's shard is full, which holds back the subscription rather
+// than dropping the trade here.
+func (i *tradeIngestor) ingest(event *database.TradeEvent) {
+	trade, ok := i.admit(event)
+	if !ok {
+		return
+	}
+
+	if err := i.engine.ProcessTraderTrade(i.ctx, trade); err != nil {
+		i.fail(trade.ID, "")
+		i.log.Errorf("Failed to ingest trade %s: %v", trade.ID, err)
+		return
+	}
+
+	i.record(func(m *IngestionMetrics) { m.Processed++ })
+}
+
+// ingestMessage hands one stream entry to the engine. The entry is acknowledged only
+// once the engine has processed the trade; until then it stays pending and is
+// reclaimed if this consumer dies.
+func (i *tradeIngestor) ingestMessage(message *database.TradeStreamMessage) {
+	i.mu.Lock()
+	_, busy := i.inFlight[message.ID]
+	i.mu.Unlock()
+	if busy {
+		return
+	}
+
+	if message.Event == nil {
+		i.record(func(m *IngestionMetrics) { m.Received++; m.Invalid++ })
+		i.ack(message.ID)
+		return
+	}
+
+	trade, ok := i.admit(message.Event)
+	if !ok {
+		// Invalid entries would fail again and duplicates are handled by their first delivery
+		i.ack(message.ID)
+		return
+	}
+
+	i.mu.Lock()
+	i.inFlight[message.ID] = struct{}{}
+	i.mu.Unlock()
+
+	err := i.engine.SubmitTrade(i.ctx, trade, func(err error) {
+		if err != nil {
+			i.fail(trade.ID, message.ID)
+			return
+		}
+
+		i.mu.Lock()
+		delete(i.inFlight, message.ID)
+		i.mu.Unlock()
+		i.ack(message.ID)
+	})
+	if err != nil {
+		i.fail(trade.ID, message.ID)
+		i.log.Errorf("Failed to ingest trade %s: %v", trade.ID, err)
+		return
+	}
+
+	i.record(func(m *IngestionMetrics) { m.Processed++ })
+}
+
+// admit converts an event into a trade, recording it as received and rejecting
+// invalid and duplicate events
+func (i *tradeIngestor) admit(event *database.TradeEvent) (*models.Trade, bool) {
+	now := time.Now()
+
+	trade, err := tradeFromEvent(event)
+	if err != nil {
+		i.record(func(m *IngestionMetrics) { m.Received++; m.Invalid++ })
+		i.log.Warnf("Ignoring trade event: %v", err)
+		return nil, false
+	}
+
+	lag := now.Sub(event.Timestamp)
+	if event.Timestamp.IsZero() {
+		lag = now.Sub(trade.CreatedAt)
+	}
+
+	if !i.markSeen(trade.ID, now) {
+		i.record(func(m *IngestionMetrics) { m.Received++; m.Duplicates++ })
+		i.log.Debugf("Skipping duplicate trade event %s", trade.ID)
+		return nil, false
+	}
+
+	i.record(func(m *IngestionMetrics) {
+		m.Received++
+		m.LastLag = lag
+		if lag > m.MaxLag {
+			m.MaxLag = lag
+		}
+	})
+
+	return trade, true
+}
+
+// fail forgets a trade so a redelivery gets another chance. A stream entry is left
+// pending and is picked up again by the next reclaim.
+func (i *tradeIngestor) fail(tradeID, messageID string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	delete(i.seen, tradeID)
+	if messageID != "" {
+		delete(i.inFlight, messageID)
+	}
+	i.metrics.Failed++
+}
+
+func (i *tradeIngestor) ack(messageID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := i.redis.AckTradeEvents(ctx, i.config.ConsumerGroup, messageID); err != nil {
+		i.log.Errorf("Failed to acknowledge trade event %s: %v", messageID, err)
+		return
+	}
+
+	i.record(func(m *IngestionMetrics) { m.Acked++ })
+}
+
+// reclaim takes over entries left pending longer than claimIdle, including this
+// consumer's own entries from before a restart
+func (i *tradeIngestor) reclaim(claimIdle time.Duration) {
+	messages, err := i.redis.ClaimStaleTradeEvents(i.ctx, i.config.ConsumerGroup, i.config.ConsumerName,
+		claimIdle, int64(i.config.BatchSize))
+	if err != nil {
+		if i.ctx.Err() == nil {
+			i.log.Errorf("Failed to reclaim trade events: %v", err)
+		}
+		return
+	}
+
+	if len(messages) == 0 {
+		return
+	}
+
+	i.log.Infof("Reclaimed %d unacknowledged trade events", len(messages))
+	for _, message := range messages {
+		i.record(func(m *IngestionMetrics) { m.Reclaimed++ })
+
+		i.mu.Lock()
+		_, busy := i.inFlight[message.ID]
+		i.mu.Unlock()
+		if !busy && message.Deliveries > int64(i.config.MaxAttempts) {
+			i.deadLetterMessage(message)
+			continue
+		}
+
+		i.ingestMessage(message)
+	}
+}
+
+// deadLetterMessage sets aside a stream entry delivered more than MaxAttempts times,
+// so an event the engine keeps failing on is not reclaimed forever. If it cannot be
+// moved it stays pending and is tried again on the next reclaim.
+func (i *tradeIngestor) deadLetterMessage(message *database.TradeStreamMessage) {
+	ctx, cancel := context.WithTimeout(i.ctx, 5*time.Second)
+	defer cancel()
+
+	reason := fmt.Sprintf("delivered %d times without being processed", message.Deliveries)
+	if err := i.redis.DeadLetterTradeEvent(ctx, i.config.ConsumerGroup, message, reason); err != nil {
+		i.log.Errorf("Failed to dead-letter trade event %s: %v", message.ID, err)
+		return
+	}
+
+	i.record(func(m *IngestionMetrics) { m.DeadLettered++ })
+	i.log.Errorf("Trade event %s %s, moved to the dead-letter stream", message.ID, reason)
+}
+
+// trimStream drops acknowledged events older than the configured retention
+func (i *tradeIngestor) trimStream() {
+	if i.config.Retention <= 0 {
+		return
+	}
+
+	olderThan := time.Now().Add(-time.Duration(i.config.Retention) * time.Hour)
+	trimmed, err := i.redis.TrimTradeStream(i.ctx, i.config.ConsumerGroup, olderThan)
+	if err != nil {
+		i.log.Errorf("Failed to trim trade events: %v", err)
+		return
+	}
+
+	if trimmed > 0 {
+		i.log.Debugf("Trimmed %d trade events older than %s", trimmed, olderThan.Format(time.RFC3339))
+	}
+}
+
+// maintain prunes the dedup window and logs the ingestion counters
+func (i *tradeIngestor) maintain() {
+	i.pruneSeen(time.Now())
+
+	metrics := i.GetMetrics()
+	i.log.Infof("Trade ingestion: %d received, %d processed, %d acked, %d reclaimed, %d duplicates, %d invalid, %d failed, %d dead-lettered, %d dropped, %d order updates, lag %s (max %s)",
+		metrics.Received, metrics.Processed, metrics.Acked, metrics.Reclaimed, metrics.Duplicates,
+		metrics.Invalid, metrics.Failed, metrics.DeadLettered, metrics.Dropped, metrics.OrderUpdates, metrics.LastLag, metrics.MaxLag)
+}
+
+// markSeen records a trade ID and reports whether it was new within the dedup window
+func (i *tradeIngestor) markSeen(tradeID string, now time.Time) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if seenAt, ok := i.seen[tradeID]; ok && now.Sub(seenAt) < dedupWindow {
+		return false
+	}
+	i.seen[tradeID] = now
+	return true
+}
+
+func (i *tradeIngestor) pruneSeen(now time.Time) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for tradeID, seenAt := range i.seen {
+		if now.Sub(seenAt) >= dedupWindow {
+			delete(i.seen, tradeID)
+		}
+	}
+}
+
+func (i *tradeIngestor) record(update func(m *IngestionMetrics)) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	update(&i.metrics)
+}
+
+// tradeFromEvent converts a published trade event into the trader trade the copy
+// engine expects. Copy trades are rejected so the engine never copies its own fills.
+func tradeFromEvent(event *database.TradeEvent) (*models.Trade, error) {
+	if event == nil || event.Trade == nil {
+		return nil, fmt.Errorf("event carries no trade")
+	}
+	if event.Trade.ID == "" {
+		return nil, fmt.Errorf("trade has no ID")
+	}
+	if event.Trade.IsCopyTrade {
+		return nil, fmt.Errorf("trade %s is a copy trade", event.Trade.ID)
+	}
+
+	trade := *event.Trade
+	switch {
+	case trade.TraderID == nil && event.TraderID == "":
+		return nil, fmt.Errorf("trade %s has no trader ID", trade.ID)
+	case trade.TraderID == nil:
+		traderID := event.TraderID
+		trade.TraderID = &traderID
+	case event.TraderID != "" && *trade.TraderID != event.TraderID:
+		return nil, fmt.Errorf("trade %s belongs to trader %s, event names %s",
+			trade.ID, *trade.TraderID, event.TraderID)
+	}
+
+	if trade.Size <= 0 || trade.Price <= 0 {
+		return nil, fmt.Errorf("trade %s has no size or price", trade.ID)
+	}
+	if trade.CreatedAt.IsZero() {
+		trade.CreatedAt = event.Timestamp
+	}
+
+	return &trade, nil
+}
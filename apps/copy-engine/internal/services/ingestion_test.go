@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperdash/copy-engine/internal/config"
+	"github.com/hyperdash/copy-engine/internal/database"
+	"github.com/hyperdash/copy-engine/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// fakeTradeStream hands out reclaimed entries and records what happens to them
+type fakeTradeStream struct {
+	database.Redis
+
+	mu           sync.Mutex
+	claimed      []*database.TradeStreamMessage
+	acked        []string
+	deadLettered []string
+}
+
+func (f *fakeTradeStream) ClaimStaleTradeEvents(ctx context.Context, group, consumer string, minIdle time.Duration, count int64) ([]*database.TradeStreamMessage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	claimed := f.claimed
+	f.claimed = nil
+	return claimed, nil
+}
+
+func (f *fakeTradeStream) AckTradeEvents(ctx context.Context, group string, ids ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.acked = append(f.acked, ids...)
+	return nil
+}
+
+func (f *fakeTradeStream) DeadLetterTradeEvent(ctx context.Context, group string, message *database.TradeStreamMessage, reason string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deadLettered = append(f.deadLettered, message.ID)
+	return nil
+}
+
+func (f *fakeTradeStream) DroppedTradeEvents() uint64 {
+	return 0
+}
+
+func streamMessage(id, tradeID string, deliveries int64) *database.TradeStreamMessage {
+	traderID := "trader-1"
+	return &database.TradeStreamMessage{
+		ID: id,
+		Event: &database.TradeEvent{
+			Type:     "trade",
+			TraderID: traderID,
+			Trade:    &models.Trade{ID: tradeID, TraderID: &traderID, Size: 1, Price: 100},
+		},
+		Deliveries: deliveries,
+	}
+}
+
+func TestReclaimDeadLettersEntriesDeliveredTooOften(t *testing.T) {
+	stream := &fakeTradeStream{claimed: []*database.TradeStreamMessage{
+		streamMessage("1-0", "trade-1", 3),
+		streamMessage("2-0", "trade-2", 4),
+	}}
+	engine := &fakeTradeEngine{}
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ingestor := &tradeIngestor{
+		redis:    stream,
+		engine:   engine,
+		config:   config.IngestionConfig{ConsumerGroup: "copy-engine", MaxAttempts: 3},
+		log:      log,
+		seen:     make(map[string]time.Time),
+		inFlight: make(map[string]struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+
+	ingestor.reclaim(time.Minute)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		stream.mu.Lock()
+		acked := len(stream.acked)
+		stream.mu.Unlock()
+		if acked == 1 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	submitted, _ := engine.snapshot()
+	if len(submitted) != 1 || submitted[0] != "trade-1" {
+		t.Errorf("submitted %v, want [trade-1]", submitted)
+	}
+	if len(stream.deadLettered) != 1 || stream.deadLettered[0] != "2-0" {
+		t.Errorf("dead-lettered %v, want [2-0]", stream.deadLettered)
+	}
+	if len(stream.acked) != 1 || stream.acked[0] != "1-0" {
+		t.Errorf("acknowledged %v, want [1-0]", stream.acked)
+	}
+	if metrics := ingestor.GetMetrics(); metrics.Reclaimed != 2 || metrics.DeadLettered != 1 {
+		t.Errorf("metrics %+v, want 2 reclaimed and 1 dead-lettered", metrics)
+	}
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hyperdash/copy-engine/internal/config"
+	"github.com/hyperdash/copy-engine/internal/database"
+	"github.com/segmentio/kafka-go"
+	"github.com/sirupsen/logrus"
+)
+
+// KafkaReader is the consumer group client the Kafka ingestor reads trader fills
+// from. It is satisfied by *kafka.Reader and by the in-process kafkatest broker.
+type KafkaReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// NewKafkaReader creates a consumer group reader for the trader fills topic, whose
+// name and message schema are defined in @hyperdash/shared-types (KafkaTopics.TRADER_FILLS,
+// TraderFillEventSchema). Offsets are committed synchronously and only by the
+// ingestor; a new group starts at the end of the topic.
+func NewKafkaReader(cfg config.KafkaConfig) KafkaReader {
+	return kafka.NewReader(kafka.ReaderConfig{
+		Brokers:        cfg.Brokers,
+		GroupID:        cfg.GroupID,
+		Topic:          cfg.Topic,
+		MinBytes:       1,
+		MaxBytes:       10e6,
+		CommitInterval: 0,
+		StartOffset:    kafka.LastOffset,
+	})
+}
+
+// NewKafkaTradeIngestor creates an ingestor consuming trade events from Kafka. A
+// message's offset is committed only once the copy engine has recorded executions
+// for every relationship of its trade, or the trade was dead-lettered, and never
+// past an earlier unfinished message of the same partition.
+func NewKafkaTradeIngestor(reader KafkaReader, postgres database.PostgreSQL, engine CopyEngine, cfg config.IngestionConfig, log *logrus.Logger) TradeIngestor {
+	return &tradeIngestor{
+		kafka:    reader,
+		postgres: postgres,
+		engine:   engine,
+		config:   cfg,
+		log:      log,
+		seen:     make(map[string]time.Time),
+		inFlight: make(map[string]struct{}),
+		offsets:  newOffsetTracker(),
+		queues:   make(map[string][]*queuedTrade),
+	}
+}
+
+// runKafka ingests messages from the Kafka consumer group
+func (i *tradeIngestor) runKafka() {
+	defer i.wg.Done()
+
+	ticker := time.NewTicker(dedupWindow)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-i.ctx.Done():
+			return
+		case <-ticker.C:
+			i.maintain()
+		default:
+		}
+
+		message, err := i.kafka.FetchMessage(i.ctx)
+		if err != nil {
+			if i.ctx.Err() != nil {
+				return
+			}
+			i.log.Errorf("Failed to fetch trade message: %v", err)
+			select {
+			case <-i.ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		i.ingestKafka(message)
+	}
+}
+
+// ingestKafka decodes one message and hands its trade to the engine. Messages that
+// carry no usable trade are committed straight away.
+func (i *tradeIngestor) ingestKafka(message kafka.Message) {
+	i.offsets.begin(message)
+
+	var event database.TradeEvent
+	if err := json.Unmarshal(message.Value, &event); err != nil {
+		i.record(func(m *IngestionMetrics) { m.Received++; m.Invalid++ })
+		i.log.Warnf("Ignoring trade message at %s/%d@%d: %v",
+			message.Topic, message.Partition, message.Offset, err)
+		i.complete(message)
+		return
+	}
+
+	trade, ok := i.admit(&event)
+	if !ok {
+		i.complete(message)
+		return
+	}
+
+	// The partition's offset stays put while the trade waits or is resubmitted, so a
+	// crash redelivers it
+	i.enqueue(&queuedTrade{
+		trade:  trade,
+		source: fmt.Sprintf("kafka %s/%d@%d", message.Topic, message.Partition, message.Offset),
+		done:   func() { i.complete(message) },
+	})
+}
+
+// complete marks a message processed and commits its partition up to the last
+// message with no unfinished predecessor
+func (i *tradeIngestor) complete(message kafka.Message) {
+	commit, ok := i.offsets.done(message)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := i.kafka.CommitMessages(ctx, commit); err != nil {
+		i.log.Errorf("Failed to commit %s/%d@%d: %v", commit.Topic, commit.Partition, commit.Offset, err)
+		return
+	}
+
+	i.record(func(m *IngestionMetrics) { m.Acked++ })
+}
+
+// offsetTracker keeps, per partition, the fetched messages that have not been
+// committed yet, so offsets are committed in order even though trades of different
+// traders finish out of order
+type offsetTracker struct {
+	partitions map[partitionKey]*partitionOffsets
+	mu         sync.Mutex
+}
+
+type partitionKey struct {
+	topic     string
+	partition int
+}
+
+type partitionOffsets struct {
+	queue     []kafka.Message // fetched and not yet committed, in offset order
+	done      map[int64]bool
+	committed int64
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{partitions: make(map[partitionKey]*partitionOffsets)}
+}
+
+func (t *offsetTracker) begin(message kafka.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := partitionKey{topic: message.Topic, partition: message.Partition}
+	offsets, ok := t.partitions[key]
+
+	// A message at or before one already tracked means the partition was rewound,
+	// e.g. after a group rebalance; start over from here
+	if !ok || (len(offsets.queue) > 0 && message.Offset <= offsets.queue[len(offsets.queue)-1].Offset) {
+		offsets = &partitionOffsets{done: make(map[int64]bool), committed: -1}
+		t.partitions[key] = offsets
+	}
+
+	offsets.queue = append(offsets.queue, message)
+}
+
+// done marks a message finished and returns the message to commit, if the
+// partition's committable position advanced
+func (t *offsetTracker) done(message kafka.Message) (kafka.Message, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	offsets, ok := t.partitions[partitionKey{topic: message.Topic, partition: message.Partition}]
+	if !ok {
+		return kafka.Message{}, false
+	}
+	offsets.done[message.Offset] = true
+
+	var commit kafka.Message
+	advanced := false
+	for len(offsets.queue) > 0 && offsets.done[offsets.queue[0].Offset] {
+		commit = offsets.queue[0]
+		delete(offsets.done, commit.Offset)
+		offsets.queue = offsets.queue[1:]
+		advanced = true
+	}
+
+	if !advanced || commit.Offset <= offsets.committed {
+		return kafka.Message{}, false
+	}
+	offsets.committed = commit.Offset
+
+	return commit, true
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperdash/copy-engine/internal/config"
+	"github.com/hyperdash/copy-engine/internal/kafkatest"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	testFillsTopic = "trader-fills"
+	testGroup      = "copy-engine"
+)
+
+// traderFillMessage is a trader-fills message as TraderFillEventSchema in
+// @hyperdash/shared-types describes it
+const traderFillMessage = `{
+	"type": "trade",
+	"trader_id": "trader-1",
+	"trade": {
+		"id": "fill-1",
+		"trader_id": "trader-1",
+		"position_id": null,
+		"token_symbol": "ETH",
+		"side": "buy",
+		"size": 1.5,
+		"price": 3200.25,
+		"fee": 0.48,
+		"realized_pnl": 0,
+		"transaction_hash": "0xabc",
+		"block_number": null,
+		"created_at": "2026-10-17T09:30:00Z",
+		"is_copy_trade": false
+	},
+	"timestamp": "2026-10-17T09:30:00.120Z"
+}`
+
+// startKafkaIngestor runs a Kafka ingestor over the broker's trader fills topic
+func startKafkaIngestor(t *testing.T, broker *kafkatest.Broker, engine CopyEngine, postgres *fakeTradeDeadLetters, maxAttempts int) TradeIngestor {
+	t.Helper()
+
+	reader, err := broker.NewReader(testGroup, testFillsTopic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	ingestor := NewKafkaTradeIngestor(reader, postgres, engine, config.IngestionConfig{
+		Transport:   "kafka",
+		MaxAttempts: maxAttempts,
+	}, log)
+	if err := ingestor.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { ingestor.Stop() })
+	return ingestor
+}
+
+// waitFor polls until the condition holds
+func waitFor(t *testing.T, timeout time.Duration, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestKafkaIngestsTraderFillEvent(t *testing.T) {
+	broker := kafkatest.NewBroker()
+	if err := broker.CreateTopic(testFillsTopic, 1); err != nil {
+		t.Fatal(err)
+	}
+	engine := &fakeTradeEngine{}
+	startKafkaIngestor(t, broker, engine, &fakeTradeDeadLetters{}, 3)
+
+	produceFill(t, broker, "trader-1", "fill-1")
+
+	waitFor(t, 2*time.Second, "the offset commit", func() bool {
+		return broker.Committed(testGroup, testFillsTopic, 0) == 1
+	})
+
+	_, processed := engine.snapshot()
+	if len(processed) != 1 || processed[0] != "fill-1" {
+		t.Fatalf("processed %v, want [fill-1]", processed)
+	}
+	trade := engine.lastTrade()
+	if *trade.TraderID != "trader-1" || trade.TokenSymbol != "ETH" || trade.Size != 1.5 || trade.Price != 3200.25 {
+		t.Errorf("decoded trade %+v", trade)
+	}
+	if trade.TransactionHash == nil || *trade.TransactionHash != "0xabc" {
+		t.Errorf("transaction hash %v, want 0xabc", trade.TransactionHash)
+	}
+}
+
+func TestKafkaHoldsOffsetWhileTradeIsResubmitted(t *testing.T) {
+	broker := kafkatest.NewBroker()
+	if err := broker.CreateTopic(testFillsTopic, 1); err != nil {
+		t.Fatal(err)
+	}
+	engine := &fakeTradeEngine{failures: map[string]int{"fill-1": 1}}
+	startKafkaIngestor(t, broker, engine, &fakeTradeDeadLetters{}, 3)
+
+	produceFill(t, broker, "trader-1", "fill-1")
+	produceFill(t, broker, "trader-1", "fill-2")
+
+	// fill-1 waits a second for its resubmission and fill-2 waits behind it, so
+	// nothing may be committed yet
+	time.Sleep(300 * time.Millisecond)
+	if committed := broker.Committed(testGroup, testFillsTopic, 0); committed != -1 {
+		t.Fatalf("committed offset %d while fill-1 is being resubmitted", committed)
+	}
+
+	waitFor(t, 3*time.Second, "both offsets to be committed", func() bool {
+		return broker.Committed(testGroup, testFillsTopic, 0) == 2
+	})
+	submitted, processed := engine.snapshot()
+	if fmt.Sprint(submitted) != "[fill-1 fill-1 fill-2]" || fmt.Sprint(processed) != "[fill-1 fill-2]" {
+		t.Errorf("submitted %v and processed %v, want fill-1 retried before fill-2", submitted, processed)
+	}
+}
+
+func TestKafkaCommitsDeadLetteredTrade(t *testing.T) {
+	broker := kafkatest.NewBroker()
+	if err := broker.CreateTopic(testFillsTopic, 1); err != nil {
+		t.Fatal(err)
+	}
+	engine := &fakeTradeEngine{failures: map[string]int{"fill-1": -1}}
+	deadLetters := &fakeTradeDeadLetters{}
+	startKafkaIngestor(t, broker, engine, deadLetters, 1)
+
+	produceFill(t, broker, "trader-1", "fill-1")
+
+	waitFor(t, 2*time.Second, "the offset commit", func() bool {
+		return broker.Committed(testGroup, testFillsTopic, 0) == 1
+	})
+	deadLetters.mu.Lock()
+	defer deadLetters.mu.Unlock()
+	if len(deadLetters.deadLetters) != 1 || deadLetters.deadLetters[0].Source != "kafka trader-fills/0@0" {
+		t.Fatalf("dead letters %+v, want fill-1 from trader-fills/0@0", deadLetters.deadLetters)
+	}
+}
+
+func TestKafkaRedeliversUncommittedMessagesAfterRestart(t *testing.T) {
+	broker := kafkatest.NewBroker()
+	if err := broker.CreateTopic(testFillsTopic, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first run processes fill-1 and keeps failing fill-2, so it stops with only
+	// fill-1 committed
+	first := &fakeTradeEngine{failures: map[string]int{"fill-2": -1}}
+	ingestor := startKafkaIngestor(t, broker, first, &fakeTradeDeadLetters{}, 10)
+	produceFill(t, broker, "trader-1", "fill-1")
+	produceFill(t, broker, "trader-1", "fill-2")
+	waitFor(t, 2*time.Second, "fill-1 to be committed", func() bool {
+		return broker.Committed(testGroup, testFillsTopic, 0) == 1
+	})
+	if err := ingestor.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	second := &fakeTradeEngine{}
+	startKafkaIngestor(t, broker, second, &fakeTradeDeadLetters{}, 10)
+	waitFor(t, 2*time.Second, "fill-2 to be committed", func() bool {
+		return broker.Committed(testGroup, testFillsTopic, 0) == 2
+	})
+	if _, processed := second.snapshot(); fmt.Sprint(processed) != "[fill-2]" {
+		t.Errorf("restarted ingestor processed %v, want [fill-2]", processed)
+	}
+}
+
+func TestKafkaCommitsInOffsetOrderAcrossTraders(t *testing.T) {
+	broker := kafkatest.NewBroker()
+	if err := broker.CreateTopic(testFillsTopic, 1); err != nil {
+		t.Fatal(err)
+	}
+	engine := &fakeTradeEngine{failures: map[string]int{"fill-1": 1}}
+	startKafkaIngestor(t, broker, engine, &fakeTradeDeadLetters{}, 3)
+
+	// Both traders share the partition; trader-2's fill finishes first, but the
+	// offset cannot move past trader-1's unfinished one
+	produceFill(t, broker, "trader-1", "fill-1")
+	produceFill(t, broker, "trader-2", "fill-2")
+
+	waitFor(t, time.Second, "fill-2 to be processed", func() bool {
+		_, processed := engine.snapshot()
+		return len(processed) == 1
+	})
+	if committed := broker.Committed(testGroup, testFillsTopic, 0); committed != -1 {
+		t.Fatalf("committed offset %d past the unfinished fill-1", committed)
+	}
+
+	waitFor(t, 3*time.Second, "both offsets to be committed", func() bool {
+		return broker.Committed(testGroup, testFillsTopic, 0) == 2
+	})
+}
+
+// produceFill publishes a trader-fills message keyed by its trader
+func produceFill(t *testing.T, broker *kafkatest.Broker, traderID, tradeID string) {
+	t.Helper()
+	message := strings.NewReplacer(`"trader-1"`, `"`+traderID+`"`, `"fill-1"`, `"`+tradeID+`"`).Replace(traderFillMessage)
+	if _, _, err := broker.Produce(testFillsTopic, []byte(traderID), []byte(message)); err != nil {
+		t.Fatal(err)
+	}
+}
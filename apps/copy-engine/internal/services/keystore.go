@@ -0,0 +1,309 @@
+package services
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperdash/copy-engine/internal/config"
+	"github.com/hyperdash/copy-engine/internal/database"
+	"github.com/hyperdash/copy-engine/internal/exchange"
+	"github.com/hyperdash/copy-engine/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// Agent keys are sealed by the api-gateway (see key-management.ts) with AES-256-GCM
+// as base64(iv || tag || ciphertext)
+const (
+	agentKeyIVSize  = 12
+	agentKeyTagSize = 16
+)
+
+// ErrNoAgentWallet is returned (wrapped) when a follower has no usable agent wallet.
+// It wraps exchange.ErrSignerNotConfigured, so executions fail instead of retrying.
+var ErrNoAgentWallet = fmt.Errorf("%w: no approved agent wallet", exchange.ErrSignerNotConfigured)
+
+// AccountBinder maps account IDs to the on-chain address they trade
+type AccountBinder interface {
+	BindAccount(accountID, address string)
+}
+
+// AgentKeyStore signs the orders of each user the engine trades for (followers and
+// strategy owners) with the agent key the user approved. Keys are decrypted on
+// first use and dropped from memory after the configured TTL; users without an
+// agent wallet who are not followers are left to the adapter's own signer.
+type AgentKeyStore interface {
+	exchange.SignerProvider
+	Start(ctx context.Context) error
+	Stop() error
+}
+
+type agentKeyStore struct {
+	postgres database.PostgreSQL
+	accounts AccountBinder
+	key      []byte
+	mainnet  bool
+	ttl      time.Duration
+	log      *logrus.Logger
+
+	signers map[string]*cachedSigner
+	mu      sync.Mutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	running bool
+	runMu   sync.Mutex
+}
+
+// cachedSigner is the outcome of resolving an account, kept until expiresAt
+type cachedSigner struct {
+	signer    exchange.Signer // nil for accounts that are not followers
+	err       error           // set for followers without a usable agent wallet
+	expiresAt time.Time
+}
+
+// NewAgentKeyStore creates a key store reading agent wallets from postgres. Each
+// user with an agent wallet is bound on accounts to the master account the agent
+// trades for, so that info queries for the user read the user's own account.
+func NewAgentKeyStore(postgres database.PostgreSQL, accounts AccountBinder, cfg config.KeyStoreConfig, mainnet bool, log *logrus.Logger) (AgentKeyStore, error) {
+	key, err := cfg.Key()
+	if err != nil {
+		return nil, err
+	}
+
+	return &agentKeyStore{
+		postgres: postgres,
+		accounts: accounts,
+		key:      key,
+		mainnet:  mainnet,
+		ttl:      time.Duration(cfg.CacheTTL) * time.Second,
+		log:      log,
+		signers:  make(map[string]*cachedSigner),
+	}, nil
+}
+
+func (k *agentKeyStore) Start(ctx context.Context) error {
+	k.runMu.Lock()
+	defer k.runMu.Unlock()
+
+	if k.running {
+		return fmt.Errorf("agent key store is already running")
+	}
+
+	k.ctx, k.cancel = context.WithCancel(ctx)
+
+	if err := k.bindAccounts(); err != nil {
+		k.cancel()
+		return err
+	}
+
+	k.wg.Add(1)
+	go k.run()
+
+	k.running = true
+	k.log.Info("Agent key store started")
+
+	return nil
+}
+
+func (k *agentKeyStore) Stop() error {
+	k.runMu.Lock()
+	defer k.runMu.Unlock()
+
+	if !k.running {
+		return nil
+	}
+
+	k.cancel()
+	k.wg.Wait()
+
+	k.mu.Lock()
+	k.signers = make(map[string]*cachedSigner)
+	k.mu.Unlock()
+
+	k.running = false
+	k.log.Info("Agent key store stopped")
+	return nil
+}
+
+// run rebinds user accounts and evicts expired keys every TTL
+func (k *agentKeyStore) run() {
+	defer k.wg.Done()
+
+	ticker := time.NewTicker(k.ttl)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-k.ctx.Done():
+			return
+		case <-ticker.C:
+			k.evict(time.Now())
+			if err := k.bindAccounts(); err != nil && k.ctx.Err() == nil {
+				k.log.Errorf("Failed to refresh agent wallet accounts: %v", err)
+			}
+		}
+	}
+}
+
+// bindAccounts points every trading user's account at the master account its agent trades
+func (k *agentKeyStore) bindAccounts() error {
+	ctx, cancel := context.WithTimeout(k.ctx, 10*time.Second)
+	defer cancel()
+
+	wallets, err := k.postgres.GetTradingAgentWallets(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load agent wallets: %w", err)
+	}
+
+	for _, wallet := range wallets {
+		k.accounts.BindAccount(wallet.UserID, wallet.MasterAddress)
+	}
+
+	return nil
+}
+
+// SignerFor returns the signer of a follower's agent wallet, decrypting it on first
+// use. A follower without a usable wallet gets ErrNoAgentWallet; any other account
+// gets no signer so the adapter uses its own.
+func (k *agentKeyStore) SignerFor(ctx context.Context, account string) (exchange.Signer, error) {
+	now := time.Now()
+
+	k.mu.Lock()
+	cached, ok := k.signers[account]
+	k.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.signer, cached.err
+	}
+
+	signer, err := k.load(ctx, account)
+	if err != nil && !errors.Is(err, exchange.ErrSignerNotConfigured) {
+		// Lookup failures are not cached so the next order tries again
+		return nil, err
+	}
+
+	k.mu.Lock()
+	k.signers[account] = &cachedSigner{signer: signer, err: err, expiresAt: now.Add(k.ttl)}
+	k.mu.Unlock()
+
+	return signer, err
+}
+
+func (k *agentKeyStore) load(ctx context.Context, account string) (exchange.Signer, error) {
+	wallet, err := k.postgres.GetAgentWallet(ctx, account)
+	if errors.Is(err, database.ErrNotFound) {
+		relationships, err := k.postgres.GetCopyRelationshipsByFollower(ctx, account)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up follower %s: %w", account, err)
+		}
+		if len(relationships) > 0 {
+			return nil, fmt.Errorf("%w for follower %s", ErrNoAgentWallet, account)
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey, err := decryptAgentKey(k.key, wallet)
+	if err != nil {
+		return nil, fmt.Errorf("%w for follower %s: %v", ErrNoAgentWallet, account, err)
+	}
+
+	signer, err := exchange.NewPrivateKeySigner(privateKey, k.mainnet)
+	if err != nil {
+		return nil, fmt.Errorf("%w for follower %s: %v", ErrNoAgentWallet, account, err)
+	}
+	if !strings.EqualFold(signer.Address(), wallet.Address) {
+		return nil, fmt.Errorf("%w for follower %s: key of agent wallet %s signs as %s",
+			ErrNoAgentWallet, account, wallet.ID, signer.Address())
+	}
+
+	// The agent only signs; orders and queries address the master account
+	k.accounts.BindAccount(account, wallet.MasterAddress)
+	k.log.Infof("Loaded agent %s trading %s for user %s", signer.Address(), wallet.MasterAddress, account)
+
+	return signer, nil
+}
+
+func (k *agentKeyStore) evict(now time.Time) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	for account, cached := range k.signers {
+		if !now.Before(cached.expiresAt) {
+			delete(k.signers, account)
+		}
+	}
+}
+
+// EncryptAgentKey seals an agent private key for the wallet's encrypted_private_key
+// column the way the api-gateway does, so keys written by either side decrypt on both
+func EncryptAgentKey(encryptionKey []byte, privateKey string) (string, error) {
+	aead, err := agentKeyCipher(encryptionKey)
+	if err != nil {
+		return "", err
+	}
+
+	iv := make([]byte, agentKeyIVSize)
+	if _, err := rand.Read(iv); err != nil {
+		return "", fmt.Errorf("failed to generate iv: %w", err)
+	}
+
+	// Go appends the tag to the ciphertext; the stored form keeps it in front
+	sealed := aead.Seal(nil, iv, []byte(privateKey), nil)
+	ciphertext, tag := sealed[:len(sealed)-agentKeyTagSize], sealed[len(sealed)-agentKeyTagSize:]
+
+	data := make([]byte, 0, len(sealed)+agentKeyIVSize)
+	data = append(append(append(data, iv...), tag...), ciphertext...)
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+func decryptAgentKey(encryptionKey []byte, wallet *models.AgentWallet) (string, error) {
+	if wallet.EncryptedPrivateKey == nil {
+		return "", fmt.Errorf("agent wallet %s has no key", wallet.ID)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(*wallet.EncryptedPrivateKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode key of agent wallet %s: %w", wallet.ID, err)
+	}
+	if len(data) < agentKeyIVSize+agentKeyTagSize {
+		return "", fmt.Errorf("key of agent wallet %s is truncated", wallet.ID)
+	}
+
+	aead, err := agentKeyCipher(encryptionKey)
+	if err != nil {
+		return "", err
+	}
+
+	iv := data[:agentKeyIVSize]
+	tag := data[agentKeyIVSize : agentKeyIVSize+agentKeyTagSize]
+	ciphertext := data[agentKeyIVSize+agentKeyTagSize:]
+
+	sealed := make([]byte, 0, len(ciphertext)+len(tag))
+	sealed = append(append(sealed, ciphertext...), tag...)
+	plaintext, err := aead.Open(nil, iv, sealed, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt key of agent wallet %s: %w", wallet.ID, err)
+	}
+
+	return string(plaintext), nil
+}
+
+func agentKeyCipher(encryptionKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(encryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid agent key encryption key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/hyperdash/copy-engine/internal/exchange"
+	"github.com/hyperdash/copy-engine/internal/models"
+)
+
+// Produced by the api-gateway's encryptKey (src/services/key-management.ts) with
+// the key and private key below
+const (
+	gatewayEncryptionKey = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	gatewayPrivateKey    = "0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+	gatewayAgentAddress  = "0xf39fd6e51aad88f6f4ce6ab8827279cfffb92266"
+	gatewaySealedKey     = "oPednJKSFTyduKuCqY8dCrQeTUP+IXik+zySAT4BH13TXAF5O8pLqcvMYOoXZcN9V+hJZ7OO3dddKOcEVsqoO16D17sdjXgmme3hiWZCOT2zd0BP7dCox5f97E0RPQ=="
+)
+
+func testEncryptionKey(t *testing.T) []byte {
+	t.Helper()
+	key, err := hex.DecodeString(gatewayEncryptionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestDecryptAgentKeyWrittenByGateway(t *testing.T) {
+	sealed := gatewaySealedKey
+	wallet := &models.AgentWallet{ID: "wallet-1", EncryptedPrivateKey: &sealed}
+
+	privateKey, err := decryptAgentKey(testEncryptionKey(t), wallet)
+	if err != nil {
+		t.Fatalf("decryptAgentKey: %v", err)
+	}
+	if privateKey != gatewayPrivateKey {
+		t.Fatalf("decrypted %q, want %q", privateKey, gatewayPrivateKey)
+	}
+
+	signer, err := exchange.NewPrivateKeySigner(privateKey, true)
+	if err != nil {
+		t.Fatalf("NewPrivateKeySigner: %v", err)
+	}
+	if signer.Address() != gatewayAgentAddress {
+		t.Fatalf("signer address %s, want %s", signer.Address(), gatewayAgentAddress)
+	}
+}
+
+func TestEncryptAgentKeyRoundTrip(t *testing.T) {
+	key := testEncryptionKey(t)
+
+	sealed, err := EncryptAgentKey(key, gatewayPrivateKey)
+	if err != nil {
+		t.Fatalf("EncryptAgentKey: %v", err)
+	}
+	again, err := EncryptAgentKey(key, gatewayPrivateKey)
+	if err != nil {
+		t.Fatalf("EncryptAgentKey: %v", err)
+	}
+	if sealed == again {
+		t.Fatal("sealing twice produced the same ciphertext")
+	}
+
+	privateKey, err := decryptAgentKey(key, &models.AgentWallet{ID: "wallet-1", EncryptedPrivateKey: &sealed})
+	if err != nil {
+		t.Fatalf("decryptAgentKey: %v", err)
+	}
+	if privateKey != gatewayPrivateKey {
+		t.Fatalf("decrypted %q, want %q", privateKey, gatewayPrivateKey)
+	}
+}
+
+func TestDecryptAgentKeyFailures(t *testing.T) {
+	wrongKey, _ := hex.DecodeString(strings.Repeat("ff", 32))
+	sealed := gatewaySealedKey
+	truncated := "AAAA"
+	tampered := "A" + gatewaySealedKey[1:]
+
+	tests := []struct {
+		name   string
+		key    []byte
+		sealed *string
+	}{
+		{"wrong key", wrongKey, &sealed},
+		{"no key", testEncryptionKey(t), nil},
+		{"truncated", testEncryptionKey(t), &truncated},
+		{"tampered", testEncryptionKey(t), &tampered},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decryptAgentKey(tt.key, &models.AgentWallet{ID: "wallet-1", EncryptedPrivateKey: tt.sealed})
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
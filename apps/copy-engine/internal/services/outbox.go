@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hyperdash/copy-engine/internal/config"
+	"github.com/hyperdash/copy-engine/internal/database"
+	"github.com/hyperdash/copy-engine/internal/models"
+	"github.com/segmentio/kafka-go"
+	"github.com/sirupsen/logrus"
+)
+
+// EventSink delivers a published event to downstream consumers
+type EventSink interface {
+	Name() string
+	Publish(ctx context.Context, event *models.OutboxEvent) error
+	// Close releases the sink's connections once the publisher stopped
+	Close() error
+}
+
+// OutboxPublisher moves events from the outbox table to the configured sinks.
+// Delivery is at least once: consumers deduplicate on the event ID.
+type OutboxPublisher interface {
+	Start(ctx context.Context) error
+	Stop() error
+}
+
+type outboxPublisher struct {
+	postgres database.PostgreSQL
+	sinks    []EventSink
+	config   config.EventsConfig
+	log      *logrus.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	running bool
+	mu      sync.Mutex
+}
+
+// NewOutboxPublisher creates a publisher delivering outbox events to every sink
+func NewOutboxPublisher(postgres database.PostgreSQL, sinks []EventSink, cfg config.EventsConfig, log *logrus.Logger) OutboxPublisher {
+	return &outboxPublisher{
+		postgres: postgres,
+		sinks:    sinks,
+		config:   cfg,
+		log:      log,
+	}
+}
+
+func (p *outboxPublisher) Start(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.running {
+		return fmt.Errorf("outbox publisher is already running")
+	}
+	if p.config.PollInterval <= 0 || p.config.BatchSize <= 0 {
+		return fmt.Errorf("outbox poll interval and batch size must be positive")
+	}
+
+	p.ctx, p.cancel = context.WithCancel(ctx)
+
+	p.wg.Add(1)
+	go p.run()
+
+	p.running = true
+	p.log.Infof("Outbox publisher started (%d sinks)", len(p.sinks))
+
+	return nil
+}
+
+func (p *outboxPublisher) Stop() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.running {
+		return nil
+	}
+
+	p.cancel()
+	p.wg.Wait()
+
+	for _, sink := range p.sinks {
+		if err := sink.Close(); err != nil {
+			p.log.Warnf("Failed to close %s event sink: %v", sink.Name(), err)
+		}
+	}
+
+	p.running = false
+	p.log.Info("Outbox publisher stopped")
+	return nil
+}
+
+func (p *outboxPublisher) run() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(time.Duration(p.config.PollInterval) * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			p.drain()
+		}
+	}
+}
+
+// drain publishes batches until the outbox is empty or a sink fails
+func (p *outboxPublisher) drain() {
+	for {
+		ctx, cancel := context.WithTimeout(p.ctx, 30*time.Second)
+		published, err := p.postgres.PublishOutboxEvents(ctx, p.config.BatchSize, p.publish)
+		cancel()
+
+		if err != nil {
+			if p.ctx.Err() == nil {
+				p.log.Errorf("Failed to publish outbox events (%d published): %v", published, err)
+			}
+			return
+		}
+		if published < p.config.BatchSize {
+			return
+		}
+	}
+}
+
+// publish delivers an event to every sink; a sink that already accepted it receives
+// it again on the retry after another sink fails
+func (p *outboxPublisher) publish(ctx context.Context, event *models.OutboxEvent) error {
+	for _, sink := range p.sinks {
+		if err := sink.Publish(ctx, event); err != nil {
+			return fmt.Errorf("%s sink: %w", sink.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+type redisEventSink struct {
+	redis  database.Redis
+	stream string
+	maxLen int64
+}
+
+// NewRedisEventSink appends events to a Redis stream
+func NewRedisEventSink(redis database.Redis, cfg config.EventsConfig) EventSink {
+	return &redisEventSink{
+		redis:  redis,
+		stream: cfg.RedisStream,
+		maxLen: cfg.RedisMaxLen,
+	}
+}
+
+func (s *redisEventSink) Name() string {
+	return "redis"
+}
+
+func (s *redisEventSink) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	return s.redis.AppendCopyEvent(ctx, s.stream, event, s.maxLen)
+}
+
+// Close is a no-op: the Redis client is shared and closed by its owner
+func (s *redisEventSink) Close() error {
+	return nil
+}
+
+// kafkaWriterBatchTimeout bounds how long the writer waits to fill a batch. Events
+// are written one at a time inside the outbox transaction, so each write waits for
+// it in full; kafka-go's default of a second would stall the outbox.
+const kafkaWriterBatchTimeout = 5 * time.Millisecond
+
+// KafkaWriter is the producer the Kafka event sink writes with. It is satisfied by
+// *kafka.Writer and by the in-process kafkatest broker.
+type KafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// NewKafkaWriter creates a producer for the events topic, partitioning by key
+func NewKafkaWriter(kafkaCfg config.KafkaConfig, cfg config.EventsConfig) KafkaWriter {
+	return &kafka.Writer{
+		Addr:         kafka.TCP(kafkaCfg.Brokers...),
+		Topic:        cfg.KafkaTopic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		BatchTimeout: kafkaWriterBatchTimeout,
+	}
+}
+
+type kafkaEventSink struct {
+	writer KafkaWriter
+}
+
+// NewKafkaEventSink produces events to a Kafka topic, keyed by aggregate so that
+// events of one execution or position stay in order within a partition
+func NewKafkaEventSink(writer KafkaWriter) EventSink {
+	return &kafkaEventSink{writer: writer}
+}
+
+func (s *kafkaEventSink) Name() string {
+	return "kafka"
+}
+
+func (s *kafkaEventSink) Close() error {
+	return s.writer.Close()
+}
+
+func (s *kafkaEventSink) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", event.Type, err)
+	}
+
+	return s.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(event.AggregateID),
+		Value: data,
+		Headers: []kafka.Header{
+			{Key: "event_type", Value: []byte(event.Type)},
+			{Key: "schema_version", Value: []byte(strconv.Itoa(event.SchemaVersion))},
+		},
+		Time: event.OccurredAt,
+	})
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperdash/copy-engine/internal/config"
+	"github.com/hyperdash/copy-engine/internal/database"
+	"github.com/hyperdash/copy-engine/internal/kafkatest"
+	"github.com/hyperdash/copy-engine/internal/models"
+	"github.com/segmentio/kafka-go"
+	"github.com/sirupsen/logrus"
+)
+
+const testEventsTopic = "copy-engine-events"
+
+// fakeOutboxStore publishes in-memory events the way the outbox table does: oldest
+// first, stopping at the first event a sink rejects
+type fakeOutboxStore struct {
+	database.PostgreSQL
+
+	mu        sync.Mutex
+	events    []*models.OutboxEvent
+	published int
+}
+
+func (f *fakeOutboxStore) PublishOutboxEvents(ctx context.Context, limit int, publish func(ctx context.Context, event *models.OutboxEvent) error) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	published := 0
+	for f.published < len(f.events) && published < limit {
+		if err := publish(ctx, f.events[f.published]); err != nil {
+			return published, err
+		}
+		f.published++
+		published++
+	}
+	return published, nil
+}
+
+func (f *fakeOutboxStore) pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.events) - f.published
+}
+
+func testOutboxEvent(t *testing.T, aggregateID string, sequence int) *models.OutboxEvent {
+	t.Helper()
+	event, err := models.NewOutboxEvent(models.EventExecutionStatusChanged, aggregateID, map[string]int{"sequence": sequence})
+	if err != nil {
+		t.Fatalf("NewOutboxEvent: %v", err)
+	}
+	return event
+}
+
+func newTestEventsBroker(t *testing.T) *kafkatest.Broker {
+	t.Helper()
+	broker := kafkatest.NewBroker()
+	if err := broker.CreateTopic(testEventsTopic, 3); err != nil {
+		t.Fatalf("CreateTopic: %v", err)
+	}
+	return broker
+}
+
+// readEvents reads n messages from the events topic
+func readEvents(t *testing.T, broker *kafkatest.Broker, n int) []kafka.Message {
+	t.Helper()
+	reader, err := broker.NewReader("test", testEventsTopic)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	defer reader.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	messages := make([]kafka.Message, 0, n)
+	for len(messages) < n {
+		message, err := reader.FetchMessage(ctx)
+		if err != nil {
+			t.Fatalf("read %d of %d events: %v", len(messages), n, err)
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+func TestKafkaEventSinkWritesKeyedEvents(t *testing.T) {
+	broker := newTestEventsBroker(t)
+	sink := NewKafkaEventSink(broker.NewWriter(testEventsTopic))
+
+	events := []*models.OutboxEvent{
+		testOutboxEvent(t, "execution-1", 1),
+		testOutboxEvent(t, "execution-2", 1),
+		testOutboxEvent(t, "execution-1", 2),
+	}
+	for _, event := range events {
+		if err := sink.Publish(context.Background(), event); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	byID := make(map[string]kafka.Message)
+	partitions := make(map[string]int)
+	for _, message := range readEvents(t, broker, len(events)) {
+		var decoded models.OutboxEvent
+		if err := json.Unmarshal(message.Value, &decoded); err != nil {
+			t.Fatalf("undecodable event %s: %v", message.Value, err)
+		}
+		byID[decoded.ID] = message
+		if partition, ok := partitions[string(message.Key)]; ok && partition != message.Partition {
+			t.Errorf("events of %s written to partitions %d and %d", message.Key, partition, message.Partition)
+		}
+		partitions[string(message.Key)] = message.Partition
+	}
+
+	for _, event := range events {
+		message, ok := byID[event.ID]
+		if !ok {
+			t.Fatalf("event %s was not written", event.ID)
+		}
+		if string(message.Key) != event.AggregateID {
+			t.Errorf("event %s keyed %q, want %q", event.ID, message.Key, event.AggregateID)
+		}
+		headers := make(map[string]string)
+		for _, header := range message.Headers {
+			headers[header.Key] = string(header.Value)
+		}
+		if headers["event_type"] != string(event.Type) || headers["schema_version"] != fmt.Sprint(event.SchemaVersion) {
+			t.Errorf("event %s headers %v", event.ID, headers)
+		}
+	}
+}
+
+func TestOutboxPublisherDrainsBacklogToKafka(t *testing.T) {
+	broker := newTestEventsBroker(t)
+	store := &fakeOutboxStore{}
+	for i := 0; i < 250; i++ {
+		store.events = append(store.events, testOutboxEvent(t, fmt.Sprintf("execution-%d", i%7), i))
+	}
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	sink := NewKafkaEventSink(broker.NewWriter(testEventsTopic))
+	publisher := NewOutboxPublisher(store, []EventSink{sink}, config.EventsConfig{PollInterval: 10, BatchSize: 100}, log)
+
+	if err := publisher.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for store.pending() > 0 {
+		if time.Now().After(deadline) {
+			publisher.Stop()
+			t.Fatalf("%d events still in the outbox", store.pending())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := publisher.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	if messages := readEvents(t, broker, len(store.events)); len(messages) != len(store.events) {
+		t.Errorf("%d events written, want %d", len(messages), len(store.events))
+	}
+
+	if err := sink.Publish(context.Background(), testOutboxEvent(t, "execution-1", 0)); err == nil {
+		t.Error("the sink's writer is still open after the publisher stopped")
+	}
+}
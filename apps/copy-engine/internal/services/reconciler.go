@@ -0,0 +1,511 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hyperdash/copy-engine/internal/config"
+	"github.com/hyperdash/copy-engine/internal/database"
+	"github.com/hyperdash/copy-engine/internal/exchange"
+	"github.com/hyperdash/copy-engine/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// Reconciler keeps stored follower positions in line with the exchange
+type Reconciler interface {
+	Start(ctx context.Context) error
+	Stop() error
+	ReconcileFollower(ctx context.Context, followerID string) ([]*models.PositionDrift, error)
+}
+
+type reconciler struct {
+	postgres database.PostgreSQL
+	exchange exchange.Adapter
+	config   config.ReconcilerConfig
+	log      *logrus.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	running bool
+	mu      sync.Mutex
+}
+
+// NewReconciler creates a reconciler comparing stored positions with the exchange
+func NewReconciler(postgres database.PostgreSQL, adapter exchange.Adapter, cfg config.ReconcilerConfig, log *logrus.Logger) Reconciler {
+	return &reconciler{
+		postgres: postgres,
+		exchange: adapter,
+		config:   cfg,
+		log:      log,
+	}
+}
+
+func (r *reconciler) Start(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.running {
+		return fmt.Errorf("reconciler is already running")
+	}
+	if r.config.Interval <= 0 {
+		return fmt.Errorf("reconcile interval must be positive")
+	}
+
+	r.ctx, r.cancel = context.WithCancel(ctx)
+
+	r.wg.Add(1)
+	go r.run()
+
+	r.running = true
+	r.log.Infof("Position reconciler started (interval %ds, corrective orders: %t)",
+		r.config.Interval, r.config.PlaceCorrectiveOrders)
+
+	return nil
+}
+
+func (r *reconciler) Stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.running {
+		return nil
+	}
+
+	r.cancel()
+	r.wg.Wait()
+
+	r.running = false
+	r.log.Info("Position reconciler stopped")
+	return nil
+}
+
+func (r *reconciler) run() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(time.Duration(r.config.Interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+			r.reconcileAll()
+		}
+	}
+}
+
+func (r *reconciler) reconcileAll() {
+	ctx, cancel := context.WithTimeout(r.ctx, time.Duration(r.config.Interval)*time.Second)
+	defer cancel()
+
+	relationships, err := r.postgres.GetActiveCopyRelationships(ctx)
+	if err != nil {
+		r.log.Errorf("Failed to get active relationships for reconciliation: %v", err)
+		return
+	}
+
+	followers := make(map[string]bool)
+	for _, relationship := range relationships {
+		followers[relationship.FollowerID] = true
+	}
+
+	for followerID := range followers {
+		drifts, err := r.ReconcileFollower(ctx, followerID)
+		if err != nil {
+			r.log.Errorf("Failed to reconcile follower %s: %v", followerID, err)
+			continue
+		}
+		if len(drifts) > 0 {
+			r.log.Infof("Reconciled follower %s: %d drifts", followerID, len(drifts))
+		}
+	}
+}
+
+// ReconcileFollower repairs the follower's stored positions from the exchange and,
+// when enabled, places orders to bring auto-rebalancing relationships back to the
+// exposure their followed traders imply. It returns every drift it recorded.
+func (r *reconciler) ReconcileFollower(ctx context.Context, followerID string) ([]*models.PositionDrift, error) {
+	stored, err := r.postgres.GetFollowerPositions(ctx, followerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stored positions: %w", err)
+	}
+
+	live, err := r.exchange.GetPositions(ctx, followerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange positions: %w", err)
+	}
+
+	relationships, err := r.postgres.GetCopyRelationshipsByFollower(ctx, followerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get relationships: %w", err)
+	}
+
+	exposure, err := r.expectedExposure(ctx, relationships)
+	if err != nil {
+		return nil, err
+	}
+
+	storedBySymbol := make(map[string]*models.Position)
+	var drifts []*models.PositionDrift
+	for _, position := range stored {
+		if _, exists := storedBySymbol[position.TokenSymbol]; exists {
+			// Only one open row per symbol can be right; close duplicates
+			drift, err := r.closeStored(ctx, followerID, position)
+			if err != nil {
+				return drifts, err
+			}
+			drifts = append(drifts, drift)
+			continue
+		}
+		storedBySymbol[position.TokenSymbol] = position
+	}
+
+	liveBySymbol := make(map[string]*exchange.Position)
+	for _, position := range live {
+		if position.Size != 0 {
+			liveBySymbol[position.Symbol] = position
+		}
+	}
+
+	for _, symbol := range unionSymbols(storedBySymbol, liveBySymbol, exposure.sizes) {
+		drift, err := r.repairSymbol(ctx, followerID, symbol, storedBySymbol[symbol], liveBySymbol[symbol], exposure)
+		if err != nil {
+			return drifts, err
+		}
+		if drift != nil {
+			drifts = append(drifts, drift)
+		}
+
+		drift, err = r.correctSymbol(ctx, followerID, symbol, storedBySymbol[symbol], liveBySymbol[symbol], exposure)
+		if err != nil {
+			r.log.Errorf("Failed to correct %s exposure for follower %s: %v", symbol, followerID, err)
+			continue
+		}
+		if drift != nil {
+			drifts = append(drifts, drift)
+		}
+	}
+
+	return drifts, nil
+}
+
+// followerExposure is the position a follower should hold per symbol
+type followerExposure struct {
+	sizes  map[string]float64 // signed
+	owners map[string]string  // symbol to the first relationship contributing to it
+	manual map[string]bool    // a relationship without auto rebalance contributes to the symbol
+	auto   map[string]bool    // relationships that allow corrective orders
+}
+
+// expectedExposure mirrors each followed trader's positions scaled by the relationship's
+// allocation, the same way proportional sizing scales their trades
+func (r *reconciler) expectedExposure(ctx context.Context, relationships []*models.CopyRelationship) (*followerExposure, error) {
+	exposure := &followerExposure{
+		sizes:  make(map[string]float64),
+		owners: make(map[string]string),
+		manual: make(map[string]bool),
+		auto:   make(map[string]bool),
+	}
+
+	// Traders are followed by ID but read on the exchange by the address they trade from
+	addresses, err := r.postgres.GetActiveTraderAddresses(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trader addresses: %w", err)
+	}
+
+	traderPositions := make(map[string][]*exchange.Position)
+	for _, relationship := range relationships {
+		if !relationship.IsActive {
+			continue
+		}
+		exposure.auto[relationship.ID] = relationship.AutoRebalance
+
+		positions, ok := traderPositions[relationship.TraderID]
+		if !ok {
+			address, ok := addresses[relationship.TraderID]
+			if !ok {
+				// Without the trader's positions the expected exposure is unknown, and
+				// treating it as flat would unwind the follower
+				return nil, fmt.Errorf("trader %s has no known address", relationship.TraderID)
+			}
+
+			positions, err = r.exchange.GetPositions(ctx, address)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get positions of trader %s: %w", relationship.TraderID, err)
+			}
+			traderPositions[relationship.TraderID] = positions
+		}
+
+		for _, position := range positions {
+			if position.Size == 0 {
+				continue
+			}
+
+			size := position.Size * relationship.AllocationPercent / 100.0
+			if relationship.MaxAllocation > 0 && math.Abs(size) > relationship.MaxAllocation {
+				size = math.Copysign(relationship.MaxAllocation, size)
+			}
+
+			exposure.sizes[position.Symbol] += size
+			if _, ok := exposure.owners[position.Symbol]; !ok {
+				exposure.owners[position.Symbol] = relationship.ID
+			}
+			if !relationship.AutoRebalance {
+				exposure.manual[position.Symbol] = true
+			}
+		}
+	}
+
+	return exposure, nil
+}
+
+// repairSymbol makes the stored row for a symbol match the exchange
+func (r *reconciler) repairSymbol(ctx context.Context, followerID, symbol string, stored *models.Position,
+	live *exchange.Position, exposure *followerExposure) (*models.PositionDrift, error) {
+
+	recorded := signedSize(stored)
+	var actual float64
+	if live != nil {
+		actual = live.Size
+	}
+
+	if r.inSync(recorded, actual) {
+		return nil, nil
+	}
+
+	now := time.Now()
+	drift := &models.PositionDrift{
+		ID:           uuid.New().String(),
+		FollowerID:   followerID,
+		TokenSymbol:  symbol,
+		RecordedSize: recorded,
+		ExchangeSize: actual,
+		DetectedAt:   now,
+	}
+
+	switch {
+	case live == nil:
+		return r.closeStored(ctx, followerID, stored)
+
+	case stored == nil:
+		position := &models.Position{
+			ID:          uuid.New().String(),
+			UserID:      &followerID,
+			TokenSymbol: symbol,
+			CreatedAt:   now,
+			IsCopyTrade: true,
+		}
+		if owner, ok := exposure.owners[symbol]; ok {
+			position.CopyRelationshipID = &owner
+		}
+		applyLivePosition(position, live, now)
+
+		if err := r.postgres.CreatePosition(ctx, position); err != nil {
+			return nil, fmt.Errorf("failed to insert %s position: %w", symbol, err)
+		}
+		drift.RelationshipID = position.CopyRelationshipID
+		drift.Action = models.DriftInserted
+
+	default:
+		applyLivePosition(stored, live, now)
+		if err := r.postgres.UpdatePosition(ctx, stored); err != nil {
+			return nil, fmt.Errorf("failed to update %s position: %w", symbol, err)
+		}
+		drift.RelationshipID = stored.CopyRelationshipID
+		drift.Action = models.DriftUpdated
+	}
+
+	if err := r.postgres.RecordPositionDrift(ctx, drift); err != nil {
+		return nil, err
+	}
+
+	r.log.Warnf("Position drift for follower %s %s: stored %.6f, exchange %.6f (%s)",
+		followerID, symbol, recorded, actual, drift.Action)
+	return drift, nil
+}
+
+// closeStored zeroes a stored position the exchange no longer holds
+func (r *reconciler) closeStored(ctx context.Context, followerID string, stored *models.Position) (*models.PositionDrift, error) {
+	now := time.Now()
+	drift := &models.PositionDrift{
+		ID:             uuid.New().String(),
+		FollowerID:     followerID,
+		RelationshipID: stored.CopyRelationshipID,
+		TokenSymbol:    stored.TokenSymbol,
+		RecordedSize:   signedSize(stored),
+		Action:         models.DriftClosed,
+		DetectedAt:     now,
+	}
+
+	stored.Size = 0
+	stored.UnrealizedPnL = 0
+	stored.UpdatedAt = now
+	if err := r.postgres.UpdatePosition(ctx, stored); err != nil {
+		return nil, fmt.Errorf("failed to close %s position: %w", stored.TokenSymbol, err)
+	}
+
+	if err := r.postgres.RecordPositionDrift(ctx, drift); err != nil {
+		return nil, err
+	}
+
+	r.log.Warnf("Closed stored %s position %s for follower %s, not held on the exchange",
+		stored.TokenSymbol, stored.ID, followerID)
+	return drift, nil
+}
+
+// correctSymbol compares the exchange position with the expected exposure and places
+// a corrective order when every contributing relationship allows auto rebalancing
+func (r *reconciler) correctSymbol(ctx context.Context, followerID, symbol string, stored *models.Position,
+	live *exchange.Position, exposure *followerExposure) (*models.PositionDrift, error) {
+
+	expected := exposure.sizes[symbol]
+	var actual float64
+	if live != nil {
+		actual = live.Size
+	}
+
+	if r.inSync(expected, actual) {
+		return nil, nil
+	}
+
+	relationshipID, attributed := exposure.owners[symbol]
+	if !attributed && stored != nil && stored.CopyRelationshipID != nil {
+		relationshipID, attributed = *stored.CopyRelationshipID, true
+	}
+	if !attributed {
+		// Not held because of any relationship, so there's nothing to correct against
+		return nil, nil
+	}
+
+	drift := &models.PositionDrift{
+		ID:             uuid.New().String(),
+		FollowerID:     followerID,
+		RelationshipID: &relationshipID,
+		TokenSymbol:    symbol,
+		RecordedSize:   signedSize(stored),
+		ExchangeSize:   actual,
+		ExpectedSize:   &expected,
+		Action:         models.DriftOutOfSync,
+		DetectedAt:     time.Now(),
+	}
+
+	correctable := r.config.PlaceCorrectiveOrders && exposure.auto[relationshipID] && !exposure.manual[symbol]
+	if correctable {
+		placed, err := r.placeCorrection(ctx, followerID, symbol, actual, expected-actual)
+		if err != nil {
+			return nil, err
+		}
+		if placed {
+			drift.Action = models.DriftCorrected
+		}
+	}
+
+	if err := r.postgres.RecordPositionDrift(ctx, drift); err != nil {
+		return nil, err
+	}
+
+	return drift, nil
+}
+
+func (r *reconciler) placeCorrection(ctx context.Context, followerID, symbol string, actual, delta float64) (bool, error) {
+	prices, err := r.exchange.GetMidPrices(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get prices: %w", err)
+	}
+
+	mid, ok := prices[symbol]
+	if !ok || mid <= 0 {
+		return false, fmt.Errorf("no price available for %s", symbol)
+	}
+	if math.Abs(delta)*mid < r.config.MinCorrectionSize {
+		return false, nil
+	}
+
+	side := exchange.SideBuy
+	if delta < 0 {
+		side = exchange.SideSell
+	}
+
+	result, err := r.exchange.PlaceOrder(ctx, &exchange.OrderRequest{
+		Account:        followerID,
+		Symbol:         symbol,
+		Side:           side,
+		Type:           exchange.OrderTypeMarket,
+		Size:           math.Abs(delta),
+		Price:          mid,
+		ReduceOnly:     actual != 0 && (actual > 0) != (delta > 0) && math.Abs(delta) <= math.Abs(actual),
+		MaxSlippageBps: r.config.MaxSlippage,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to place corrective order: %w", err)
+	}
+
+	r.log.Infof("Placed corrective %s %s order for follower %s: %.6f requested, %.6f filled",
+		side, symbol, followerID, math.Abs(delta), result.FilledSize)
+	return result.FilledSize > 0, nil
+}
+
+// inSync reports whether two signed sizes agree within the configured tolerance
+func (r *reconciler) inSync(a, b float64) bool {
+	diff := math.Abs(a - b)
+	if diff < 1e-9 {
+		return true
+	}
+	return diff <= r.config.Tolerance*math.Max(math.Abs(a), math.Abs(b))
+}
+
+// applyLivePosition copies the exchange's view of a position onto a stored row
+func applyLivePosition(position *models.Position, live *exchange.Position, now time.Time) {
+	position.Side = models.PositionLong
+	if live.Size < 0 {
+		position.Side = models.PositionShort
+	}
+
+	currentPrice := live.PositionValue / math.Abs(live.Size)
+	position.Size = math.Abs(live.Size)
+	position.EntryPrice = live.EntryPrice
+	position.CurrentPrice = &currentPrice
+	position.UnrealizedPnL = live.UnrealizedPnL
+	position.Leverage = live.Leverage
+	position.LiquidationPrice = live.LiquidationPrice
+	position.UpdatedAt = now
+}
+
+// signedSize returns a stored position's size, negative for shorts
+func signedSize(position *models.Position) float64 {
+	if position == nil {
+		return 0
+	}
+	if position.Side == models.PositionShort {
+		return -position.Size
+	}
+	return position.Size
+}
+
+func unionSymbols(stored map[string]*models.Position, live map[string]*exchange.Position, expected map[string]float64) []string {
+	seen := make(map[string]bool)
+	for symbol := range stored {
+		seen[symbol] = true
+	}
+	for symbol := range live {
+		seen[symbol] = true
+	}
+	for symbol := range expected {
+		seen[symbol] = true
+	}
+
+	symbols := make([]string, 0, len(seen))
+	for symbol := range seen {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hyperdash/copy-engine/internal/config"
+	"github.com/hyperdash/copy-engine/internal/database"
+	"github.com/hyperdash/copy-engine/internal/exchange"
+	"github.com/hyperdash/copy-engine/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// fakeReconcileStore serves a follower's stored positions and relationships and
+// records what the reconciler writes back
+type fakeReconcileStore struct {
+	database.PostgreSQL
+
+	mu            sync.Mutex
+	positions     []*models.Position
+	relationships []*models.CopyRelationship
+	addresses     map[string]string
+	drifts        []*models.PositionDrift
+}
+
+func (f *fakeReconcileStore) GetFollowerPositions(ctx context.Context, followerID string) ([]*models.Position, error) {
+	return f.positions, nil
+}
+
+func (f *fakeReconcileStore) GetCopyRelationshipsByFollower(ctx context.Context, followerID string) ([]*models.CopyRelationship, error) {
+	return f.relationships, nil
+}
+
+func (f *fakeReconcileStore) GetActiveTraderAddresses(ctx context.Context) (map[string]string, error) {
+	return f.addresses, nil
+}
+
+func (f *fakeReconcileStore) CreatePosition(ctx context.Context, position *models.Position) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.positions = append(f.positions, position)
+	return nil
+}
+
+func (f *fakeReconcileStore) UpdatePosition(ctx context.Context, position *models.Position) error {
+	return nil
+}
+
+func (f *fakeReconcileStore) RecordPositionDrift(ctx context.Context, drift *models.PositionDrift) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.drifts = append(f.drifts, drift)
+	return nil
+}
+
+// stubAdapter serves positions by account the way the Hyperliquid adapter resolves
+// them: addresses are read as is, anything else must be a bound account
+type stubAdapter struct {
+	exchange.Adapter
+
+	mu        sync.Mutex
+	positions map[string][]*exchange.Position
+	prices    map[string]float64
+	orders    []*exchange.OrderRequest
+}
+
+func (s *stubAdapter) GetPositions(ctx context.Context, account string) ([]*exchange.Position, error) {
+	positions, ok := s.positions[account]
+	if !ok && !strings.HasPrefix(account, "0x") {
+		return nil, fmt.Errorf("%w: %s", exchange.ErrAccountNotBound, account)
+	}
+	return positions, nil
+}
+
+func (s *stubAdapter) GetMidPrices(ctx context.Context) (map[string]float64, error) {
+	return s.prices, nil
+}
+
+func (s *stubAdapter) PlaceOrder(ctx context.Context, req *exchange.OrderRequest) (*exchange.OrderResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orders = append(s.orders, req)
+	return &exchange.OrderResult{Status: exchange.OrderStatusFilled, FilledSize: req.Size, AvgPrice: req.Price}, nil
+}
+
+func newTestReconciler(store database.PostgreSQL, adapter exchange.Adapter) *reconciler {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	return NewReconciler(store, adapter, config.ReconcilerConfig{
+		Interval:              30,
+		Tolerance:             0.001,
+		PlaceCorrectiveOrders: true,
+		MinCorrectionSize:     10,
+		MaxSlippage:           50,
+	}, log).(*reconciler)
+}
+
+func TestReconcileFollowerReadsTradersByAddress(t *testing.T) {
+	followerID := "follower-1"
+	store := &fakeReconcileStore{
+		positions: []*models.Position{
+			{ID: "position-1", UserID: &followerID, TokenSymbol: "ETH", Side: models.PositionLong, Size: 2},
+		},
+		relationships: []*models.CopyRelationship{
+			{ID: "relationship-1", FollowerID: followerID, TraderID: "trader-1", AllocationPercent: 50, IsActive: true, AutoRebalance: true},
+		},
+		addresses: map[string]string{"trader-1": "0x00000000000000000000000000000000000000aa"},
+	}
+	adapter := &stubAdapter{
+		positions: map[string][]*exchange.Position{
+			followerID: {{Symbol: "ETH", Size: 2, PositionValue: 6400}},
+			"0x00000000000000000000000000000000000000aa": {{Symbol: "ETH", Size: 10, PositionValue: 32000}},
+		},
+		prices: map[string]float64{"ETH": 3200},
+	}
+
+	drifts, err := newTestReconciler(store, adapter).ReconcileFollower(context.Background(), followerID)
+	if err != nil {
+		t.Fatalf("ReconcileFollower: %v", err)
+	}
+
+	if len(drifts) != 1 || drifts[0].Action != models.DriftCorrected {
+		t.Fatalf("drifts = %+v, want one corrected drift", drifts)
+	}
+	if expected := drifts[0].ExpectedSize; expected == nil || *expected != 5 {
+		t.Errorf("expected size = %v, want half of the trader's 10 ETH", expected)
+	}
+
+	if len(adapter.orders) != 1 {
+		t.Fatalf("placed %d orders, want 1", len(adapter.orders))
+	}
+	order := adapter.orders[0]
+	if order.Account != followerID || order.Side != exchange.SideBuy || order.Size != 3 || order.ReduceOnly {
+		t.Errorf("corrective order = %+v, want a 3 ETH buy for the follower", order)
+	}
+}
+
+func TestReconcileFollowerWithoutTraderAddress(t *testing.T) {
+	followerID := "follower-1"
+	store := &fakeReconcileStore{
+		relationships: []*models.CopyRelationship{
+			{ID: "relationship-1", FollowerID: followerID, TraderID: "trader-1", AllocationPercent: 50, IsActive: true, AutoRebalance: true},
+		},
+		addresses: map[string]string{},
+	}
+	adapter := &stubAdapter{
+		positions: map[string][]*exchange.Position{
+			followerID: {{Symbol: "ETH", Size: 2, PositionValue: 6400}},
+		},
+		prices: map[string]float64{"ETH": 3200},
+	}
+
+	_, err := newTestReconciler(store, adapter).ReconcileFollower(context.Background(), followerID)
+	if err == nil || errors.Is(err, exchange.ErrAccountNotBound) {
+		t.Fatalf("err = %v, want a missing address error before reading the exchange", err)
+	}
+	if len(adapter.orders) != 0 || len(store.drifts) != 0 {
+		t.Errorf("placed %d orders and recorded %d drifts, want none without the trader's positions",
+			len(adapter.orders), len(store.drifts))
+	}
+}
+
+func TestReconcileFollowerRepairsStoredPositions(t *testing.T) {
+	followerID := "follower-1"
+	store := &fakeReconcileStore{
+		positions: []*models.Position{
+			{ID: "position-1", UserID: &followerID, TokenSymbol: "BTC", Side: models.PositionLong, Size: 1},
+		},
+		addresses: map[string]string{},
+	}
+	adapter := &stubAdapter{
+		positions: map[string][]*exchange.Position{
+			followerID: {{Symbol: "SOL", Size: -4, PositionValue: 600, EntryPrice: 150}},
+		},
+	}
+
+	drifts, err := newTestReconciler(store, adapter).ReconcileFollower(context.Background(), followerID)
+	if err != nil {
+		t.Fatalf("ReconcileFollower: %v", err)
+	}
+
+	actions := make(map[string]models.DriftAction)
+	for _, drift := range drifts {
+		actions[drift.TokenSymbol] = drift.Action
+	}
+	if actions["BTC"] != models.DriftClosed || actions["SOL"] != models.DriftInserted {
+		t.Errorf("actions = %v, want BTC closed and SOL inserted", actions)
+	}
+	if len(adapter.orders) != 0 {
+		t.Errorf("placed %d orders for positions no relationship explains", len(adapter.orders))
+	}
+
+	inserted := store.positions[len(store.positions)-1]
+	if inserted.TokenSymbol != "SOL" || inserted.Side != models.PositionShort || inserted.Size != 4 {
+		t.Errorf("inserted position = %+v, want a 4 SOL short", inserted)
+	}
+}
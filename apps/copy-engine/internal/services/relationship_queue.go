@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/hyperdash/copy-engine/internal/models"
+)
+
+// relationshipHold keeps a relationship's executions in trade order while one of
+// them waits for a retry. The held executions are already claimed in the database,
+// so if the engine stops they are resumed by stale execution recovery.
+type relationshipHold struct {
+	retrying map[string]bool     // executions of the relationship awaiting a retry
+	queued   []*executionAttempt // later executions, in the order their trades arrived
+	draining bool
+}
+
+// holdForRetry holds the relationship's later executions until this one is settled
+func (ce *copyEngine) holdForRetry(execution *models.CopyExecution) {
+	ce.holdsMu.Lock()
+	defer ce.holdsMu.Unlock()
+
+	hold, ok := ce.holds[execution.Relationship.ID]
+	if !ok {
+		hold = &relationshipHold{retrying: make(map[string]bool)}
+		ce.holds[execution.Relationship.ID] = hold
+	}
+	hold.retrying[execution.ID] = true
+}
+
+// queueBehindRetry queues the attempt if its relationship is held and reports
+// whether it did
+func (ce *copyEngine) queueBehindRetry(attempt *executionAttempt) bool {
+	ce.holdsMu.Lock()
+	defer ce.holdsMu.Unlock()
+
+	hold, ok := ce.holds[attempt.execution.Relationship.ID]
+	if !ok {
+		return false
+	}
+	hold.queued = append(hold.queued, attempt)
+	return true
+}
+
+// settleHold is called once an execution completed, failed or was dead-lettered.
+// When the last retrying execution of its relationship settles, the executions held
+// behind it run in order.
+func (ce *copyEngine) settleHold(execution *models.CopyExecution) {
+	relationshipID := execution.Relationship.ID
+
+	ce.holdsMu.Lock()
+	defer ce.holdsMu.Unlock()
+
+	hold, ok := ce.holds[relationshipID]
+	if !ok {
+		return
+	}
+	delete(hold.retrying, execution.ID)
+	if len(hold.retrying) > 0 || hold.draining {
+		return
+	}
+	if len(hold.queued) == 0 {
+		delete(ce.holds, relationshipID)
+		return
+	}
+
+	hold.draining = true
+	ce.wg.Add(1)
+	go ce.drainHold(relationshipID)
+}
+
+// drainHold attempts the held executions one at a time until none are left or one
+// of them is scheduled for a retry, which holds the rest again
+func (ce *copyEngine) drainHold(relationshipID string) {
+	defer ce.wg.Done()
+
+	for {
+		ce.holdsMu.Lock()
+		hold := ce.holds[relationshipID]
+		if len(hold.retrying) > 0 || len(hold.queued) == 0 || ce.ctx.Err() != nil {
+			hold.draining = false
+			if len(hold.retrying) == 0 && len(hold.queued) == 0 {
+				delete(ce.holds, relationshipID)
+			}
+			ce.holdsMu.Unlock()
+			return
+		}
+		attempt := hold.queued[0]
+		hold.queued = hold.queued[1:]
+		ce.holdsMu.Unlock()
+
+		ctx, cancel := context.WithTimeout(ce.ctx, 30*time.Second)
+		// Stale execution recovery takes over executions held for longer than an
+		// attempt may take; those are retried on their own
+		stored, err := ce.postgres.GetCopyExecution(ctx, attempt.execution.ID)
+		if err == nil && stored.Status != attempt.execution.Status {
+			ce.log.Infof("Held copy execution %s is already %s, skipping", attempt.execution.ID, stored.Status)
+		} else {
+			ce.attemptExecution(ctx, attempt)
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperdash/copy-engine/internal/exchange"
+	"github.com/hyperdash/copy-engine/internal/models"
+)
+
+// flakyAdapter fails the first failures orders with a temporary exchange error and
+// fills the rest, recording the client order ID of every order it receives
+type flakyAdapter struct {
+	exchange.Adapter
+
+	mu       sync.Mutex
+	failures int
+	orders   []string
+}
+
+func (a *flakyAdapter) PlaceOrder(ctx context.Context, req *exchange.OrderRequest) (*exchange.OrderResult, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.orders = append(a.orders, req.ClientOrderID)
+	if a.failures > 0 {
+		a.failures--
+		return nil, &exchange.APIError{StatusCode: 503, Message: "unavailable"}
+	}
+	return &exchange.OrderResult{Status: exchange.OrderStatusFilled, FilledSize: req.Size, AvgPrice: req.Price}, nil
+}
+
+func (a *flakyAdapter) GetFills(ctx context.Context, account string, since time.Time) ([]*exchange.Fill, error) {
+	return nil, nil
+}
+
+func (a *flakyAdapter) placed() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]string(nil), a.orders...)
+}
+
+func heldAttempt(store *fakeExecutionStore, id string) *executionAttempt {
+	execution, _ := store.GetCopyExecution(context.Background(), id)
+	return &executionAttempt{
+		execution:     execution,
+		originalTrade: &models.Trade{ID: "trade-" + id, TokenSymbol: "BTC", Side: models.TradeBuy, Size: 1, Price: 64000},
+		copySize:      2,
+	}
+}
+
+func TestLaterExecutionsWaitForARetryingOne(t *testing.T) {
+	const first, second, third = "6a1f0c6e-0b7e-4a43-8f1c-6d2a1e9b7c01", "6a1f0c6e-0b7e-4a43-8f1c-6d2a1e9b7c02", "6a1f0c6e-0b7e-4a43-8f1c-6d2a1e9b7c03"
+	store := newFakeExecutionStore(
+		storedExecution(first, models.StatusPending, time.Now(), nil),
+		storedExecution(second, models.StatusPending, time.Now(), nil),
+		storedExecution(third, models.StatusPending, time.Now(), nil),
+	)
+	adapter := &flakyAdapter{failures: 1}
+	ce := newTestRetryEngine(t, store)
+	ce.exchange = adapter
+
+	// The first trade fails and is scheduled for a retry, so the later ones are held
+	ce.attemptExecution(context.Background(), heldAttempt(store, first))
+	if got := store.status(first); got != models.StatusRetrying {
+		t.Fatalf("first execution %s, want retrying", got)
+	}
+	for _, id := range []string{second, third} {
+		if !ce.queueBehindRetry(heldAttempt(store, id)) {
+			t.Fatalf("execution %s was not held behind the retrying one", id)
+		}
+	}
+	if placed := adapter.placed(); len(placed) != 1 {
+		t.Fatalf("placed %d orders while the first execution awaits its retry, want 1", len(placed))
+	}
+
+	ce.wg.Add(1)
+	go ce.retryProcessor()
+	t.Cleanup(func() {
+		ce.cancel()
+		ce.wg.Wait()
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for store.status(third) != models.StatusCompleted {
+		if time.Now().After(deadline) {
+			t.Fatalf("statuses %s, %s, %s, want all completed", store.status(first), store.status(second), store.status(third))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	want := []string{clientOrderID(first), clientOrderID(first), clientOrderID(second), clientOrderID(third)}
+	placed := adapter.placed()
+	if len(placed) != len(want) {
+		t.Fatalf("placed %v, want %v", placed, want)
+	}
+	for i := range want {
+		if placed[i] != want[i] {
+			t.Fatalf("placed %v, want the retry before the held executions: %v", placed, want)
+		}
+	}
+
+	for held := true; held; {
+		ce.holdsMu.Lock()
+		held = len(ce.holds) > 0
+		ce.holdsMu.Unlock()
+		if held && time.Now().After(deadline) {
+			t.Fatal("relationship still held after every execution completed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestExecutionsAreNotHeldWithoutARetry(t *testing.T) {
+	store := newFakeExecutionStore(storedExecution("execution-1", models.StatusPending, time.Now(), nil))
+	ce := newTestRetryEngine(t, store)
+
+	if ce.queueBehindRetry(heldAttempt(store, "execution-1")) {
+		t.Error("execution held although no execution of its relationship awaits a retry")
+	}
+}
@@ -0,0 +1,379 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hyperdash/copy-engine/internal/exchange"
+	"github.com/hyperdash/copy-engine/internal/models"
+	"github.com/hyperdash/copy-engine/internal/risk"
+)
+
+// maxRetryBackoff caps the delay between two attempts of the same execution
+const maxRetryBackoff = 5 * time.Minute
+
+// errOrderOutcomeUnknown is returned (wrapped) when an order may have reached the
+// exchange but its status could not be looked up. Placing it again could copy the
+// trade twice, so the execution is dead-lettered instead of retried.
+var errOrderOutcomeUnknown = errors.New("order outcome unknown")
+
+// executionAttempt is everything needed to (re)run a copy execution
+type executionAttempt struct {
+	execution     *models.CopyExecution
+	originalTrade *models.Trade
+	copySize      float64
+	attempts      int
+}
+
+// attemptExecution runs one attempt of a claimed execution and decides what happens
+// on failure: schedule a retry, fail it, or move it to the dead-letter store.
+func (ce *copyEngine) attemptExecution(ctx context.Context, attempt *executionAttempt) {
+	execution := attempt.execution
+	retrying := false
+	defer func() {
+		if !retrying {
+			ce.settleHold(execution)
+		}
+	}()
+
+	attempt.attempts++
+	execution.Parameters["attempts"] = attempt.attempts
+
+	err := ce.executeCopyTrade(ctx, execution, attempt.originalTrade, attempt.copySize)
+	if err == nil {
+		if err := ce.transitionExecution(ctx, execution, models.StatusCompleted, "copy trade recorded"); err != nil {
+			ce.log.Errorf("Failed to mark copy execution %s completed: %v", execution.ID, err)
+			return
+		}
+		ce.log.Infof("Successfully executed copy trade for relationship %s", execution.Relationship.ID)
+		return
+	}
+
+	message := err.Error()
+	execution.ErrorMessage = &message
+
+	if errors.Is(err, errOrderOutcomeUnknown) {
+		ce.deadLetter(ctx, attempt, "order outcome unknown", message)
+		return
+	}
+
+	if !isRetryable(err) {
+		var events []*models.OutboxEvent
+		var rejection *risk.RejectionError
+		if errors.As(err, &rejection) {
+			rejection.RecordOn(execution)
+			if event, err := riskBreachEvent(execution, rejection); err != nil {
+				ce.log.Warnf("Failed to build risk breach event for execution %s: %v", execution.ID, err)
+			} else {
+				events = append(events, event)
+			}
+		}
+		ce.log.Errorf("Copy execution %s failed permanently: %v", execution.ID, err)
+		if err := ce.transitionExecution(ctx, execution, models.StatusFailed, message, events...); err != nil {
+			ce.log.Errorf("Failed to mark copy execution %s failed: %v", execution.ID, err)
+		}
+		return
+	}
+
+	if attempt.attempts > ce.config.RetryAttempts {
+		ce.deadLetter(ctx, attempt, "retries exhausted", message)
+		return
+	}
+
+	if execution.Status != models.StatusRetrying {
+		if err := ce.transitionExecution(ctx, execution, models.StatusRetrying, message); err != nil {
+			ce.log.Errorf("Failed to schedule retry of copy execution %s: %v", execution.ID, err)
+			return
+		}
+	}
+
+	delay := ce.retryBackoff(attempt.attempts)
+	ce.log.Warnf("Copy execution %s failed (attempt %d of %d), retrying in %s: %v",
+		execution.ID, attempt.attempts, ce.config.RetryAttempts+1, delay, err)
+	retrying = true
+	ce.scheduleRetry(attempt, delay)
+}
+
+// deadLetter fails an execution that cannot be retried automatically and stores it
+// for replay
+func (ce *copyEngine) deadLetter(ctx context.Context, attempt *executionAttempt, reason, lastError string) {
+	execution := attempt.execution
+	if !execution.Status.CanTransitionTo(models.StatusFailed) {
+		ce.log.Errorf("Cannot dead-letter copy execution %s: %s -> %s", execution.ID, execution.Status, models.StatusFailed)
+		return
+	}
+
+	deadLetter := &models.DeadLetter{
+		ID:             uuid.New().String(),
+		ExecutionID:    execution.ID,
+		RelationshipID: execution.Relationship.ID,
+		OriginalTrade:  attempt.originalTrade,
+		CopySize:       attempt.copySize,
+		Attempts:       attempt.attempts,
+		LastError:      lastError,
+		CreatedAt:      time.Now(),
+	}
+	err := ce.applyTransition(ctx, execution, models.StatusFailed, reason+": "+lastError, func(transition *models.ExecutionTransition) error {
+		return ce.postgres.DeadLetterCopyExecution(ctx, execution, transition, deadLetter)
+	})
+	if err != nil {
+		ce.log.Errorf("Failed to dead-letter copy execution %s: %v", execution.ID, err)
+		return
+	}
+
+	ce.log.Errorf("Copy execution %s moved to dead letter %s after %d attempts: %s",
+		execution.ID, deadLetter.ID, attempt.attempts, reason)
+}
+
+// scheduleRetry hands the attempt to the retry processor once the delay has passed.
+// Until the execution is settled, the relationship's later executions wait behind it.
+func (ce *copyEngine) scheduleRetry(attempt *executionAttempt, delay time.Duration) {
+	ce.holdForRetry(attempt.execution)
+	time.AfterFunc(delay, func() {
+		select {
+		case ce.retryChan <- attempt:
+		case <-ce.ctx.Done():
+		}
+	})
+}
+
+func (ce *copyEngine) retryProcessor() {
+	defer ce.wg.Done()
+
+	for {
+		select {
+		case <-ce.ctx.Done():
+			return
+		case attempt := <-ce.retryChan:
+			ctx, cancel := context.WithTimeout(ce.ctx, 30*time.Second)
+			ce.attemptExecution(ctx, attempt)
+			cancel()
+		}
+	}
+}
+
+// retryBackoff returns the jittered delay before the next attempt: an exponential
+// step from RetryBackoffBase, of which a random half is kept
+func (ce *copyEngine) retryBackoff(attempts int) time.Duration {
+	base := time.Duration(ce.config.RetryBackoffBase) * time.Second
+	if base <= 0 {
+		base = time.Second
+	}
+
+	backoff := time.Duration(float64(base) * math.Pow(2, float64(attempts-1)))
+	if backoff <= 0 || backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// recoverRetries requeues executions that were waiting for a retry when the engine stopped
+func (ce *copyEngine) recoverRetries(ctx context.Context) error {
+	executions, err := ce.postgres.GetCopyExecutionsByStatus(ctx, models.StatusRetrying)
+	if err != nil {
+		return fmt.Errorf("failed to load retrying executions: %w", err)
+	}
+
+	for _, execution := range executions {
+		attempt, err := ce.restoreAttempt(ctx, execution)
+		if err != nil {
+			ce.log.Errorf("Cannot resume copy execution %s: %v", execution.ID, err)
+			continue
+		}
+		ce.scheduleRetry(attempt, ce.retryBackoff(1))
+	}
+
+	if len(executions) > 0 {
+		ce.log.Infof("Requeued %d copy executions awaiting retry", len(executions))
+	}
+
+	return ce.recoverStaleExecutions(ctx)
+}
+
+// staleExecutionMonitor periodically recovers executions whose attempt was
+// interrupted, including those of other instances that crashed
+func (ce *copyEngine) staleExecutionMonitor() {
+	defer ce.wg.Done()
+
+	ticker := time.NewTicker(ce.staleExecutionAfter())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ce.ctx.Done():
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(ce.ctx, 30*time.Second)
+			if err := ce.recoverStaleExecutions(ctx); err != nil {
+				ce.log.Errorf("Failed to recover stale executions: %v", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// recoverStaleExecutions resumes pending and executing executions nobody has
+// touched for longer than an attempt can take. A pending execution never placed
+// its order and an executing one that recorded its fill only has the trade left
+// to store, so both are retried. Any other executing execution may or may not
+// have placed its order; retrying could copy the trade twice, so it is
+// dead-lettered for an operator to check the follower's account and replay.
+func (ce *copyEngine) recoverStaleExecutions(ctx context.Context) error {
+	executions, err := ce.postgres.GetStaleCopyExecutions(ctx, time.Now().Add(-ce.staleExecutionAfter()))
+	if err != nil {
+		return fmt.Errorf("failed to load stale executions: %w", err)
+	}
+
+	for _, execution := range executions {
+		attempt, err := ce.restoreAttempt(ctx, execution)
+		if err != nil {
+			ce.log.Errorf("Cannot resume copy execution %s: %v", execution.ID, err)
+			continue
+		}
+
+		if execution.Status == models.StatusExecuting && paramFloat(execution.Parameters, "filled_size", 0) <= 0 {
+			message := "interrupted while executing, the order's outcome is unknown"
+			execution.ErrorMessage = &message
+			ce.deadLetter(ctx, attempt, "interrupted", message)
+			continue
+		}
+
+		if err := ce.transitionExecution(ctx, execution, models.StatusRetrying, "recovered stale "+string(execution.Status)+" execution"); err != nil {
+			// Another instance may have recovered it first
+			ce.log.Warnf("Failed to recover stale copy execution %s: %v", execution.ID, err)
+			continue
+		}
+		ce.scheduleRetry(attempt, ce.retryBackoff(1))
+	}
+
+	if len(executions) > 0 {
+		ce.log.Infof("Recovered %d stale copy executions", len(executions))
+	}
+	return nil
+}
+
+// staleExecutionAfter is how long an unfinished execution may go without an update
+// before it is considered interrupted
+func (ce *copyEngine) staleExecutionAfter() time.Duration {
+	after := time.Duration(ce.config.StaleExecutionAfter) * time.Second
+	if after <= 0 {
+		after = 5 * time.Minute
+	}
+	return after
+}
+
+// restoreAttempt rebuilds an attempt from a stored execution's parameters
+func (ce *copyEngine) restoreAttempt(ctx context.Context, execution *models.CopyExecution) (*executionAttempt, error) {
+	relationship, err := ce.postgres.GetCopyRelationship(ctx, execution.Relationship.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load relationship: %w", err)
+	}
+	execution.Relationship = relationship
+
+	data, err := json.Marshal(execution.Parameters["original_trade"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode original trade: %w", err)
+	}
+	var originalTrade models.Trade
+	if err := json.Unmarshal(data, &originalTrade); err != nil || originalTrade.ID == "" {
+		return nil, fmt.Errorf("execution has no original trade snapshot")
+	}
+
+	return &executionAttempt{
+		execution:     execution,
+		originalTrade: &originalTrade,
+		copySize:      paramFloat(execution.Parameters, "calculated_size", 0),
+		attempts:      int(paramFloat(execution.Parameters, "attempts", 0)),
+	}, nil
+}
+
+// ListDeadLetters returns executions that exhausted their retries and were not replayed
+func (ce *copyEngine) ListDeadLetters(ctx context.Context, limit int) ([]*models.DeadLetter, error) {
+	return ce.postgres.ListDeadLetters(ctx, limit)
+}
+
+// ReplayDeadLetter sends a dead-lettered execution back through the retry scheduler
+// with a fresh set of attempts
+func (ce *copyEngine) ReplayDeadLetter(ctx context.Context, deadLetterID string) error {
+	deadLetter, err := ce.postgres.GetDeadLetter(ctx, deadLetterID)
+	if err != nil {
+		return err
+	}
+	if deadLetter.ReplayedAt != nil {
+		return fmt.Errorf("dead letter %s was already replayed at %s", deadLetterID, deadLetter.ReplayedAt)
+	}
+
+	execution, err := ce.postgres.GetCopyExecution(ctx, deadLetter.ExecutionID)
+	if err != nil {
+		return err
+	}
+
+	relationship, err := ce.postgres.GetCopyRelationship(ctx, deadLetter.RelationshipID)
+	if err != nil {
+		return fmt.Errorf("failed to load relationship: %w", err)
+	}
+	execution.Relationship = relationship
+
+	if !execution.Status.CanReplayTo(models.StatusRetrying) {
+		return fmt.Errorf("%w: cannot replay %s execution %s", models.ErrInvalidTransition, execution.Status, execution.ID)
+	}
+	err = ce.applyTransition(ctx, execution, models.StatusRetrying, "replayed from dead letter "+deadLetterID, func(transition *models.ExecutionTransition) error {
+		return ce.postgres.ReplayDeadLetter(ctx, deadLetterID, execution, transition)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to replay execution %s: %w", execution.ID, err)
+	}
+
+	ce.log.Infof("Replaying copy execution %s from dead letter %s", execution.ID, deadLetterID)
+	ce.scheduleRetry(&executionAttempt{
+		execution:     execution,
+		originalTrade: deadLetter.OriginalTrade,
+		copySize:      deadLetter.CopySize,
+	}, 0)
+
+	return nil
+}
+
+// riskBreachEvent reports a risk limit that stopped an execution
+func riskBreachEvent(execution *models.CopyExecution, rejection *risk.RejectionError) (*models.OutboxEvent, error) {
+	return models.NewOutboxEvent(models.EventRiskBreach, execution.Relationship.ID, models.RiskBreachEventData{
+		ExecutionID:    execution.ID,
+		RelationshipID: execution.Relationship.ID,
+		FollowerID:     execution.Relationship.FollowerID,
+		Reason:         string(rejection.Reason),
+		Message:        rejection.Message,
+	})
+}
+
+// isRetryable reports whether a failed attempt may succeed if run again. Risk
+// rejections and invalid orders are deliberate outcomes and are never retried.
+func isRetryable(err error) bool {
+	if _, rejected := risk.ReasonOf(err); rejected {
+		return false
+	}
+
+	switch {
+	case errors.Is(err, models.ErrInvalidTransition),
+		errors.Is(err, exchange.ErrInvalidOrder),
+		errors.Is(err, exchange.ErrUnknownSymbol),
+		errors.Is(err, exchange.ErrInsufficientMargin),
+		errors.Is(err, exchange.ErrSignerNotConfigured),
+		errors.Is(err, context.Canceled):
+		return false
+	}
+
+	var apiErr *exchange.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+
+	return true
+}
@@ -0,0 +1,412 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperdash/copy-engine/internal/config"
+	"github.com/hyperdash/copy-engine/internal/database"
+	"github.com/hyperdash/copy-engine/internal/exchange"
+	"github.com/hyperdash/copy-engine/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// fakeExecutionStore keeps copy executions and dead letters in memory. Methods the
+// tests do not use panic through the nil embedded interface.
+type fakeExecutionStore struct {
+	database.PostgreSQL
+
+	mu          sync.Mutex
+	executions  map[string]*models.CopyExecution
+	deadLetters map[string]*models.DeadLetter
+	transitions []*models.ExecutionTransition
+	trades      []*models.Trade
+	failCommit  error
+}
+
+func newFakeExecutionStore(executions ...*models.CopyExecution) *fakeExecutionStore {
+	store := &fakeExecutionStore{
+		executions:  make(map[string]*models.CopyExecution),
+		deadLetters: make(map[string]*models.DeadLetter),
+	}
+	for _, execution := range executions {
+		store.executions[execution.ID] = execution
+	}
+	return store
+}
+
+func (f *fakeExecutionStore) GetCopyRelationship(ctx context.Context, id string) (*models.CopyRelationship, error) {
+	return &models.CopyRelationship{ID: id, FollowerID: "follower-1", TraderID: "trader-1"}, nil
+}
+
+func (f *fakeExecutionStore) GetCopyExecution(ctx context.Context, id string) (*models.CopyExecution, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	execution, ok := f.executions[id]
+	if !ok {
+		return nil, database.ErrNotFound
+	}
+	copied := *execution
+	return &copied, nil
+}
+
+func (f *fakeExecutionStore) GetCopyExecutionsByStatus(ctx context.Context, status models.ExecutionStatus) ([]*models.CopyExecution, error) {
+	return f.find(func(e *models.CopyExecution) bool { return e.Status == status }), nil
+}
+
+func (f *fakeExecutionStore) GetStaleCopyExecutions(ctx context.Context, updatedBefore time.Time) ([]*models.CopyExecution, error) {
+	return f.find(func(e *models.CopyExecution) bool {
+		return (e.Status == models.StatusPending || e.Status == models.StatusExecuting) && e.UpdatedAt.Before(updatedBefore)
+	}), nil
+}
+
+func (f *fakeExecutionStore) find(match func(*models.CopyExecution) bool) []*models.CopyExecution {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var found []*models.CopyExecution
+	for _, execution := range f.executions {
+		if match(execution) {
+			copied := *execution
+			found = append(found, &copied)
+		}
+	}
+	return found
+}
+
+func (f *fakeExecutionStore) TransitionCopyExecution(ctx context.Context, execution *models.CopyExecution, transition *models.ExecutionTransition, events ...*models.OutboxEvent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.transition(execution, transition)
+}
+
+func (f *fakeExecutionStore) DeadLetterCopyExecution(ctx context.Context, execution *models.CopyExecution, transition *models.ExecutionTransition, deadLetter *models.DeadLetter) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failCommit != nil {
+		return f.failCommit
+	}
+	if err := f.transition(execution, transition); err != nil {
+		return err
+	}
+	f.deadLetters[deadLetter.ID] = deadLetter
+	return nil
+}
+
+func (f *fakeExecutionStore) GetDeadLetter(ctx context.Context, id string) (*models.DeadLetter, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	deadLetter, ok := f.deadLetters[id]
+	if !ok {
+		return nil, database.ErrNotFound
+	}
+	return deadLetter, nil
+}
+
+func (f *fakeExecutionStore) ReplayDeadLetter(ctx context.Context, id string, execution *models.CopyExecution, transition *models.ExecutionTransition) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failCommit != nil {
+		return f.failCommit
+	}
+	deadLetter := f.deadLetters[id]
+	if deadLetter.ReplayedAt != nil {
+		return fmt.Errorf("%w: unreplayed dead letter %s", database.ErrNotFound, id)
+	}
+	if err := f.transition(execution, transition); err != nil {
+		return err
+	}
+	replayedAt := transition.CreatedAt
+	deadLetter.ReplayedAt = &replayedAt
+	return nil
+}
+
+func (f *fakeExecutionStore) transition(execution *models.CopyExecution, transition *models.ExecutionTransition) error {
+	stored := f.executions[execution.ID]
+	if stored.Status != transition.FromStatus {
+		return fmt.Errorf("%w: execution %s is no longer %s", models.ErrInvalidTransition, execution.ID, transition.FromStatus)
+	}
+	stored.Status = transition.ToStatus
+	stored.UpdatedAt = transition.CreatedAt
+	f.transitions = append(f.transitions, transition)
+	return nil
+}
+
+func (f *fakeExecutionStore) CreateTrade(ctx context.Context, trade *models.Trade) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.trades = append(f.trades, trade)
+	return nil
+}
+
+func (f *fakeExecutionStore) status(id string) models.ExecutionStatus {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.executions[id].Status
+}
+
+// fakeStatusCache accepts execution status writes
+type fakeStatusCache struct {
+	database.Redis
+}
+
+func (fakeStatusCache) SetExecutionStatus(ctx context.Context, executionID string, status models.ExecutionStatus) error {
+	return nil
+}
+
+func newTestRetryEngine(t *testing.T, store *fakeExecutionStore) *copyEngine {
+	t.Helper()
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	return &copyEngine{
+		postgres:  store,
+		redis:     fakeStatusCache{},
+		log:       log,
+		config:    config.EngineConfig{RetryAttempts: 3, RetryBackoffBase: 1, StaleExecutionAfter: 60},
+		retryChan: make(chan *executionAttempt, 10),
+		holds:     make(map[string]*relationshipHold),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+func storedExecution(id string, status models.ExecutionStatus, updatedAt time.Time, params map[string]interface{}) *models.CopyExecution {
+	parameters := map[string]interface{}{
+		"calculated_size": 2.0,
+		"original_trade":  map[string]interface{}{"id": "trade-" + id, "token_symbol": "BTC", "size": 1.0},
+	}
+	for key, value := range params {
+		parameters[key] = value
+	}
+	return &models.CopyExecution{
+		ID:           id,
+		Relationship: &models.CopyRelationship{ID: "relationship-1"},
+		Status:       status,
+		Parameters:   parameters,
+		UpdatedAt:    updatedAt,
+	}
+}
+
+func TestRecoverRetriesResumesStaleExecutions(t *testing.T) {
+	stale := time.Now().Add(-time.Hour)
+	store := newFakeExecutionStore(
+		storedExecution("pending-stale", models.StatusPending, stale, nil),
+		storedExecution("pending-fresh", models.StatusPending, time.Now(), nil),
+		storedExecution("executing-filled", models.StatusExecuting, stale, map[string]interface{}{"filled_size": 2.0}),
+		storedExecution("executing-unknown", models.StatusExecuting, stale, nil),
+		storedExecution("retrying", models.StatusRetrying, stale, nil),
+	)
+	ce := newTestRetryEngine(t, store)
+
+	if err := ce.recoverRetries(context.Background()); err != nil {
+		t.Fatalf("recoverRetries: %v", err)
+	}
+
+	want := map[string]models.ExecutionStatus{
+		"pending-stale":     models.StatusRetrying,
+		"pending-fresh":     models.StatusPending, // may still be running on another instance
+		"executing-filled":  models.StatusRetrying,
+		"executing-unknown": models.StatusFailed,
+		"retrying":          models.StatusRetrying,
+	}
+	for id, status := range want {
+		if got := store.status(id); got != status {
+			t.Errorf("%s: status %s, want %s", id, got, status)
+		}
+	}
+
+	if len(store.deadLetters) != 1 {
+		t.Fatalf("%d dead letters, want 1", len(store.deadLetters))
+	}
+	for _, deadLetter := range store.deadLetters {
+		if deadLetter.ExecutionID != "executing-unknown" {
+			t.Errorf("dead-lettered %s, want executing-unknown", deadLetter.ExecutionID)
+		}
+	}
+
+	scheduled := make(map[string]bool)
+	timeout := time.After(3 * time.Second)
+	for len(scheduled) < 3 {
+		select {
+		case attempt := <-ce.retryChan:
+			scheduled[attempt.execution.ID] = true
+		case <-timeout:
+			t.Fatalf("scheduled %v, want pending-stale, executing-filled and retrying", scheduled)
+		}
+	}
+	for _, id := range []string{"pending-stale", "executing-filled", "retrying"} {
+		if !scheduled[id] {
+			t.Errorf("%s was not scheduled for a retry", id)
+		}
+	}
+}
+
+func TestDeadLetterKeepsExecutionWhenStoreFails(t *testing.T) {
+	store := newFakeExecutionStore(storedExecution("execution-1", models.StatusRetrying, time.Now(), nil))
+	store.failCommit = fmt.Errorf("connection reset")
+	ce := newTestRetryEngine(t, store)
+
+	execution, _ := store.GetCopyExecution(context.Background(), "execution-1")
+	ce.deadLetter(context.Background(), &executionAttempt{execution: execution, attempts: 4}, "retries exhausted", "timeout")
+
+	if execution.Status != models.StatusRetrying {
+		t.Errorf("in-memory status %s, want retrying", execution.Status)
+	}
+	if got := store.status("execution-1"); got != models.StatusRetrying {
+		t.Errorf("stored status %s, want retrying", got)
+	}
+	if len(store.deadLetters) != 0 {
+		t.Errorf("%d dead letters stored without their transition", len(store.deadLetters))
+	}
+}
+
+func TestReplayDeadLetter(t *testing.T) {
+	store := newFakeExecutionStore(storedExecution("execution-1", models.StatusRetrying, time.Now(), nil))
+	ce := newTestRetryEngine(t, store)
+
+	execution, _ := store.GetCopyExecution(context.Background(), "execution-1")
+	ce.deadLetter(context.Background(), &executionAttempt{
+		execution:     execution,
+		originalTrade: &models.Trade{ID: "trade-1"},
+		attempts:      4,
+	}, "retries exhausted", "timeout")
+	if len(store.deadLetters) != 1 {
+		t.Fatalf("%d dead letters, want 1", len(store.deadLetters))
+	}
+	var deadLetterID string
+	for id := range store.deadLetters {
+		deadLetterID = id
+	}
+
+	if err := ce.ReplayDeadLetter(context.Background(), deadLetterID); err != nil {
+		t.Fatalf("ReplayDeadLetter: %v", err)
+	}
+	if got := store.status("execution-1"); got != models.StatusRetrying {
+		t.Errorf("status %s after replay, want retrying", got)
+	}
+	if store.deadLetters[deadLetterID].ReplayedAt == nil {
+		t.Error("dead letter not marked replayed")
+	}
+
+	if err := ce.ReplayDeadLetter(context.Background(), deadLetterID); err == nil {
+		t.Error("replaying a dead letter twice succeeded")
+	}
+}
+
+// lostOrderExecutionID is a UUID so the execution has a client order ID
+const lostOrderExecutionID = "5f0c8a52-1d3e-4c47-9a7e-2b6f3c1d9e80"
+
+// lostOrderAdapter fails every order placement and answers status lookups and fill
+// queries with a scripted outcome
+type lostOrderAdapter struct {
+	exchange.Adapter
+
+	placeErr  error
+	status    *exchange.OrderResult
+	statusErr error
+	fills     []*exchange.Fill
+
+	placed, lookups int
+}
+
+func (a *lostOrderAdapter) PlaceOrder(ctx context.Context, req *exchange.OrderRequest) (*exchange.OrderResult, error) {
+	a.placed++
+	return nil, a.placeErr
+}
+
+func (a *lostOrderAdapter) GetOrderStatus(ctx context.Context, account string, cloid string) (*exchange.OrderResult, error) {
+	a.lookups++
+	if account != "follower-1" || cloid != clientOrderID(lostOrderExecutionID) {
+		return nil, fmt.Errorf("looked up %s of %s", cloid, account)
+	}
+	return a.status, a.statusErr
+}
+
+func (a *lostOrderAdapter) GetFills(ctx context.Context, account string, since time.Time) ([]*exchange.Fill, error) {
+	return a.fills, nil
+}
+
+func TestAttemptExecutionLooksUpOrdersThatMayHaveBeenSent(t *testing.T) {
+	timeout := fmt.Errorf("failed to place order: %w", context.DeadlineExceeded)
+
+	tests := []struct {
+		name        string
+		adapter     *lostOrderAdapter
+		wantStatus  models.ExecutionStatus
+		wantLookups int
+		wantTrades  int
+		wantPnL     float64
+		deadLetter  bool
+	}{
+		{
+			name: "filled despite the timeout",
+			adapter: &lostOrderAdapter{placeErr: timeout,
+				status: &exchange.OrderResult{OrderID: 7, Status: exchange.OrderStatusFilled, FilledSize: 2, AvgPrice: 64000},
+				fills: []*exchange.Fill{
+					{Symbol: "BTC", OrderID: 7, Size: 1.5, ClosedPnL: 90},
+					{Symbol: "BTC", OrderID: 6, Size: 1, ClosedPnL: -40},
+					{Symbol: "BTC", OrderID: 7, Size: 0.5, ClosedPnL: 30},
+				}},
+			wantStatus:  models.StatusCompleted,
+			wantLookups: 1,
+			wantTrades:  1,
+			wantPnL:     120,
+		},
+		{
+			name:        "never received",
+			adapter:     &lostOrderAdapter{placeErr: timeout, statusErr: fmt.Errorf("%w: cloid", exchange.ErrOrderNotFound)},
+			wantStatus:  models.StatusRetrying,
+			wantLookups: 1,
+		},
+		{
+			name:        "status unknown",
+			adapter:     &lostOrderAdapter{placeErr: timeout, statusErr: fmt.Errorf("connection reset")},
+			wantStatus:  models.StatusFailed,
+			wantLookups: 1,
+			deadLetter:  true,
+		},
+		{
+			name:       "exchange responded",
+			adapter:    &lostOrderAdapter{placeErr: &exchange.APIError{StatusCode: 502, Message: "bad gateway"}},
+			wantStatus: models.StatusRetrying,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeExecutionStore(storedExecution(lostOrderExecutionID, models.StatusPending, time.Now(), nil))
+			ce := newTestRetryEngine(t, store)
+			ce.exchange = tt.adapter
+
+			execution, _ := store.GetCopyExecution(context.Background(), lostOrderExecutionID)
+			execution.Relationship.FollowerID = "follower-1"
+			ce.attemptExecution(context.Background(), &executionAttempt{
+				execution:     execution,
+				originalTrade: &models.Trade{ID: "trade-1", TokenSymbol: "BTC", Side: models.TradeBuy, Size: 1, Price: 64000},
+				copySize:      2,
+			})
+
+			if got := store.status(lostOrderExecutionID); got != tt.wantStatus {
+				t.Errorf("status %s, want %s", got, tt.wantStatus)
+			}
+			if tt.adapter.placed != 1 || tt.adapter.lookups != tt.wantLookups {
+				t.Errorf("placed %d orders and looked up %d, want 1 and %d", tt.adapter.placed, tt.adapter.lookups, tt.wantLookups)
+			}
+			if len(store.trades) != tt.wantTrades {
+				t.Errorf("%d copy trades, want %d", len(store.trades), tt.wantTrades)
+			} else if tt.wantTrades > 0 && store.trades[0].RealizedPnL != tt.wantPnL {
+				t.Errorf("copy trade realized %v, want the order's closed PnL of %v", store.trades[0].RealizedPnL, tt.wantPnL)
+			}
+			if (len(store.deadLetters) == 1) != tt.deadLetter {
+				t.Errorf("%d dead letters, want dead-lettered %t", len(store.deadLetters), tt.deadLetter)
+			}
+		})
+	}
+}
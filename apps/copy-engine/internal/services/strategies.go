@@ -2,8 +2,12 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"math"
+	"time"
 
+	"github.com/hyperdash/copy-engine/internal/database"
 	"github.com/hyperdash/copy-engine/internal/models"
 	"github.com/sirupsen/logrus"
 )
@@ -18,9 +22,9 @@ func NewProportionalStrategy(log *logrus.Logger) *ProportionalStrategy {
 }
 
 func (s *ProportionalStrategy) CalculatePositionSize(ctx context.Context, signal *models.CopySignal, originalTrade *models.Trade) (float64, error) {
-	// Calculate size based on allocation percentage
-	allocationPercent := signal.Relationship.AllocationPercent / 100.0
-	calculatedSize := originalTrade.Size * allocationPercent
+	// Calculate size based on allocation percentage, which the strategy may override
+	allocationPercent := paramFloat(signal.Parameters, "allocation_percentage", signal.Relationship.AllocationPercent) / 100.0
+	calculatedSize := originalTrade.Size * allocationPercent * paramFloat(signal.Parameters, "size_multiplier", 1.0)
 
 	// Apply min/max constraints
 	if calculatedSize < signal.Relationship.MinAllocation {
@@ -48,6 +52,13 @@ func (s *ProportionalStrategy) GetStrategyType() models.StrategyType {
 	return models.StrategyProportional
 }
 
+func (s *ProportionalStrategy) ValidateParams(params models.StrategyParams) error {
+	return validateParams(params, map[string]paramRule{
+		"allocation_percentage": {min: 0, max: 100, exclusiveMin: true},
+		"size_multiplier":       {min: 0, max: 10, exclusiveMin: true},
+	})
+}
+
 // FixedStrategy implements fixed position sizing
 type FixedStrategy struct {
 	log *logrus.Logger
@@ -58,8 +69,8 @@ func NewFixedStrategy(log *logrus.Logger) *FixedStrategy {
 }
 
 func (s *FixedStrategy) CalculatePositionSize(ctx context.Context, signal *models.CopySignal, originalTrade *models.Trade) (float64, error) {
-	// Use fixed size from relationship max allocation as base
-	fixedSize := signal.Relationship.MaxAllocation
+	// Use the configured fixed size, falling back to the relationship max allocation
+	fixedSize := paramFloat(signal.Parameters, "fixed_size", signal.Relationship.MaxAllocation)
 	if fixedSize == 0 {
 		// If no max allocation set, use a default based on allocation percentage
 		fixedSize = originalTrade.Size * (signal.Relationship.AllocationPercent / 100.0)
@@ -83,31 +94,41 @@ func (s *FixedStrategy) GetStrategyType() models.StrategyType {
 	return models.StrategyFixed
 }
 
+func (s *FixedStrategy) ValidateParams(params models.StrategyParams) error {
+	return validateParams(params, map[string]paramRule{
+		"fixed_size": {min: 0, max: math.Inf(1), exclusiveMin: true},
+	})
+}
+
 // AdaptiveStrategy implements adaptive position sizing based on performance
 type AdaptiveStrategy struct {
-	log *logrus.Logger
+	log     *logrus.Logger
+	history HistoryProvider
 }
 
-func NewAdaptiveStrategy(log *logrus.Logger) *AdaptiveStrategy {
-	return &AdaptiveStrategy{log: log}
+func NewAdaptiveStrategy(log *logrus.Logger, history HistoryProvider) *AdaptiveStrategy {
+	return &AdaptiveStrategy{log: log, history: history}
 }
 
 func (s *AdaptiveStrategy) CalculatePositionSize(ctx context.Context, signal *models.CopySignal, originalTrade *models.Trade) (float64, error) {
-	// Get performance metrics to adjust size
-	// This would typically fetch from a metrics service
 	baseSize := originalTrade.Size * (signal.Relationship.AllocationPercent / 100.0)
 
-	// Adaptive multiplier based on win rate (placeholder logic)
-	// In practice, this would fetch actual performance metrics
+	// Adaptive multiplier based on the relationship's realized win rate
 	adaptiveMultiplier := 1.0
-	winRate := 0.5 // Placeholder - would fetch actual metrics
-
-	if winRate > 0.7 {
-		// High win rate, increase size by 20%
-		adaptiveMultiplier = 1.2
-	} else if winRate < 0.3 {
-		// Low win rate, decrease size by 30%
-		adaptiveMultiplier = 0.7
+	history, err := s.history.GetHistory(ctx, signal.Relationship)
+	if err != nil {
+		// Without history, size conservatively rather than at full allocation
+		s.log.Warnf("Failed to get performance history for relationship %s: %v", signal.Relationship.ID, err)
+		adaptiveMultiplier = paramFloat(signal.Parameters, "decrease_multiplier", 0.7)
+	} else if history.DecidedTrades < int(paramFloat(signal.Parameters, "min_trades", 10)) {
+		// Too few closed trades for the win rate to mean anything yet
+		s.log.Debugf("Relationship %s has %d closed trades, keeping base size", signal.Relationship.ID, history.DecidedTrades)
+	} else if history.WinRate > paramFloat(signal.Parameters, "high_win_rate", 0.7) {
+		// High win rate, increase size (20% by default)
+		adaptiveMultiplier = paramFloat(signal.Parameters, "increase_multiplier", 1.2)
+	} else if history.WinRate < paramFloat(signal.Parameters, "low_win_rate", 0.3) {
+		// Low win rate, decrease size (30% by default)
+		adaptiveMultiplier = paramFloat(signal.Parameters, "decrease_multiplier", 0.7)
 	}
 
 	calculatedSize := baseSize * adaptiveMultiplier
@@ -140,34 +161,133 @@ func (s *AdaptiveStrategy) GetStrategyType() models.StrategyType {
 	return models.StrategyAdaptive
 }
 
+func (s *AdaptiveStrategy) ValidateParams(params models.StrategyParams) error {
+	if err := validateParams(params, map[string]paramRule{
+		"high_win_rate":       {min: 0, max: 1},
+		"low_win_rate":        {min: 0, max: 1},
+		"increase_multiplier": {min: 1, max: 5},
+		"decrease_multiplier": {min: 0, max: 1, exclusiveMin: true},
+		"min_trades":          {min: 0, max: 1000, integer: true},
+	}); err != nil {
+		return err
+	}
+
+	if paramFloat(params, "low_win_rate", 0.3) >= paramFloat(params, "high_win_rate", 0.7) {
+		return fmt.Errorf("low_win_rate must be below high_win_rate")
+	}
+	return nil
+}
+
 // RiskBasedStrategy implements risk-adjusted position sizing
 type RiskBasedStrategy struct {
-	log *logrus.Logger
+	log      *logrus.Logger
+	postgres database.PostgreSQL
+}
+
+func NewRiskBasedStrategy(log *logrus.Logger, postgres database.PostgreSQL) *RiskBasedStrategy {
+	return &RiskBasedStrategy{log: log, postgres: postgres}
+}
+
+const (
+	// defaultDailyVolatility is assumed when a symbol has too little price history
+	defaultDailyVolatility = 0.05
+	// adverseMoveZ scales daily volatility to a one-sided 95% adverse move
+	adverseMoveZ = 1.65
+)
+
+// tradeRisk describes the risk a copied trade would add to the follower's book
+type tradeRisk struct {
+	DailyVolatility float64
+	AdverseMove     float64 // expected worst price move as a fraction of price
+	Leverage        float64 // follower's current notional-weighted leverage, at least 1
+	Concentration   float64 // follower's current HHI
+	AddsToSymbol    bool    // follower already holds the traded symbol
+}
+
+// PerTrade is the fraction of allocated capital lost if the adverse move happens
+func (r *tradeRisk) PerTrade() float64 {
+	return r.AdverseMove * r.Leverage
 }
 
-func NewRiskBasedStrategy(log *logrus.Logger) *RiskBasedStrategy {
-	return &RiskBasedStrategy{log: log}
+func (s *RiskBasedStrategy) assessRisk(ctx context.Context, signal *models.CopySignal, originalTrade *models.Trade) (*tradeRisk, error) {
+	window := time.Duration(paramFloat(signal.Parameters, "volatility_window_hours", 24)) * time.Hour
+	prices, err := s.postgres.GetHourlyClosePrices(ctx, originalTrade.TokenSymbol, time.Now().Add(-window))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price history: %w", err)
+	}
+
+	positions, err := s.postgres.GetFollowerPositions(ctx, signal.Relationship.FollowerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get follower positions: %w", err)
+	}
+
+	risk := &tradeRisk{
+		DailyVolatility: defaultDailyVolatility,
+		Leverage:        1.0,
+		Concentration:   concentrationRisk(positions),
+	}
+
+	if hourly, ok := realizedVolatility(prices); ok {
+		risk.DailyVolatility = hourly * math.Sqrt(24)
+	} else {
+		s.log.Debugf("Not enough %s price history for volatility, assuming %.2f daily",
+			originalTrade.TokenSymbol, defaultDailyVolatility)
+	}
+
+	risk.AdverseMove = adverseMoveZ * risk.DailyVolatility
+	if signal.Relationship.StopLossPercent != nil && *signal.Relationship.StopLossPercent > 0 {
+		// A stop loss caps the adverse move the follower is exposed to
+		risk.AdverseMove = math.Min(risk.AdverseMove, *signal.Relationship.StopLossPercent/100.0)
+	}
+
+	var notional, margin float64
+	for _, position := range positions {
+		if position.CurrentPrice == nil {
+			continue
+		}
+		value := position.Size * *position.CurrentPrice
+		notional += value
+		if position.Leverage > 0 {
+			margin += value / position.Leverage
+		} else {
+			margin += value
+		}
+		if position.TokenSymbol == originalTrade.TokenSymbol {
+			risk.AddsToSymbol = true
+		}
+	}
+	if margin > 0 {
+		risk.Leverage = math.Max(1.0, notional/margin)
+	}
+
+	return risk, nil
 }
 
 func (s *RiskBasedStrategy) CalculatePositionSize(ctx context.Context, signal *models.CopySignal, originalTrade *models.Trade) (float64, error) {
 	// Calculate size based on risk metrics
 	baseSize := originalTrade.Size * (signal.Relationship.AllocationPercent / 100.0)
 
-	// Risk adjustments (placeholder values)
-	volatilityAdjustment := 0.8    // Reduce size for high volatility
-	concentrationAdjustment := 1.0 // Adjust for concentration risk
-	leverageAdjustment := 1.0      // Adjust for leverage
+	risk, err := s.assessRisk(ctx, signal, originalTrade)
+	if err != nil {
+		return 0, err
+	}
 
-	// Apply risk adjustments
-	riskAdjustedSize := baseSize * volatilityAdjustment * concentrationAdjustment * leverageAdjustment
+	// Scale down so an adverse move at the follower's leverage loses at most the target risk
+	targetRisk := paramFloat(signal.Parameters, "target_risk_per_trade", 0.01)
+	riskAdjustment := 1.0
+	if perTrade := risk.PerTrade(); perTrade > targetRisk {
+		riskAdjustment = targetRisk / perTrade
+	}
 
-	// Apply stop loss consideration
-	if signal.Relationship.StopLossPercent != nil {
-		// Reduce size if stop loss is tight to maintain risk/reward ratio
-		stopLossAdjustment := math.Min(1.0, *signal.Relationship.StopLossPercent/2.0)
-		riskAdjustedSize *= stopLossAdjustment
+	// Adding to a symbol in an already concentrated book is scaled by how far HHI exceeds the limit
+	concentrationAdjustment := 1.0
+	maxConcentration := paramFloat(signal.Parameters, "max_concentration", 0.5)
+	if risk.AddsToSymbol && risk.Concentration > maxConcentration {
+		concentrationAdjustment = maxConcentration / risk.Concentration
 	}
 
+	riskAdjustedSize := baseSize * riskAdjustment * concentrationAdjustment
+
 	// Apply constraints
 	if riskAdjustedSize < signal.Relationship.MinAllocation {
 		riskAdjustedSize = signal.Relationship.MinAllocation
@@ -177,22 +297,31 @@ func (s *RiskBasedStrategy) CalculatePositionSize(ctx context.Context, signal *m
 		riskAdjustedSize = signal.Relationship.MaxAllocation
 	}
 
-	s.log.Debugf("Risk-based strategy calculated size %.2f for relationship %s",
-		riskAdjustedSize, signal.Relationship.ID)
+	s.log.Debugf("Risk-based strategy calculated size %.2f for relationship %s "+
+		"(daily vol %.4f, leverage %.2f, HHI %.3f, risk adj %.3f, concentration adj %.3f)",
+		riskAdjustedSize, signal.Relationship.ID, risk.DailyVolatility, risk.Leverage,
+		risk.Concentration, riskAdjustment, concentrationAdjustment)
 
 	return riskAdjustedSize, nil
 }
 
 func (s *RiskBasedStrategy) ShouldExecute(ctx context.Context, signal *models.CopySignal, originalTrade *models.Trade) (bool, error) {
-	// Risk-based strategy includes risk checks
-	// For example, don't execute if risk metrics are too high
+	risk, err := s.assessRisk(ctx, signal, originalTrade)
+	if err != nil {
+		return false, err
+	}
 
-	// Placeholder risk checks
-	maxRiskPerTrade := 0.02                                      // 2% risk per trade
-	tradeRisk := originalTrade.Size * originalTrade.Price * 0.01 // Assume 1% price risk
+	baseSize := originalTrade.Size * (signal.Relationship.AllocationPercent / 100.0)
+	if baseSize <= 0 {
+		return false, nil
+	}
 
-	if tradeRisk > maxRiskPerTrade*originalTrade.Size*originalTrade.Price {
-		s.log.Debugf("Skipping trade due to high risk: %.4f > %.4f", tradeRisk, maxRiskPerTrade*originalTrade.Size*originalTrade.Price)
+	// Sizing can scale risk down only as far as the minimum allocation; skip the trade
+	// when even that leaves more than the hard per-trade limit at risk
+	minimumFraction := math.Min(1.0, signal.Relationship.MinAllocation/baseSize)
+	maxRiskPerTrade := paramFloat(signal.Parameters, "max_risk_per_trade", 0.02) // 2% risk per trade by default
+	if minimumRisk := risk.PerTrade() * minimumFraction; minimumRisk > maxRiskPerTrade {
+		s.log.Debugf("Skipping trade due to high risk: %.4f > %.4f", minimumRisk, maxRiskPerTrade)
 		return false, nil
 	}
 
@@ -200,31 +329,80 @@ func (s *RiskBasedStrategy) ShouldExecute(ctx context.Context, signal *models.Co
 }
 
 func (s *RiskBasedStrategy) GetStrategyType() models.StrategyType {
-	return "risk_based"
+	return models.StrategyRiskBased
+}
+
+func (s *RiskBasedStrategy) ValidateParams(params models.StrategyParams) error {
+	if err := validateParams(params, map[string]paramRule{
+		"target_risk_per_trade":   {min: 0, max: 1, exclusiveMin: true},
+		"max_risk_per_trade":      {min: 0, max: 1, exclusiveMin: true},
+		"max_concentration":       {min: 0, max: 1, exclusiveMin: true},
+		"volatility_window_hours": {min: 2, max: 720, integer: true},
+	}); err != nil {
+		return err
+	}
+
+	if paramFloat(params, "target_risk_per_trade", 0.01) > paramFloat(params, "max_risk_per_trade", 0.02) {
+		return fmt.Errorf("target_risk_per_trade must not exceed max_risk_per_trade")
+	}
+	return nil
+}
+
+// realizedVolatility returns the standard deviation of log returns between consecutive
+// prices, or false when there are fewer than two returns
+func realizedVolatility(prices []float64) (float64, bool) {
+	var returns []float64
+	for i := 1; i < len(prices); i++ {
+		if prices[i-1] > 0 && prices[i] > 0 {
+			returns = append(returns, math.Log(prices[i]/prices[i-1]))
+		}
+	}
+
+	if len(returns) < 2 {
+		return 0, false
+	}
+
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	variance := 0.0
+	for _, r := range returns {
+		diff := r - mean
+		variance += diff * diff
+	}
+	variance /= float64(len(returns) - 1)
+
+	return math.Sqrt(variance), true
 }
 
 // MartingaleStrategy implements martingale-style position sizing
 type MartingaleStrategy struct {
-	log *logrus.Logger
+	log     *logrus.Logger
+	history HistoryProvider
 }
 
-func NewMartingaleStrategy(log *logrus.Logger) *MartingaleStrategy {
-	return &MartingaleStrategy{log: log}
+func NewMartingaleStrategy(log *logrus.Logger, history HistoryProvider) *MartingaleStrategy {
+	return &MartingaleStrategy{log: log, history: history}
 }
 
 func (s *MartingaleStrategy) CalculatePositionSize(ctx context.Context, signal *models.CopySignal, originalTrade *models.Trade) (float64, error) {
 	// Martingale strategy increases size after losses
 	baseSize := originalTrade.Size * (signal.Relationship.AllocationPercent / 100.0)
 
-	// Get recent performance to determine multiplier
-	// This would fetch actual trading history
-	consecutiveLosses := 0 // Placeholder - would fetch actual data
+	// Size up by the current losing streak; never guess a streak we can't read
+	history, err := s.history.GetHistory(ctx, signal.Relationship)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get performance history: %w", err)
+	}
 
-	multiplier := math.Pow(2, float64(consecutiveLosses))
+	multiplier := math.Pow(paramFloat(signal.Parameters, "multiplier", 2.0), float64(history.ConsecutiveLosses))
 	calculatedSize := baseSize * multiplier
 
 	// Apply maximum constraints to prevent unlimited growth
-	maxMultiplier := 4.0 // Maximum 4x base size
+	maxMultiplier := paramFloat(signal.Parameters, "max_multiplier", 4.0) // Maximum 4x base size by default
 	if multiplier > maxMultiplier {
 		calculatedSize = baseSize * maxMultiplier
 		s.log.Debugf("Applied maximum martingale multiplier for relationship %s", signal.Relationship.ID)
@@ -247,14 +425,17 @@ func (s *MartingaleStrategy) CalculatePositionSize(ctx context.Context, signal *
 
 func (s *MartingaleStrategy) ShouldExecute(ctx context.Context, signal *models.CopySignal, originalTrade *models.Trade) (bool, error) {
 	// Martingale strategy can include logic to pause after too many losses
-	maxConsecutiveLosses := 5 // Safety limit
+	maxConsecutiveLosses := int(paramFloat(signal.Parameters, "max_consecutive_losses", 5)) // Safety limit
 
-	// Get actual consecutive losses (placeholder)
-	consecutiveLosses := 0
+	// The safety limit fails closed: an unreadable history blocks execution
+	history, err := s.history.GetHistory(ctx, signal.Relationship)
+	if err != nil {
+		return false, fmt.Errorf("failed to get performance history: %w", err)
+	}
 
-	if consecutiveLosses >= maxConsecutiveLosses {
-		s.log.Warnf("Martingale strategy paused due to max consecutive losses for relationship %s",
-			signal.Relationship.ID)
+	if history.ConsecutiveLosses >= maxConsecutiveLosses {
+		s.log.Warnf("Martingale strategy paused after %d consecutive losses for relationship %s",
+			history.ConsecutiveLosses, signal.Relationship.ID)
 		return false, nil
 	}
 
@@ -262,37 +443,52 @@ func (s *MartingaleStrategy) ShouldExecute(ctx context.Context, signal *models.C
 }
 
 func (s *MartingaleStrategy) GetStrategyType() models.StrategyType {
-	return "martingale"
+	return models.StrategyMartingale
+}
+
+func (s *MartingaleStrategy) ValidateParams(params models.StrategyParams) error {
+	return validateParams(params, map[string]paramRule{
+		"multiplier":             {min: 1, max: 4, exclusiveMin: true},
+		"max_multiplier":         {min: 1, max: 16},
+		"max_consecutive_losses": {min: 1, max: 10, integer: true},
+	})
 }
 
 // AntiMartingaleStrategy implements anti-martingale position sizing
 type AntiMartingaleStrategy struct {
-	log *logrus.Logger
+	log     *logrus.Logger
+	history HistoryProvider
 }
 
-func NewAntiMartingaleStrategy(log *logrus.Logger) *AntiMartingaleStrategy {
-	return &AntiMartingaleStrategy{log: log}
+func NewAntiMartingaleStrategy(log *logrus.Logger, history HistoryProvider) *AntiMartingaleStrategy {
+	return &AntiMartingaleStrategy{log: log, history: history}
 }
 
 func (s *AntiMartingaleStrategy) CalculatePositionSize(ctx context.Context, signal *models.CopySignal, originalTrade *models.Trade) (float64, error) {
 	// Anti-martingale strategy increases size after wins
 	baseSize := originalTrade.Size * (signal.Relationship.AllocationPercent / 100.0)
 
-	// Get recent performance
-	consecutiveWins := 0 // Placeholder - would fetch actual data
+	// Get the current winning streak; without history treat it as no streak, which reduces size
+	consecutiveWins := 0
+	history, err := s.history.GetHistory(ctx, signal.Relationship)
+	if err != nil {
+		s.log.Warnf("Failed to get performance history for relationship %s: %v", signal.Relationship.ID, err)
+	} else {
+		consecutiveWins = history.ConsecutiveWins
+	}
 
-	multiplier := math.Pow(1.5, float64(consecutiveWins)) // Increase by 50% per win
+	multiplier := math.Pow(paramFloat(signal.Parameters, "multiplier", 1.5), float64(consecutiveWins)) // Increase by 50% per win by default
 	calculatedSize := baseSize * multiplier
 
 	// Apply maximum constraints
-	maxMultiplier := 3.0 // Maximum 3x base size
+	maxMultiplier := paramFloat(signal.Parameters, "max_multiplier", 3.0) // Maximum 3x base size by default
 	if multiplier > maxMultiplier {
 		calculatedSize = baseSize * maxMultiplier
 	}
 
 	// Reduce size after losses
 	if consecutiveWins == 0 {
-		calculatedSize = baseSize * 0.8 // Reduce by 20% after loss
+		calculatedSize = baseSize * paramFloat(signal.Parameters, "loss_reduction", 0.8) // Reduce by 20% after loss by default
 	}
 
 	// Apply hard limits
@@ -317,5 +513,68 @@ func (s *AntiMartingaleStrategy) ShouldExecute(ctx context.Context, signal *mode
 }
 
 func (s *AntiMartingaleStrategy) GetStrategyType() models.StrategyType {
-	return "anti_martingale"
+	return models.StrategyAntiMartingale
+}
+
+func (s *AntiMartingaleStrategy) ValidateParams(params models.StrategyParams) error {
+	return validateParams(params, map[string]paramRule{
+		"multiplier":     {min: 1, max: 4, exclusiveMin: true},
+		"max_multiplier": {min: 1, max: 16},
+		"loss_reduction": {min: 0, max: 1, exclusiveMin: true},
+	})
+}
+
+// paramRule bounds a numeric strategy parameter
+type paramRule struct {
+	min, max     float64
+	exclusiveMin bool
+	integer      bool
+}
+
+// validateParams rejects unknown keys and values outside their rule's bounds
+func validateParams(params models.StrategyParams, rules map[string]paramRule) error {
+	for key, value := range params {
+		rule, ok := rules[key]
+		if !ok {
+			return fmt.Errorf("unknown parameter %q", key)
+		}
+
+		v := paramFloat(params, key, math.NaN())
+		if math.IsNaN(v) {
+			return fmt.Errorf("parameter %q must be a number, got %v", key, value)
+		}
+		if v < rule.min || (rule.exclusiveMin && v == rule.min) || v > rule.max {
+			return fmt.Errorf("parameter %q is out of range: %v", key, v)
+		}
+		if rule.integer && v != math.Trunc(v) {
+			return fmt.Errorf("parameter %q must be an integer, got %v", key, v)
+		}
+	}
+
+	return nil
+}
+
+// paramFloat reads a numeric strategy parameter, falling back to def when unset or invalid
+func paramFloat(params map[string]interface{}, key string, def float64) float64 {
+	value, ok := params[key]
+	if !ok {
+		return def
+	}
+
+	switch v := value.(type) {
+	case float64:
+		return v
+	case float32:
+		return float64(v)
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+	}
+
+	return def
 }
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hyperdash/copy-engine/internal/models"
+)
+
+// maxResubmitBackoff caps the wait before a failed trade is submitted again
+const maxResubmitBackoff = time.Minute
+
+// queuedTrade is a Kafka or websocket trade waiting for the copy engine. These
+// transports have no redelivery to fall back on, so the ingestor resubmits failed
+// trades itself.
+type queuedTrade struct {
+	trade    *models.Trade
+	source   string // where the trade was read from, kept on its dead letter
+	done     func() // called once the trade was processed or dead-lettered
+	attempts int
+}
+
+// enqueue hands a trade to the engine once every earlier trade of its trader was
+// processed. A trader's trades are submitted one at a time, so a trade that fails
+// holds back the ones after it instead of letting them overtake it.
+func (i *tradeIngestor) enqueue(job *queuedTrade) {
+	traderID := *job.trade.TraderID
+
+	i.mu.Lock()
+	queue := i.queues[traderID]
+	i.queues[traderID] = append(queue, job)
+	i.mu.Unlock()
+
+	if len(queue) == 0 {
+		i.submitQueued(job)
+	}
+}
+
+func (i *tradeIngestor) submitQueued(job *queuedTrade) {
+	job.attempts++
+	err := i.engine.SubmitTrade(i.ctx, job.trade, func(err error) {
+		i.settle(job, err)
+	})
+	if err != nil {
+		if i.ctx.Err() != nil {
+			return
+		}
+		i.log.Errorf("Failed to ingest trade %s: %v", job.trade.ID, err)
+		i.settle(job, err)
+		return
+	}
+
+	i.record(func(m *IngestionMetrics) { m.Processed++ })
+}
+
+// settle resubmits a failed trade with backoff until it has used MaxAttempts, then
+// dead-letters it. Once the trade is processed or dead-lettered, the trader's next
+// trade is submitted.
+func (i *tradeIngestor) settle(job *queuedTrade, err error) {
+	if err != nil {
+		i.record(func(m *IngestionMetrics) { m.Failed++ })
+		if job.attempts < i.config.MaxAttempts || !i.deadLetterTrade(job, err) {
+			i.resubmit(job)
+			return
+		}
+	}
+
+	if job.done != nil {
+		job.done()
+	}
+
+	traderID := *job.trade.TraderID
+	i.mu.Lock()
+	queue := i.queues[traderID][1:]
+	if len(queue) == 0 {
+		delete(i.queues, traderID)
+	} else {
+		i.queues[traderID] = queue
+	}
+	i.mu.Unlock()
+
+	if len(queue) > 0 {
+		// settle runs on the engine's shard worker, which must not wait on its own queue
+		go i.submitQueued(queue[0])
+	}
+}
+
+func (i *tradeIngestor) resubmit(job *queuedTrade) {
+	backoff := maxResubmitBackoff
+	if job.attempts <= 6 {
+		backoff = time.Duration(1<<uint(job.attempts-1)) * time.Second
+	}
+
+	i.log.Warnf("Resubmitting trade %s in %s (attempt %d of %d)", job.trade.ID, backoff, job.attempts, i.config.MaxAttempts)
+	time.AfterFunc(backoff, func() {
+		if i.ctx.Err() != nil {
+			return
+		}
+		i.claimQueued(*job.trade.TraderID)
+		i.submitQueued(job)
+	})
+}
+
+// claimQueued keeps a held back trader's trades inside the dedup window, so a
+// redelivered fill is not admitted a second time while they wait
+func (i *tradeIngestor) claimQueued(traderID string) {
+	now := time.Now()
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for _, job := range i.queues[traderID] {
+		i.seen[job.trade.ID] = now
+	}
+}
+
+// deadLetterTrade stores a trade that used all its attempts. It reports false if
+// the trade could not be stored, in which case it keeps being resubmitted.
+func (i *tradeIngestor) deadLetterTrade(job *queuedTrade, lastErr error) bool {
+	ctx, cancel := context.WithTimeout(i.ctx, 5*time.Second)
+	defer cancel()
+
+	deadLetter := &models.TradeDeadLetter{
+		ID:        uuid.New().String(),
+		TradeID:   job.trade.ID,
+		TraderID:  *job.trade.TraderID,
+		Source:    job.source,
+		Trade:     job.trade,
+		Attempts:  job.attempts,
+		LastError: lastErr.Error(),
+		CreatedAt: time.Now(),
+	}
+	if err := i.postgres.CreateTradeDeadLetter(ctx, deadLetter); err != nil {
+		i.log.Errorf("Failed to dead-letter trade %s: %v", job.trade.ID, err)
+		return false
+	}
+
+	i.record(func(m *IngestionMetrics) { m.DeadLettered++ })
+	i.log.Errorf("Trade %s failed %d times, moved to dead letter %s: %v",
+		job.trade.ID, job.attempts, deadLetter.ID, lastErr)
+	return true
+}
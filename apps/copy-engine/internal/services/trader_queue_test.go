@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperdash/copy-engine/internal/config"
+	"github.com/hyperdash/copy-engine/internal/database"
+	"github.com/hyperdash/copy-engine/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// fakeTradeEngine processes submitted trades asynchronously, failing each trade
+// as many times as configured
+type fakeTradeEngine struct {
+	CopyEngine
+
+	mu        sync.Mutex
+	failures  map[string]int
+	submitted []string
+	processed []string
+	last      *models.Trade
+}
+
+func (f *fakeTradeEngine) SubmitTrade(ctx context.Context, trade *models.Trade, onProcessed func(error)) error {
+	f.mu.Lock()
+	f.submitted = append(f.submitted, trade.ID)
+	f.last = trade
+	var err error
+	if f.failures[trade.ID] != 0 {
+		f.failures[trade.ID]--
+		err = fmt.Errorf("database unavailable")
+	} else {
+		f.processed = append(f.processed, trade.ID)
+	}
+	f.mu.Unlock()
+
+	go onProcessed(err)
+	return nil
+}
+
+func (f *fakeTradeEngine) snapshot() (submitted, processed []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.submitted...), append([]string(nil), f.processed...)
+}
+
+func (f *fakeTradeEngine) lastTrade() *models.Trade {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.last
+}
+
+// fakeTradeDeadLetters records dead-lettered trades
+type fakeTradeDeadLetters struct {
+	database.PostgreSQL
+
+	mu          sync.Mutex
+	deadLetters []*models.TradeDeadLetter
+}
+
+func (f *fakeTradeDeadLetters) CreateTradeDeadLetter(ctx context.Context, deadLetter *models.TradeDeadLetter) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deadLetters = append(f.deadLetters, deadLetter)
+	return nil
+}
+
+func newTestQueueIngestor(t *testing.T, engine CopyEngine, postgres database.PostgreSQL, maxAttempts int) *tradeIngestor {
+	t.Helper()
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	return &tradeIngestor{
+		engine:   engine,
+		postgres: postgres,
+		config:   config.IngestionConfig{MaxAttempts: maxAttempts},
+		log:      log,
+		seen:     make(map[string]time.Time),
+		queues:   make(map[string][]*queuedTrade),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+func queuedTestTrade(id, traderID string, done chan<- string) *queuedTrade {
+	return &queuedTrade{
+		trade:  &models.Trade{ID: id, TraderID: &traderID, Size: 1, Price: 100},
+		source: "test",
+		done:   func() { done <- id },
+	}
+}
+
+func waitDone(t *testing.T, done <-chan string, count int) []string {
+	t.Helper()
+	var ids []string
+	timeout := time.After(5 * time.Second)
+	for len(ids) < count {
+		select {
+		case id := <-done:
+			ids = append(ids, id)
+		case <-timeout:
+			t.Fatalf("%d of %d trades finished: %v", len(ids), count, ids)
+		}
+	}
+	return ids
+}
+
+func TestFailedTradeHoldsBackLaterTradesOfItsTrader(t *testing.T) {
+	engine := &fakeTradeEngine{failures: map[string]int{"a1": 1}}
+	ingestor := newTestQueueIngestor(t, engine, &fakeTradeDeadLetters{}, 5)
+	done := make(chan string, 3)
+
+	ingestor.enqueue(queuedTestTrade("a1", "trader-a", done))
+	ingestor.enqueue(queuedTestTrade("a2", "trader-a", done))
+	ingestor.enqueue(queuedTestTrade("b1", "trader-b", done))
+
+	finished := waitDone(t, done, 3)
+	if finished[0] != "b1" {
+		t.Errorf("finished %v, want trader-b's trade first while trader-a's waits for its retry", finished)
+	}
+
+	submitted, processed := engine.snapshot()
+	var traderA []string
+	for _, id := range submitted {
+		if id != "b1" {
+			traderA = append(traderA, id)
+		}
+	}
+	if fmt.Sprint(traderA) != "[a1 a1 a2]" {
+		t.Errorf("trader-a submissions %v, want [a1 a1 a2]", traderA)
+	}
+	if fmt.Sprint(processed) != "[b1 a1 a2]" {
+		t.Errorf("processed %v, want [b1 a1 a2]", processed)
+	}
+}
+
+func TestTradeIsDeadLetteredAfterMaxAttempts(t *testing.T) {
+	engine := &fakeTradeEngine{failures: map[string]int{"a1": -1}}
+	deadLetters := &fakeTradeDeadLetters{}
+	ingestor := newTestQueueIngestor(t, engine, deadLetters, 2)
+	done := make(chan string, 2)
+
+	ingestor.enqueue(queuedTestTrade("a1", "trader-a", done))
+	ingestor.enqueue(queuedTestTrade("a2", "trader-a", done))
+
+	if finished := waitDone(t, done, 2); fmt.Sprint(finished) != "[a1 a2]" {
+		t.Fatalf("finished %v, want [a1 a2]", finished)
+	}
+
+	submitted, processed := engine.snapshot()
+	if fmt.Sprint(submitted) != "[a1 a1 a2]" {
+		t.Errorf("submitted %v, want [a1 a1 a2]", submitted)
+	}
+	if fmt.Sprint(processed) != "[a2]" {
+		t.Errorf("processed %v, want [a2]", processed)
+	}
+
+	if len(deadLetters.deadLetters) != 1 {
+		t.Fatalf("%d dead letters, want 1", len(deadLetters.deadLetters))
+	}
+	deadLetter := deadLetters.deadLetters[0]
+	if deadLetter.TradeID != "a1" || deadLetter.TraderID != "trader-a" || deadLetter.Attempts != 2 {
+		t.Errorf("dead letter %+v, want a1 of trader-a after 2 attempts", deadLetter)
+	}
+	if metrics := ingestor.GetMetrics(); metrics.Failed != 2 || metrics.DeadLettered != 1 {
+		t.Errorf("metrics %+v, want 2 failed and 1 dead-lettered", metrics)
+	}
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperdash/copy-engine/internal/config"
+	"github.com/hyperdash/copy-engine/internal/database"
+	"github.com/hyperdash/copy-engine/internal/exchange"
+	"github.com/hyperdash/copy-engine/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// FillStream is the exchange subscription the websocket ingestor reads trader fills
+// from. It is satisfied by *exchange.HyperliquidStream.
+type FillStream interface {
+	SetAddresses(addresses []string)
+	Run(ctx context.Context, handler exchange.StreamHandler) error
+}
+
+// NewWebSocketTradeIngestor creates an ingestor subscribing to the fills of every
+// trader with an active copy relationship directly on the exchange. The traders are
+// reloaded every RefreshInterval, so relationships created or stopped are picked up
+// without a restart.
+func NewWebSocketTradeIngestor(stream FillStream, postgres database.PostgreSQL, engine CopyEngine, cfg config.IngestionConfig, log *logrus.Logger) TradeIngestor {
+	return &tradeIngestor{
+		stream:   stream,
+		postgres: postgres,
+		engine:   engine,
+		config:   cfg,
+		log:      log,
+		seen:     make(map[string]time.Time),
+		inFlight: make(map[string]struct{}),
+		traders:  make(map[string]string),
+		queues:   make(map[string][]*queuedTrade),
+	}
+}
+
+// runWebSocket keeps the fill subscriptions in line with the active relationships
+// while the stream delivers to the ingestor
+func (i *tradeIngestor) runWebSocket() {
+	defer i.wg.Done()
+
+	i.wg.Add(1)
+	go func() {
+		defer i.wg.Done()
+		i.stream.Run(i.ctx, i)
+	}()
+
+	refreshTicker := time.NewTicker(time.Duration(i.config.RefreshInterval) * time.Second)
+	defer refreshTicker.Stop()
+
+	maintenanceTicker := time.NewTicker(dedupWindow)
+	defer maintenanceTicker.Stop()
+
+	for {
+		select {
+		case <-i.ctx.Done():
+			return
+		case <-refreshTicker.C:
+			if err := i.refreshTraders(); err != nil && i.ctx.Err() == nil {
+				i.log.Errorf("Failed to refresh subscribed traders: %v", err)
+			}
+		case <-maintenanceTicker.C:
+			i.maintain()
+		}
+	}
+}
+
+// refreshTraders subscribes to the traders that currently have an active copy
+// relationship and drops the rest
+func (i *tradeIngestor) refreshTraders() error {
+	ctx, cancel := context.WithTimeout(i.ctx, 10*time.Second)
+	defer cancel()
+
+	addresses, err := i.postgres.GetActiveTraderAddresses(ctx)
+	if err != nil {
+		return err
+	}
+
+	traders := make(map[string]string, len(addresses))
+	subscribed := make([]string, 0, len(addresses))
+	for traderID, address := range addresses {
+		address = strings.ToLower(address)
+		traders[address] = traderID
+		subscribed = append(subscribed, address)
+	}
+
+	i.mu.Lock()
+	changed := len(traders) != len(i.traders)
+	for address, traderID := range traders {
+		if i.traders[address] != traderID {
+			changed = true
+		}
+	}
+	i.traders = traders
+	i.mu.Unlock()
+
+	i.stream.SetAddresses(subscribed)
+	if changed {
+		i.log.Infof("Subscribed to fills of %d traders", len(subscribed))
+	}
+
+	return nil
+}
+
+// OnFills hands a trader's new fills to the copy engine in the order they happened
+func (i *tradeIngestor) OnFills(address string, fills []*exchange.Fill) {
+	i.mu.Lock()
+	traderID, ok := i.traders[address]
+	i.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	for _, fill := range fills {
+		trade, ok := i.admit(&database.TradeEvent{
+			Type:      "trade",
+			TraderID:  traderID,
+			Trade:     tradeFromFill(traderID, fill),
+			Timestamp: fill.Time,
+		})
+		if !ok {
+			continue
+		}
+
+		i.enqueue(&queuedTrade{trade: trade, source: "websocket " + address})
+	}
+}
+
+// OnOrderUpdates counts trader order updates. They carry no user, so they cannot be
+// attributed to a trader once more than one is subscribed.
+func (i *tradeIngestor) OnOrderUpdates(updates []*exchange.OrderUpdate) {
+	i.record(func(m *IngestionMetrics) { m.OrderUpdates += uint64(len(updates)) })
+}
+
+// tradeFromFill converts a trader's exchange fill into the trade the copy engine
+// expects. The trade ID is derived from the trader and the exchange trade ID, so the
+// same fill seen twice maps to the same trade while both counterparties of a trade,
+// which share its ID, stay distinct.
+func tradeFromFill(traderID string, fill *exchange.Fill) *models.Trade {
+	side := models.TradeSell
+	if fill.Side == exchange.SideBuy {
+		side = models.TradeBuy
+	}
+
+	trade := &models.Trade{
+		ID:          fmt.Sprintf("hyperliquid:%s:%d", traderID, fill.TradeID),
+		TraderID:    &traderID,
+		TokenSymbol: fill.Symbol,
+		Side:        side,
+		Size:        fill.Size,
+		Price:       fill.Price,
+		Fee:         fill.Fee,
+		RealizedPnL: fill.ClosedPnL,
+		CreatedAt:   fill.Time,
+	}
+	if fill.Hash != "" {
+		hash := fill.Hash
+		trade.TransactionHash = &hash
+	}
+
+	return trade
+}
@@ -0,0 +1,22 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/hyperdash/copy-engine/internal/exchange"
+)
+
+func TestTradeFromFillKeepsCounterpartiesApart(t *testing.T) {
+	buy := &exchange.Fill{Symbol: "ETH", Side: exchange.SideBuy, Size: 0.5, Price: 3201.1, TradeID: 118906512037719}
+	sell := &exchange.Fill{Symbol: "ETH", Side: exchange.SideSell, Size: 0.5, Price: 3201.1, TradeID: 118906512037719}
+
+	buyer := tradeFromFill("trader-1", buy)
+	seller := tradeFromFill("trader-2", sell)
+	if buyer.ID == seller.ID {
+		t.Errorf("both sides of trade %d map to %s", buy.TradeID, buyer.ID)
+	}
+
+	if again := tradeFromFill("trader-1", buy); again.ID != buyer.ID {
+		t.Errorf("the same fill mapped to %s and %s", buyer.ID, again.ID)
+	}
+}
@@ -0,0 +1,7 @@
+-- Sizing strategies selected per copy relationship by the copy engine
+ALTER TABLE copy_strategies ADD COLUMN IF NOT EXISTS relationship_id uuid;
+ALTER TABLE copy_strategies ADD COLUMN IF NOT EXISTS strategy_type text NOT NULL DEFAULT 'proportional';
+ALTER TABLE copy_strategies ADD COLUMN IF NOT EXISTS parameters jsonb DEFAULT '{}';
+ALTER TABLE copy_strategies ADD COLUMN IF NOT EXISTS is_active boolean NOT NULL DEFAULT true;
+
+CREATE INDEX IF NOT EXISTS idx_copy_strategies_relationship ON copy_strategies(relationship_id, is_active);
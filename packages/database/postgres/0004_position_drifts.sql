@@ -0,0 +1,15 @@
+-- Differences between stored follower positions and the exchange, found by the copy engine reconciler
+CREATE TABLE IF NOT EXISTS position_drifts (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  follower_id uuid NOT NULL,
+  relationship_id uuid,
+  token_symbol text NOT NULL,
+  recorded_size numeric(30, 10) NOT NULL,
+  exchange_size numeric(30, 10) NOT NULL,
+  expected_size numeric(30, 10),
+  action text NOT NULL,
+  detected_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_position_drifts_follower ON position_drifts(follower_id, detected_at);
+CREATE INDEX IF NOT EXISTS idx_position_drifts_action ON position_drifts(action);
@@ -0,0 +1,20 @@
+-- Copy executions are unique per copied trade and relationship, so redelivered
+-- trader fills can never be copied twice
+CREATE TABLE IF NOT EXISTS copy_executions (
+  id uuid PRIMARY KEY,
+  signal_id uuid NOT NULL,
+  relationship_id uuid NOT NULL,
+  trade_id uuid,
+  status text NOT NULL,
+  error_message text,
+  parameters jsonb DEFAULT '{}',
+  created_at timestamp NOT NULL DEFAULT now(),
+  updated_at timestamp NOT NULL DEFAULT now()
+);
+
+ALTER TABLE copy_executions ADD COLUMN IF NOT EXISTS original_trade_id text;
+ALTER TABLE copy_executions ALTER COLUMN trade_id DROP NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_copy_executions_original_trade
+  ON copy_executions(original_trade_id, relationship_id)
+  WHERE original_trade_id IS NOT NULL;
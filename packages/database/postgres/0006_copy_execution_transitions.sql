@@ -0,0 +1,12 @@
+-- Every status change of a copy execution, in order
+CREATE TABLE IF NOT EXISTS copy_execution_transitions (
+  id uuid PRIMARY KEY,
+  execution_id uuid NOT NULL REFERENCES copy_executions(id) ON DELETE CASCADE,
+  from_status text,
+  to_status text NOT NULL,
+  reason text,
+  created_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_copy_execution_transitions_execution
+  ON copy_execution_transitions(execution_id, created_at);
@@ -0,0 +1,18 @@
+-- Copy executions that exhausted their retries, kept for inspection and replay
+CREATE TABLE IF NOT EXISTS copy_execution_dead_letters (
+  id uuid PRIMARY KEY,
+  execution_id uuid NOT NULL REFERENCES copy_executions(id) ON DELETE CASCADE,
+  relationship_id uuid NOT NULL,
+  original_trade jsonb NOT NULL,
+  copy_size numeric(30, 10) NOT NULL,
+  attempts integer NOT NULL,
+  last_error text,
+  created_at timestamp NOT NULL DEFAULT now(),
+  replayed_at timestamp
+);
+
+CREATE INDEX IF NOT EXISTS idx_copy_execution_dead_letters_pending
+  ON copy_execution_dead_letters(created_at)
+  WHERE replayed_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_copy_executions_status ON copy_executions(status);
@@ -0,0 +1,18 @@
+-- Events written in the same transaction as the change they describe, published
+-- to downstream consumers by the copy engine's outbox publisher
+CREATE TABLE IF NOT EXISTS event_outbox (
+  id uuid PRIMARY KEY,
+  event_type varchar(100) NOT NULL,
+  schema_version integer NOT NULL,
+  aggregate_id text NOT NULL,
+  payload jsonb NOT NULL,
+  occurred_at timestamp NOT NULL,
+  seq bigserial NOT NULL,
+  attempts integer NOT NULL DEFAULT 0,
+  last_error text,
+  published_at timestamp
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_outbox_unpublished
+  ON event_outbox(seq)
+  WHERE published_at IS NULL;
@@ -0,0 +1,15 @@
+-- Trader trades the ingestor stopped resubmitting after repeated failures, kept for
+-- inspection. Later trades of the same trader were held back until they landed here.
+CREATE TABLE IF NOT EXISTS trade_dead_letters (
+  id uuid PRIMARY KEY,
+  trade_id text NOT NULL,
+  trader_id text NOT NULL,
+  source text NOT NULL,
+  trade jsonb NOT NULL,
+  attempts integer NOT NULL,
+  last_error text,
+  created_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_trade_dead_letters_trader
+  ON trade_dead_letters(trader_id, created_at);
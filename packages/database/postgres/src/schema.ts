@@ -272,6 +272,12 @@ export const copyStrategies = pgTable(
       onDelete: 'set null',
     }),
 
+    // Per-relationship sizing used by the copy engine
+    relationshipId: uuid('relationship_id'),
+    strategyType: text('strategy_type').default('proportional').notNull(), // see copy-engine models.StrategyType
+    parameters: jsonb('parameters').default('{}'),
+    isActive: boolean('is_active').default(true).notNull(),
+
     // Metadata
     metadata: jsonb('metadata').default('{}'),
     createdAt: timestamp('created_at').defaultNow(),
@@ -279,6 +285,7 @@ export const copyStrategies = pgTable(
   },
   (table) => ({
     userIdIdx: index('idx_copy_strategies_user_id').on(table.userId),
+    relationshipIdx: index('idx_copy_strategies_relationship').on(table.relationshipId, table.isActive),
     statusIdx: index('idx_copy_strategies_status').on(table.status),
     agentWalletIdx: index('idx_copy_strategies_agent_wallet').on(table.agentWalletId),
     totalPnlIdx: index('idx_copy_strategies_total_pnl').on(table.totalPnl),
@@ -84,9 +84,108 @@ export const CopyStrategySchema = z.object({
   alignmentRate: z.number(),
 });
 
+// Kafka Topics
+export const KafkaTopics = {
+  // Trader fills consumed by the copy engine, keyed by trader_id so each trader's
+  // fills stay in order within a partition
+  TRADER_FILLS: 'trader-fills',
+} as const;
+
+// Trader Fill Event Schema (topic trader-fills)
+export const TraderFillEventSchema = z.object({
+  type: z.literal('trade'),
+  trader_id: z.string(),
+  trade: z.object({
+    id: z.string(),
+    trader_id: z.string().nullable().optional(),
+    position_id: z.string().nullable().optional(),
+    token_symbol: z.string(),
+    side: z.enum(['buy', 'sell']),
+    size: z.number().positive(),
+    price: z.number().positive(),
+    fee: z.number(),
+    realized_pnl: z.number(),
+    transaction_hash: z.string().nullable().optional(),
+    block_number: z.number().nullable().optional(),
+    created_at: z.string(),
+    is_copy_trade: z.boolean().optional(),
+  }),
+  timestamp: z.string(),
+  data: z.record(z.unknown()).nullable().optional(),
+});
+
+// Copy Engine Event Schemas (schema_version 1)
+const CopyEngineEventEnvelope = {
+  id: z.string(),
+  schema_version: z.literal(1),
+  aggregate_id: z.string(),
+  occurred_at: z.string(),
+};
+
+export const CopyExecutionEventSchema = z.object({
+  ...CopyEngineEventEnvelope,
+  type: z.literal('copy_execution.status_changed'),
+  data: z.object({
+    execution_id: z.string(),
+    relationship_id: z.string(),
+    follower_id: z.string().optional(),
+    trader_id: z.string().optional(),
+    original_trade_id: z.string().optional(),
+    trade_id: z.string().optional(),
+    from_status: z.string().optional(),
+    status: z.enum(['pending', 'executing', 'retrying', 'completed', 'failed', 'cancelled']),
+    terminal: z.boolean(),
+    reason: z.string().optional(),
+    copy_size: z.number().optional(),
+    error: z.string().optional(),
+  }),
+});
+
+export const PositionEventSchema = z.object({
+  ...CopyEngineEventEnvelope,
+  type: z.enum(['position.opened', 'position.changed', 'position.closed']),
+  data: z.object({
+    position_id: z.string(),
+    user_id: z.string().optional(),
+    trader_id: z.string().optional(),
+    copy_relationship_id: z.string().optional(),
+    token_symbol: z.string(),
+    side: z.enum(['long', 'short']),
+    size: z.number(),
+    entry_price: z.number(),
+    leverage: z.number(),
+    unrealized_pnl: z.number(),
+    is_copy_trade: z.boolean(),
+  }),
+});
+
+export const RiskBreachEventSchema = z.object({
+  ...CopyEngineEventEnvelope,
+  type: z.literal('risk.breach'),
+  data: z.object({
+    execution_id: z.string().optional(),
+    relationship_id: z.string(),
+    follower_id: z.string().optional(),
+    reason: z.string(),
+    message: z.string(),
+  }),
+});
+
+export const CopyEngineEventSchema = z.union([
+  CopyExecutionEventSchema,
+  PositionEventSchema,
+  RiskBreachEventSchema,
+]);
+
 // Export types
 export type MarketOverview = z.infer<typeof MarketOverviewSchema>;
 export type OHLCV = z.infer<typeof OHLCVSchema>;
 export type HeatmapBin = z.infer<typeof HeatmapBinSchema>;
 export type Trader = z.infer<typeof TraderSchema>;
 export type CopyStrategy = z.infer<typeof CopyStrategySchema>;
+export type KafkaTopic = (typeof KafkaTopics)[keyof typeof KafkaTopics];
+export type TraderFillEvent = z.infer<typeof TraderFillEventSchema>;
+export type CopyExecutionEvent = z.infer<typeof CopyExecutionEventSchema>;
+export type PositionEvent = z.infer<typeof PositionEventSchema>;
+export type RiskBreachEvent = z.infer<typeof RiskBreachEventSchema>;
+export type CopyEngineEvent = z.infer<typeof CopyEngineEventSchema>;